@@ -13,15 +13,26 @@ import (
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
+// @title Shopcart API
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description Access token issued by /auth/login, sent as "Bearer <token>"
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+// @description API key issued by an administrator for integrations
 func NewApi(r *gin.Engine, cfg *config.Config, store storage.StorageI, logger logger.LoggerI) {
 
 	handler := handler.NewHandler(cfg, store, logger)
+	router := r.Group("", handler.AuthMiddleware(), handler.IdempotencyMiddleware())
 
-	r.POST("/book", handler.CreateBook)
-	r.GET("/book/:id", handler.GetByIdBook)
-	r.GET("/book", handler.GetListBook)
-	r.PUT("/book/:id", handler.UpdateBook)
-	r.DELETE("/book/:id", handler.DeleteBook)
+	router.POST("/book", handler.Permission("book.write"), handler.CreateBook)
+	router.GET("/book/:id", handler.Permission("book.read"), handler.GetByIdBook)
+	router.GET("/book", handler.Permission("book.read"), handler.GetListBook)
+	router.PUT("/book/:id", handler.Permission("book.write"), handler.UpdateBook)
+	router.DELETE("/book/:id", handler.Permission("book.write"), handler.DeleteBook)
+	router.POST("/book/:id/restore", handler.Permission("book.write"), handler.RestoreBook)
 
 	url := ginSwagger.URL("swagger/doc.json") // The url pointing to API definition
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler, url))
@@ -30,77 +41,161 @@ func NewApi(r *gin.Engine, cfg *config.Config, store storage.StorageI, logger lo
 func NewApiUser(r *gin.Engine, cfg *config.Config, store storage.StorageI, logger logger.LoggerI) {
 
 	handler := handler.NewHandler(cfg, store, logger)
-
-	r.POST("/user", handler.CreateUser)
-	r.GET("/user", handler.GetListUSer)
-	r.GET("/user/:id", handler.GetByIDUser)
-	r.PUT("/user/:id", handler.UpdateUser)
-	r.DELETE("/user/:id", handler.DeleteUser)
+	router := r.Group("", handler.AuthMiddleware(), handler.IdempotencyMiddleware())
+
+	router.POST("/user", handler.Permission("user.write"), handler.CreateUser)
+	router.GET("/user", handler.Permission("user.read"), handler.GetListUSer)
+	router.GET("/user/:id", handler.Permission("user.read"), handler.GetByIDUser)
+	router.PUT("/user/:id", handler.Permission("user.write"), handler.UpdateUser)
+	router.DELETE("/user/:id", handler.Permission("user.write"), handler.DeleteUser)
+	router.POST("/user/:id/restore", handler.Permission("user.write"), handler.RestoreUser)
+	router.POST("/user/:id/top-up", handler.Permission("balance.write"), handler.TopUpBalance)
+	router.GET("/user/:id/transactions", handler.Permission("balance.read"), handler.GetListBalanceTransactions)
+	router.PUT("/user/:id/role", handler.Permission("role.write"), handler.AssignUserRole)
+	router.GET("/role", handler.Permission("role.read"), handler.GetListRoles)
 }
 
 func NewApiAuthor(r *gin.Engine, cfg *config.Config, store storage.StorageI, logger logger.LoggerI) {
 
 	handler := handler.NewHandler(cfg, store, logger)
-
-	r.POST("/author", handler.CreateAuhtor)
-	r.GET("/author", handler.GetListAuthor)
-	r.GET("/author/:id", handler.AuthorGetById)
-	r.PUT("/author/:id", handler.UpdateAuthor)
-	r.DELETE("/author/:id", handler.DeleteAuthor)
+	router := r.Group("", handler.AuthMiddleware(), handler.IdempotencyMiddleware())
+
+	router.POST("/author", handler.Permission("author.write"), handler.CreateAuhtor)
+	router.GET("/author", handler.Permission("author.read"), handler.GetListAuthor)
+	router.GET("/author/:id", handler.Permission("author.read"), handler.AuthorGetById)
+	router.PUT("/author/:id", handler.Permission("author.write"), handler.UpdateAuthor)
+	router.DELETE("/author/:id", handler.Permission("author.write"), handler.DeleteAuthor)
+	router.POST("/author/:id/restore", handler.Permission("author.write"), handler.RestoreAuthor)
 }
 
 func NewApiCustomer(r *gin.Engine, cfg *config.Config, store storage.StorageI, logger logger.LoggerI) {
 
 	handler := handler.NewHandler(cfg, store, logger)
-
-	r.POST("/customer", handler.CreateCustomer)
-	r.GET("/customer/:id", handler.GetByIdCustomer)
-	r.GET("/customer", handler.GetListCustomer)
-	r.PUT("/customer/:id", handler.UpdateCustomer)
-	r.DELETE("/customer/:id", handler.DeleteCustomer)
+	router := r.Group("", handler.AuthMiddleware(), handler.IdempotencyMiddleware())
+
+	router.POST("/customer", handler.Permission("customer.write"), handler.CreateCustomer)
+	router.GET("/customer/:id", handler.Permission("customer.read"), handler.GetByIdCustomer)
+	router.GET("/customer", handler.Permission("customer.read"), handler.GetListCustomer)
+	router.PUT("/customer/:id", handler.Permission("customer.write"), handler.UpdateCustomer)
+	router.DELETE("/customer/:id", handler.Permission("customer.write"), handler.DeleteCustomer)
+	router.POST("/customer/:id/restore", handler.Permission("customer.write"), handler.RestoreCustomer)
+	router.POST("/customer/:id/addresses", handler.Permission("address.write"), handler.CreateCustomerAddress)
+	router.GET("/customer/:id/addresses", handler.Permission("address.read"), handler.GetListCustomerAddress)
+	router.GET("/customer/:id/addresses/:address_id", handler.Permission("address.read"), handler.GetByIdCustomerAddress)
+	router.PUT("/customer/:id/addresses/:address_id", handler.Permission("address.write"), handler.UpdateCustomerAddress)
+	router.DELETE("/customer/:id/addresses/:address_id", handler.Permission("address.write"), handler.DeleteCustomerAddress)
 }
 
 func NewApiCourier(r *gin.Engine, cfg *config.Config, store storage.StorageI, logger logger.LoggerI) {
 
 	handler := handler.NewHandler(cfg, store, logger)
-
-	r.POST("/courier", handler.CreateCourier)
-	r.GET("/courier/:id", handler.GetByIDCourier)
-	r.GET("/courier", handler.GetListCourier)
-	r.PUT("/courier/:id", handler.UpdateCourier)
-	r.DELETE("/courier/:id", handler.DeleteCourier)
+	router := r.Group("", handler.AuthMiddleware(), handler.IdempotencyMiddleware())
+
+	router.POST("/courier", handler.Permission("courier.write"), handler.CreateCourier)
+	router.GET("/courier/:id", handler.Permission("courier.read"), handler.GetByIDCourier)
+	router.GET("/courier", handler.Permission("courier.read"), handler.GetListCourier)
+	router.PUT("/courier/:id", handler.Permission("courier.write"), handler.UpdateCourier)
+	router.DELETE("/courier/:id", handler.Permission("courier.write"), handler.DeleteCourier)
+	router.POST("/courier/:id/restore", handler.Permission("courier.write"), handler.RestoreCourier)
+	router.POST("/courier/:id/location", handler.Permission("courier.location"), handler.CreateCourierLocation)
+	router.GET("/courier/:id/location", handler.Permission("courier.read"), handler.GetCourierLocation)
+	router.GET("/courier/:id/track", handler.Permission("courier.read"), handler.GetCourierTrack)
 }
 
 func NewApiProduct(r *gin.Engine, cfg *config.Config, store storage.StorageI, logger logger.LoggerI) {
 
 	handler := handler.NewHandler(cfg, store, logger)
-
-	r.POST("/product", handler.CreateProduct)
-	r.GET("/product/:id", handler.GetByIdProduct)
-	r.GET("/product", handler.GetListProduct)
-	r.PUT("/product/:id", handler.UpdateProduct)
-	r.DELETE("/product/:id", handler.DeleteProduct)
+	router := r.Group("", handler.AuthMiddleware(), handler.IdempotencyMiddleware())
+
+	router.POST("/product", handler.Permission("product.write"), handler.CreateProduct)
+	router.GET("/product/:id", handler.Permission("product.read"), handler.GetByIdProduct)
+	router.GET("/product", handler.Permission("product.read"), handler.GetListProduct)
+	router.PUT("/product/:id", handler.Permission("product.write"), handler.UpdateProduct)
+	router.DELETE("/product/:id", handler.Permission("product.write"), handler.DeleteProduct)
+	router.POST("/product/:id/restore", handler.Permission("product.write"), handler.RestoreProduct)
 }
 
 func NewApiCategory(r *gin.Engine, cfg *config.Config, store storage.StorageI, logger logger.LoggerI) {
 
 	handler := handler.NewHandler(cfg, store, logger)
-
-	r.POST("/category", handler.CreateCategory)
-	r.GET("/category/:id", handler.GetByIdCategory)
-	r.GET("/category", handler.GetListCategory)
-	r.PUT("/category/:id", handler.UpdateCategory)
-	r.DELETE("/category/:id", handler.DeleteCategory)
+	router := r.Group("", handler.AuthMiddleware(), handler.IdempotencyMiddleware())
+
+	router.POST("/category", handler.Permission("category.write"), handler.CreateCategory)
+	router.GET("/category/:id", handler.Permission("category.read"), handler.GetByIdCategory)
+	router.GET("/category", handler.Permission("category.read"), handler.GetListCategory)
+	router.PUT("/category/:id", handler.Permission("category.write"), handler.UpdateCategory)
+	router.DELETE("/category/:id", handler.Permission("category.write"), handler.DeleteCategory)
+	router.POST("/category/:id/restore", handler.Permission("category.write"), handler.RestoreCategory)
 }
 
 func NewApiOrder(r *gin.Engine, cfg *config.Config, store storage.StorageI, logger logger.LoggerI) {
 
 	handler := handler.NewHandler(cfg, store, logger)
+	router := r.Group("", handler.AuthMiddleware(), handler.IdempotencyMiddleware())
+
+	router.POST("/order", handler.Permission("order.create"), handler.CreateOrder)
+	router.GET("/order/:id", handler.Permission("order.read"), handler.GetByIdOrder)
+	router.GET("/order", handler.Permission("order.read"), handler.GetListOrders)
+	router.PUT("/order/:id", handler.Permission("order.write"), handler.UpdateOrder)
+	router.DELETE("/order/:id", handler.Permission("order.write"), handler.DeleteOrder)
+	router.PATCH("/order/:id", handler.Permission("order.write"), handler.UpdatePatchOrder)
+	router.POST("/order/:id/status", handler.Permission("order.status"), handler.UpdateOrderStatus)
+	router.POST("/order/:id/assign", handler.Permission("order.assign"), handler.AssignOrderCourier)
+	router.GET("/order/:id/status-history", handler.Permission("order.read"), handler.GetOrderStatusHistory)
+	router.GET("/order/:id/stream", handler.Permission("order.read"), handler.StreamOrder)
+}
+
+func NewApiCart(r *gin.Engine, cfg *config.Config, store storage.StorageI, logger logger.LoggerI) {
+
+	handler := handler.NewHandler(cfg, store, logger)
+	router := r.Group("", handler.AuthMiddleware(), handler.IdempotencyMiddleware())
+
+	router.POST("/cart", handler.Permission("cart.write"), handler.CreateCart)
+	router.GET("/cart/:id", handler.Permission("cart.read"), handler.GetByIdCart)
+	router.POST("/cart/:id/items", handler.Permission("cart.write"), handler.AddCartItem)
+	router.PUT("/cart/:id/items/:product_id", handler.Permission("cart.write"), handler.UpdateCartItem)
+	router.DELETE("/cart/:id/items/:product_id", handler.Permission("cart.write"), handler.DeleteCartItem)
+	router.POST("/cart/:id/checkout", handler.Permission("cart.write"), handler.CheckoutCart)
+}
+
+func NewApiDelivery(r *gin.Engine, cfg *config.Config, store storage.StorageI, logger logger.LoggerI) {
+
+	handler := handler.NewHandler(cfg, store, logger)
+
+	r.GET("/delivery/quote", handler.DeliveryQuote)
+}
+
+func NewApiDeliveryZone(r *gin.Engine, cfg *config.Config, store storage.StorageI, logger logger.LoggerI) {
+
+	handler := handler.NewHandler(cfg, store, logger)
+	router := r.Group("", handler.AuthMiddleware(), handler.IdempotencyMiddleware())
 
-	r.POST("/order", handler.CreateOrder)
-	r.GET("/order/:id", handler.GetByIdOrder)
-	r.GET("/order", handler.GetListOrders)
-	r.PUT("/order/:id", handler.UpdateOrder)
-	r.DELETE("/order/:id", handler.DeleteOrder)
-	r.PATCH("/order/:id", handler.UpdatePatchOrder)
-}
\ No newline at end of file
+	router.POST("/delivery-zone", handler.Permission("delivery_zone.write"), handler.CreateDeliveryZone)
+	router.GET("/delivery-zone/:id", handler.Permission("delivery_zone.read"), handler.GetByIdDeliveryZone)
+	router.GET("/delivery-zone", handler.Permission("delivery_zone.read"), handler.GetListDeliveryZone)
+	router.PUT("/delivery-zone/:id", handler.Permission("delivery_zone.write"), handler.UpdateDeliveryZone)
+	router.DELETE("/delivery-zone/:id", handler.Permission("delivery_zone.write"), handler.DeleteDeliveryZone)
+}
+
+func NewApiAuth(r *gin.Engine, cfg *config.Config, store storage.StorageI, logger logger.LoggerI) {
+
+	handler := handler.NewHandler(cfg, store, logger)
+
+	r.POST("/auth/register", handler.Register)
+	r.POST("/auth/login", handler.Login)
+	r.POST("/auth/refresh", handler.RefreshToken)
+	r.POST("/auth/otp/request", handler.RequestOtp)
+	r.POST("/auth/otp/verify", handler.VerifyOtp)
+	r.GET("/me", handler.AuthMiddleware(), handler.Me)
+}
+
+func NewApiApiKey(r *gin.Engine, cfg *config.Config, store storage.StorageI, logger logger.LoggerI) {
+
+	handler := handler.NewHandler(cfg, store, logger)
+	router := r.Group("", handler.AuthMiddleware(), handler.IdempotencyMiddleware())
+
+	router.POST("/api-key", handler.Permission("api_key.write"), handler.CreateApiKey)
+	router.GET("/api-key", handler.Permission("api_key.read"), handler.GetListApiKey)
+	router.GET("/api-key/:id", handler.Permission("api_key.read"), handler.GetByIdApiKey)
+	router.POST("/api-key/:id/revoke", handler.Permission("api_key.write"), handler.RevokeApiKey)
+}
@@ -0,0 +1,976 @@
+package api
+
+import (
+	"app/api/handler"
+	"app/api/models"
+	"app/config"
+	"app/pkg/delivery"
+	"app/pkg/helper"
+	"app/pkg/logger"
+	"app/storage"
+	"app/storage/memory"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	testAdminLogin    = "admin_user"
+	testAdminPassword = "admin-password"
+)
+
+// testServer is the full router backed by the memory storage.
+type testServer struct {
+	t      *testing.T
+	cfg    *config.Config
+	router *gin.Engine
+	store  storage.StorageI
+}
+
+// testResponse is the envelope written by every handler, with the data kept
+// raw so each test decodes it into the model it expects.
+type testResponse struct {
+	Status      int
+	Description string
+	Code        string
+	Data        json.RawMessage
+}
+
+func newTestServer(t *testing.T) *testServer {
+
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+
+	tiers, err := delivery.ParseTiers("3:5000,7:10000,15:15000")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{
+		StorageDriver:          "memory",
+		MemoryAdminLogin:       testAdminLogin,
+		MemoryAdminPassword:    testAdminPassword,
+		DefaultLimit:           10,
+		CourierMaxActiveOrders: 3,
+		CourierLocationTTL:     30,
+		EventBufferSize:        16,
+		StreamHeartbeat:        15,
+		StoreLatitude:          41.311081,
+		StoreLongitude:         69.240562,
+		DeliveryTiers:          tiers,
+		JWTSecret:              "test-secret",
+		AccessTokenTTL:         15,
+		RefreshTokenTTL:        24,
+		SmsDriver:              "log",
+		OtpLength:              6,
+		OtpTTL:                 5,
+		OtpMaxAttempts:         5,
+		OtpResendInterval:      60,
+		IdempotencyTTL:         24,
+		TxMaxAttempts:          3,
+	}
+
+	store, err := memory.NewStore(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log := logger.NewLogger("test", logger.LevelPanic)
+
+	r := gin.New()
+
+	NewApi(r, cfg, store, log)
+	NewApiAuth(r, cfg, store, log)
+	NewApiUser(r, cfg, store, log)
+	NewApiAuthor(r, cfg, store, log)
+	NewApiCustomer(r, cfg, store, log)
+	NewApiCourier(r, cfg, store, log)
+	NewApiProduct(r, cfg, store, log)
+	NewApiCategory(r, cfg, store, log)
+	NewApiOrder(r, cfg, store, log)
+	NewApiCart(r, cfg, store, log)
+	NewApiDelivery(r, cfg, store, log)
+	NewApiDeliveryZone(r, cfg, store, log)
+	NewApiApiKey(r, cfg, store, log)
+
+	return &testServer{t: t, cfg: cfg, router: r, store: store}
+}
+
+// request sends a JSON request with an optional bearer token and extra
+// headers given as name, value pairs.
+func (s *testServer) request(method, path, token string, body interface{}, headers ...string) testResponse {
+
+	s.t.Helper()
+
+	var payload bytes.Buffer
+
+	if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			s.t.Fatal(err)
+		}
+	}
+
+	req := httptest.NewRequest(method, path, &payload)
+	req.Header.Set("Content-Type", "application/json")
+
+	if len(token) > 0 {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+
+	var resp testResponse
+
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		s.t.Fatalf("%s %s: invalid response %q: %v", method, path, rec.Body.String(), err)
+	}
+
+	if resp.Status != rec.Code {
+		s.t.Fatalf("%s %s: envelope status %d, HTTP status %d", method, path, resp.Status, rec.Code)
+	}
+
+	return resp
+}
+
+// expect sends a request, fails the test unless it answers with status and
+// decodes the response data into out when out is not nil.
+func (s *testServer) expect(status int, method, path, token string, body, out interface{}, headers ...string) testResponse {
+
+	s.t.Helper()
+
+	resp := s.request(method, path, token, body, headers...)
+
+	if resp.Status != status {
+		s.t.Fatalf("%s %s: status %d, want %d: %s", method, path, resp.Status, status, resp.Data)
+	}
+
+	if out != nil {
+		if err := json.Unmarshal(resp.Data, out); err != nil {
+			s.t.Fatalf("%s %s: decode %s: %v", method, path, resp.Data, err)
+		}
+	}
+
+	return resp
+}
+
+func (s *testServer) login(login, password string) string {
+
+	s.t.Helper()
+
+	var tokens models.TokenResponse
+
+	s.expect(http.StatusOK, "POST", "/auth/login", "", models.LoginRequest{Login: login, Password: password}, &tokens)
+
+	return tokens.Access_token
+}
+
+func (s *testServer) adminToken() string {
+	return s.login(testAdminLogin, testAdminPassword)
+}
+
+func TestAuthAndPermissions(t *testing.T) {
+
+	s := newTestServer(t)
+
+	s.expect(http.StatusUnauthorized, "GET", "/product", "", nil, nil)
+
+	// An account without credentials could never sign in again.
+	for _, register := range []models.RegisterRequest{
+		{Name: "Shopper"},
+		{Name: "Shopper", Login: "shopper_one"},
+		{Name: "Shopper", Password: "shopper-password"},
+	} {
+		s.expect(http.StatusBadRequest, "POST", "/auth/register", "", register, nil)
+	}
+
+	register := models.RegisterRequest{Name: "Shopper", Login: "shopper_one", Password: "shopper-password"}
+
+	var tokens models.TokenResponse
+	s.expect(http.StatusCreated, "POST", "/auth/register", "", register, &tokens)
+
+	if len(tokens.Access_token) <= 0 || len(tokens.Refresh_token) <= 0 {
+		t.Fatalf("register returned no tokens: %+v", tokens)
+	}
+
+	s.expect(http.StatusConflict, "POST", "/auth/register", "", register, nil)
+	s.expect(http.StatusUnauthorized, "POST", "/auth/login", "", models.LoginRequest{Login: "shopper_one", Password: "wrong-password"}, nil)
+
+	token := s.login("shopper_one", "shopper-password")
+
+	var me models.User
+	s.expect(http.StatusOK, "GET", "/me", token, nil, &me)
+
+	if me.Login != "shopper_one" {
+		t.Fatalf("me returned login %q", me.Login)
+	}
+
+	// A registered user holds no role until an administrator grants one.
+	s.expect(http.StatusForbidden, "GET", "/product", token, nil, nil)
+
+	admin := s.adminToken()
+	s.expect(http.StatusAccepted, "PUT", "/user/"+me.Id+"/role", admin, models.AssignUserRole{Role: "customer"}, nil)
+
+	s.expect(http.StatusOK, "GET", "/product", token, nil, nil)
+	s.expect(http.StatusForbidden, "POST", "/product", token, models.CreateProduct{Name: "Tea", Price: 1000}, nil)
+}
+
+func TestApiKey(t *testing.T) {
+
+	s := newTestServer(t)
+	admin := s.adminToken()
+
+	var created models.CreatedApiKey
+	s.expect(http.StatusCreated, "POST", "/api-key", admin, models.CreateApiKey{
+		Name:   "catalog sync",
+		Scopes: []string{"product.read", "category.read"},
+	}, &created)
+
+	if len(created.Key) <= 0 || created.Api_key == nil {
+		t.Fatalf("unexpected created key %+v", created)
+	}
+
+	// The key is granted its scopes over all records, and nothing else.
+	s.expect(http.StatusOK, "GET", "/product", "", nil, nil, "X-API-Key", created.Key)
+	s.expect(http.StatusOK, "GET", "/category", "", nil, nil, "X-API-Key", created.Key)
+	s.expect(http.StatusForbidden, "POST", "/category", "", models.CreateCategory{Name: "Drinks"}, nil, "X-API-Key", created.Key)
+	s.expect(http.StatusForbidden, "GET", "/user", "", nil, nil, "X-API-Key", created.Key)
+
+	s.expect(http.StatusUnauthorized, "GET", "/product", "", nil, nil, "X-API-Key", created.Key+"x")
+
+	s.expect(http.StatusOK, "POST", "/api-key/"+created.Api_key.Id+"/revoke", admin, nil, nil)
+	s.expect(http.StatusUnauthorized, "GET", "/product", "", nil, nil, "X-API-Key", created.Key)
+}
+
+func TestOtpLoginSkipsStaff(t *testing.T) {
+
+	s := newTestServer(t)
+	admin := s.adminToken()
+
+	const phone = "+998901112233"
+
+	var customer models.Customer
+	s.expect(http.StatusCreated, "POST", "/customer", admin, models.CreateCustomer{Name: "Buyer", Phone: phone}, &customer)
+
+	// A staff account linked to the customer must not be signed in by phone.
+	var staff models.User
+	s.expect(http.StatusCreated, "POST", "/user", admin, models.CreateUser{
+		Name:     "Manager",
+		Login:    "manager",
+		Password: "manager-password",
+	}, &staff)
+	s.expect(http.StatusAccepted, "PUT", "/user/"+staff.Id+"/role", admin, models.AssignUserRole{
+		Role:        "admin",
+		Customer_id: customer.Id,
+	}, nil)
+
+	hash, err := helper.HashPassword("123456")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = s.store.Otp().CreateCustomerOtp(context.Background(), &models.CreateCustomerOtp{Phone: phone, Code_hash: hash})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tokens models.TokenResponse
+	s.expect(http.StatusOK, "POST", "/auth/otp/verify", "", models.VerifyOtp{Phone: phone, Code: "123456"}, &tokens)
+
+	var me models.User
+	s.expect(http.StatusOK, "GET", "/me", tokens.Access_token, nil, &me)
+
+	if me.Id == staff.Id || me.Role != "customer" || me.Customer_id != customer.Id {
+		t.Fatalf("signed in as %+v, want a customer user of %s", me, customer.Id)
+	}
+}
+
+func TestCategoryAndProductCRUD(t *testing.T) {
+
+	s := newTestServer(t)
+	admin := s.adminToken()
+
+	var category models.Category
+	s.expect(http.StatusCreated, "POST", "/category", admin, models.CreateCategory{Name: "Drinks"}, &category)
+
+	var product models.Product
+	s.expect(http.StatusCreated, "POST", "/product", admin, models.CreateProduct{
+		Name:        "Green Tea",
+		Price:       12500,
+		Category_id: category.Id,
+		Stock:       10,
+	}, &product)
+
+	if product.Name != "Green Tea" || product.Price != "12500" || product.Category_id != category.Id || product.Stock != 10 {
+		t.Fatalf("unexpected product %+v", product)
+	}
+
+	s.expect(http.StatusAccepted, "PUT", "/product/"+product.Id, admin, models.UpdateProduct{
+		Name:        "Black Tea",
+		Price:       13000,
+		Category_id: category.Id,
+		Stock:       8,
+	}, nil)
+
+	s.expect(http.StatusOK, "GET", "/product/"+product.Id, admin, nil, &product)
+
+	if product.Name != "Black Tea" || product.Stock != 8 {
+		t.Fatalf("product was not updated: %+v", product)
+	}
+
+	resp := s.expect(http.StatusUnprocessableEntity, "POST", "/product", admin, models.CreateProduct{
+		Name:        "Orphan",
+		Price:       1000,
+		Category_id: "6f1c1f8e-3b7a-4c55-9a43-2f0d7f1b9c10",
+		Stock:       1,
+	}, nil)
+
+	if resp.Code != "foreign_key_violation" {
+		t.Fatalf("code %q, want foreign_key_violation", resp.Code)
+	}
+
+	// A category still used by a product cannot be deleted.
+	resp = s.expect(http.StatusUnprocessableEntity, "DELETE", "/category/"+category.Id, admin, nil, nil)
+
+	if resp.Code != "foreign_key_violation" {
+		t.Fatalf("code %q, want foreign_key_violation", resp.Code)
+	}
+
+	s.expect(http.StatusOK, "DELETE", "/product/"+product.Id, admin, nil, nil)
+	s.expect(http.StatusOK, "DELETE", "/category/"+category.Id, admin, nil, nil)
+
+	resp = s.expect(http.StatusNotFound, "GET", "/category/"+category.Id, admin, nil, nil)
+
+	if resp.Code != "not_found" {
+		t.Fatalf("code %q, want not_found", resp.Code)
+	}
+
+	s.expect(http.StatusBadRequest, "GET", "/product/not-a-uuid", admin, nil, nil)
+}
+
+func TestProductListing(t *testing.T) {
+
+	s := newTestServer(t)
+	admin := s.adminToken()
+
+	var category models.Category
+	s.expect(http.StatusCreated, "POST", "/category", admin, models.CreateCategory{Name: "Fruit"}, &category)
+
+	for i, name := range []string{"Apple", "Banana", "Cherry", "Date", "Elderberry"} {
+		s.expect(http.StatusCreated, "POST", "/product", admin, models.CreateProduct{
+			Name:        name,
+			Price:       float64(1000 * (i + 1)),
+			Category_id: category.Id,
+			Stock:       5,
+		}, nil)
+	}
+
+	var list models.GetListProductResponse
+
+	s.expect(http.StatusOK, "GET", "/product?search=an", admin, nil, &list)
+
+	if list.Count != 1 || len(list.Products) != 1 || list.Products[0].Name != "Banana" {
+		t.Fatalf("search returned %+v", list)
+	}
+
+	s.expect(http.StatusOK, "GET", "/product?sort=-price&offset=1&limit=2", admin, nil, &list)
+
+	if len(list.Products) != 2 || list.Products[0].Name != "Date" || list.Products[1].Name != "Cherry" {
+		t.Fatalf("sorted page returned %+v", list.Products)
+	}
+
+	s.expect(http.StatusOK, "GET", "/product?min_price=2000&max_price=4000", admin, nil, &list)
+
+	if len(list.Products) != 3 {
+		t.Fatalf("price range returned %d products", len(list.Products))
+	}
+
+	s.expect(http.StatusBadRequest, "GET", "/product?sort=password", admin, nil, nil)
+
+	// Walk every product through keyset pages of two.
+	var (
+		seen  = make(map[string]bool)
+		path  = "/product?limit=2&cursor="
+		pages int
+	)
+
+	for {
+		var page models.GetListProductResponse
+		s.expect(http.StatusOK, "GET", path, admin, nil, &page)
+
+		for _, p := range page.Products {
+			if seen[p.Id] {
+				t.Fatalf("product %s returned twice", p.Name)
+			}
+			seen[p.Id] = true
+		}
+
+		pages++
+
+		if len(page.Next_cursor) <= 0 {
+			break
+		}
+
+		path = "/product?limit=2&cursor=" + page.Next_cursor
+	}
+
+	if len(seen) != 5 || pages != 3 {
+		t.Fatalf("cursor paging returned %d products in %d pages", len(seen), pages)
+	}
+
+	s.expect(http.StatusBadRequest, "GET", "/product?limit=2&offset=2&cursor=", admin, nil, nil)
+}
+
+func TestCategoryCursor(t *testing.T) {
+
+	s := newTestServer(t)
+	admin := s.adminToken()
+
+	for _, name := range []string{"Bakery", "Dairy", "Drinks"} {
+		s.expect(http.StatusCreated, "POST", "/category", admin, models.CreateCategory{Name: name}, nil)
+	}
+
+	var (
+		seen  = make(map[string]bool)
+		path  = "/category?limit=2&cursor="
+		pages int
+	)
+
+	for {
+		var page models.GetListCategoryResponse
+		s.expect(http.StatusOK, "GET", path, admin, nil, &page)
+
+		for _, c := range page.Categories {
+			if seen[c.Id] {
+				t.Fatalf("category %s returned twice", c.Name)
+			}
+			seen[c.Id] = true
+		}
+
+		pages++
+
+		if len(page.Next_cursor) <= 0 {
+			break
+		}
+
+		path = "/category?limit=2&cursor=" + page.Next_cursor
+	}
+
+	if len(seen) != 3 || pages != 2 {
+		t.Fatalf("cursor paging returned %d categories in %d pages", len(seen), pages)
+	}
+
+	s.expect(http.StatusBadRequest, "GET", "/category?sort=name&cursor=", admin, nil, nil)
+}
+
+// orderFixture is a stocked product and an available courier at the store,
+// with the administrator able to pay for orders.
+type orderFixture struct {
+	admin    string
+	adminId  string
+	product  models.Product
+	courier  models.Courier
+	customer models.Customer
+
+	latitude, longitude float64
+}
+
+func newOrderFixture(s *testServer) *orderFixture {
+
+	s.t.Helper()
+
+	f := &orderFixture{
+		admin:     s.adminToken(),
+		latitude:  s.cfg.StoreLatitude,
+		longitude: s.cfg.StoreLongitude,
+	}
+
+	var me models.User
+	s.expect(http.StatusOK, "GET", "/me", f.admin, nil, &me)
+	f.adminId = me.Id
+
+	s.expect(http.StatusCreated, "POST", "/user/"+f.adminId+"/top-up", f.admin, models.TopUpBalance{Amount: 100000}, nil)
+
+	var category models.Category
+	s.expect(http.StatusCreated, "POST", "/category", f.admin, models.CreateCategory{Name: "Bakery"}, &category)
+
+	s.expect(http.StatusCreated, "POST", "/product", f.admin, models.CreateProduct{
+		Name:        "Bread",
+		Price:       4000,
+		Category_id: category.Id,
+		Stock:       10,
+	}, &f.product)
+
+	available := true
+
+	s.expect(http.StatusCreated, "POST", "/courier", f.admin, models.CreateCourier{
+		Name:         "Rider",
+		Phone_number: "+998901234567",
+		Latitude:     &f.latitude,
+		Longitude:    &f.longitude,
+		Is_available: &available,
+	}, &f.courier)
+
+	s.expect(http.StatusCreated, "POST", "/customer", f.admin, models.CreateCustomer{
+		Name:  "Buyer",
+		Phone: "+998901112233",
+	}, &f.customer)
+
+	return f
+}
+
+func (f *orderFixture) createOrder(quantity int) models.CreateOrder {
+	return models.CreateOrder{
+		Name:         "Breakfast",
+		Phone_number: "+998901112233",
+		Latitude:     f.latitude,
+		Longtitude:   f.longitude,
+		User_id:      f.adminId,
+		Customer_id:  f.customer.Id,
+		Items:        []*models.CreateOrderItem{{Product_id: f.product.Id, Quantity: quantity}},
+	}
+}
+
+func TestOrderLifecycle(t *testing.T) {
+
+	s := newTestServer(t)
+	f := newOrderFixture(s)
+
+	var order models.Order
+	s.expect(http.StatusCreated, "POST", "/order", f.admin, f.createOrder(3), &order)
+
+	if order.Status != models.OrderStatusNew || order.Courier_id != f.courier.Id {
+		t.Fatalf("unexpected order %+v", order)
+	}
+
+	if order.Price != 3*4000+order.Delivery_fee || order.Delivery_fee != 5000 {
+		t.Fatalf("order priced %v with fee %v", order.Price, order.Delivery_fee)
+	}
+
+	var product models.Product
+	s.expect(http.StatusOK, "GET", "/product/"+f.product.Id, f.admin, nil, &product)
+
+	if product.Stock != 10 || product.Reserved != 3 {
+		t.Fatalf("stock %d reserved %d after order", product.Stock, product.Reserved)
+	}
+
+	var me models.User
+	s.expect(http.StatusOK, "GET", "/me", f.admin, nil, &me)
+
+	if me.Balance != 100000-order.Price {
+		t.Fatalf("balance %v after paying %v", me.Balance, order.Price)
+	}
+
+	// More than is in stock is refused without touching the reservation.
+	s.expect(http.StatusConflict, "POST", "/order", f.admin, f.createOrder(8), nil)
+
+	path := "/order/" + order.Id + "/status"
+
+	s.expect(http.StatusConflict, "POST", path, f.admin, models.UpdateOrderStatus{Status: models.OrderStatusDelivered}, nil)
+
+	// The history records the authenticated user, whatever the body claims.
+	s.expect(http.StatusOK, "POST", path, f.admin, map[string]string{
+		"status":     models.OrderStatusAccepted,
+		"changed_by": f.customer.Id,
+	}, nil)
+
+	for _, status := range []string{
+		models.OrderStatusAssigned,
+		models.OrderStatusPickedUp,
+		models.OrderStatusDelivered,
+	} {
+		s.expect(http.StatusOK, "POST", path, f.admin, models.UpdateOrderStatus{Status: status}, &order)
+
+		if order.Status != status {
+			t.Fatalf("order status %q, want %q", order.Status, status)
+		}
+	}
+
+	s.expect(http.StatusOK, "GET", "/product/"+f.product.Id, f.admin, nil, &product)
+
+	if product.Stock != 7 || product.Reserved != 0 {
+		t.Fatalf("stock %d reserved %d after delivery", product.Stock, product.Reserved)
+	}
+
+	var history models.GetOrderStatusHistoryResponse
+	s.expect(http.StatusOK, "GET", "/order/"+order.Id+"/status-history", f.admin, nil, &history)
+
+	if history.Count != 5 {
+		t.Fatalf("status history has %d entries, want 5", history.Count)
+	}
+
+	for _, entry := range history.History {
+		if entry.Changed_by != f.adminId {
+			t.Fatalf("status history entry changed by %q, want %q", entry.Changed_by, f.adminId)
+		}
+	}
+
+	// Delivered orders can only be returned.
+	s.expect(http.StatusConflict, "POST", path, f.admin, models.UpdateOrderStatus{Status: models.OrderStatusCancelled}, nil)
+}
+
+func TestOrderDeleteRefunds(t *testing.T) {
+
+	s := newTestServer(t)
+	f := newOrderFixture(s)
+
+	var order models.Order
+	s.expect(http.StatusCreated, "POST", "/order", f.admin, f.createOrder(2), &order)
+
+	var me models.User
+	s.expect(http.StatusOK, "GET", "/me", f.admin, nil, &me)
+
+	if me.Balance != 100000-order.Price {
+		t.Fatalf("balance %v after paying %v", me.Balance, order.Price)
+	}
+
+	s.expect(http.StatusOK, "DELETE", "/order/"+order.Id, f.admin, nil, nil)
+
+	s.expect(http.StatusOK, "GET", "/me", f.admin, nil, &me)
+
+	if me.Balance != 100000 {
+		t.Fatalf("balance %v after deleting a paid order, want a full refund", me.Balance)
+	}
+
+	var product models.Product
+	s.expect(http.StatusOK, "GET", "/product/"+f.product.Id, f.admin, nil, &product)
+
+	if product.Stock != 10 || product.Reserved != 0 {
+		t.Fatalf("stock %d reserved %d after deleting the order", product.Stock, product.Reserved)
+	}
+}
+
+func TestOrderPatch(t *testing.T) {
+
+	s := newTestServer(t)
+	f := newOrderFixture(s)
+
+	var order models.Order
+	s.expect(http.StatusCreated, "POST", "/order", f.admin, f.createOrder(1), &order)
+
+	path := "/order/" + order.Id
+
+	// Only whitelisted fields, spelled exactly, may be patched.
+	for _, fields := range []map[string]interface{}{
+		{"status": models.OrderStatusDelivered},
+		{"STATUS": models.OrderStatusDelivered},
+		{"Price": 0},
+		{"delivery_fee": 0},
+		{"courier_id": nil},
+		{"name": "Lunch", "updated_at = now(), price": 0},
+		{},
+	} {
+		s.expect(http.StatusBadRequest, "PATCH", path, f.admin, models.PatchRequest{Fields: fields}, nil)
+	}
+
+	s.expect(http.StatusOK, "PATCH", path, f.admin, models.PatchRequest{Fields: map[string]interface{}{"name": "Lunch"}}, &order)
+
+	if order.Name != "Lunch" || order.Status != models.OrderStatusNew || order.Price != 4000+order.Delivery_fee {
+		t.Fatalf("unexpected patched order %+v", order)
+	}
+}
+
+func TestCartCheckout(t *testing.T) {
+
+	s := newTestServer(t)
+	f := newOrderFixture(s)
+
+	var cart models.Cart
+	s.expect(http.StatusCreated, "POST", "/cart", f.admin, models.CreateCart{Customer_id: f.customer.Id}, &cart)
+
+	path := "/cart/" + cart.Id
+
+	s.expect(http.StatusOK, "POST", path+"/items", f.admin, models.AddCartItem{Product_id: f.product.Id, Quantity: 2}, nil)
+
+	checkout := models.CheckoutCart{
+		Name:         "Breakfast",
+		Phone_number: "+998901112233",
+		Latitude:     f.latitude,
+		Longtitude:   f.longitude,
+		User_id:      f.adminId,
+	}
+
+	var order models.Order
+	s.expect(http.StatusCreated, "POST", path+"/checkout", f.admin, checkout, &order)
+
+	if len(order.Items) != 1 || order.Items[0].Quantity != 2 || order.Price != 2*4000+order.Delivery_fee {
+		t.Fatalf("unexpected checked out order %+v", order)
+	}
+
+	// The checkout emptied the cart.
+	s.expect(http.StatusBadRequest, "POST", path+"/checkout", f.admin, checkout, nil)
+}
+
+func TestCourierLocationFuture(t *testing.T) {
+
+	s := newTestServer(t)
+	f := newOrderFixture(s)
+
+	path := "/courier/" + f.courier.Id + "/location"
+
+	// A point dated in the future would hold back every later update.
+	s.expect(http.StatusBadRequest, "POST", path, f.admin, models.CreateCourierLocation{
+		Latitude:    f.latitude,
+		Longitude:   f.longitude,
+		Recorded_at: time.Now().Add(time.Hour).Format(time.RFC3339),
+	}, nil)
+
+	var location models.CourierLocation
+	s.expect(http.StatusCreated, "POST", path, f.admin, models.CreateCourierLocation{
+		Latitude:    f.latitude + 0.01,
+		Longitude:   f.longitude,
+		Recorded_at: time.Now().Add(-time.Minute).Format(time.RFC3339),
+	}, &location)
+
+	if location.Latitude != f.latitude+0.01 {
+		t.Fatalf("location was not updated: %+v", location)
+	}
+}
+
+func TestOrderManualCourier(t *testing.T) {
+
+	s := newTestServer(t)
+	f := newOrderFixture(s)
+
+	unavailable := false
+
+	var resting models.Courier
+	s.expect(http.StatusCreated, "POST", "/courier", f.admin, models.CreateCourier{
+		Name:         "Resting rider",
+		Phone_number: "+998901234568",
+		Is_available: &unavailable,
+	}, &resting)
+
+	var retired models.Courier
+	s.expect(http.StatusCreated, "POST", "/courier", f.admin, models.CreateCourier{
+		Name:         "Retired rider",
+		Phone_number: "+998901234569",
+	}, &retired)
+	s.expect(http.StatusOK, "DELETE", "/courier/"+retired.Id, f.admin, nil, nil)
+
+	request := f.createOrder(1)
+
+	request.Courier_id = resting.Id
+	s.expect(http.StatusConflict, "POST", "/order", f.admin, request, nil)
+
+	request.Courier_id = retired.Id
+	s.expect(http.StatusUnprocessableEntity, "POST", "/order", f.admin, request, nil)
+
+	var order models.Order
+	request.Courier_id = f.courier.Id
+	s.expect(http.StatusCreated, "POST", "/order", f.admin, request, &order)
+
+	if order.Courier_id != f.courier.Id || order.Assignment_mode != models.AssignmentModeManual {
+		t.Fatalf("unexpected manual assignment %+v", order)
+	}
+}
+
+func TestOrderIdempotencyKey(t *testing.T) {
+
+	s := newTestServer(t)
+	f := newOrderFixture(s)
+
+	var first, replay models.Order
+
+	s.expect(http.StatusCreated, "POST", "/order", f.admin, f.createOrder(1), &first, "Idempotency-Key", "order-1")
+	s.expect(http.StatusCreated, "POST", "/order", f.admin, f.createOrder(1), &replay, "Idempotency-Key", "order-1")
+
+	if replay.Id != first.Id {
+		t.Fatalf("replay created order %s, first was %s", replay.Id, first.Id)
+	}
+
+	s.expect(http.StatusUnprocessableEntity, "POST", "/order", f.admin, f.createOrder(2), nil, "Idempotency-Key", "order-1")
+
+	var product models.Product
+	s.expect(http.StatusOK, "GET", "/product/"+f.product.Id, f.admin, nil, &product)
+
+	if product.Reserved != 1 {
+		t.Fatalf("reserved %d, want 1", product.Reserved)
+	}
+}
+
+func TestIdempotencyKeyNotReplayed(t *testing.T) {
+
+	s := newTestServer(t)
+	f := newOrderFixture(s)
+
+	// A conflict may be resolved before the retry, so it is not replayed.
+	s.expect(http.StatusConflict, "POST", "/order", f.admin, f.createOrder(20), nil, "Idempotency-Key", "order-1")
+
+	var product models.Product
+	s.expect(http.StatusOK, "GET", "/product/"+f.product.Id, f.admin, nil, &product)
+
+	s.expect(http.StatusAccepted, "PUT", "/product/"+f.product.Id, f.admin, models.UpdateProduct{
+		Name:        product.Name,
+		Price:       4000,
+		Category_id: product.Category_id,
+		Stock:       30,
+	}, nil)
+
+	s.expect(http.StatusCreated, "POST", "/order", f.admin, f.createOrder(20), nil, "Idempotency-Key", "order-1")
+
+	// Neither is a failed authentication.
+	s.expect(http.StatusUnauthorized, "POST", "/category", "invalid", models.CreateCategory{Name: "Dairy"}, nil, "Idempotency-Key", "category-1")
+	s.expect(http.StatusCreated, "POST", "/category", f.admin, models.CreateCategory{Name: "Dairy"}, nil, "Idempotency-Key", "category-1")
+
+	// A panicking handler releases its key.
+	h := handler.NewHandler(s.cfg, s.store, logger.NewLogger("test", logger.LevelPanic))
+	panicked := false
+
+	r := gin.New()
+	r.Use(gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, err interface{}) {
+		c.JSON(http.StatusInternalServerError, handler.Response{Status: http.StatusInternalServerError})
+	}))
+	r.POST("/panic", h.IdempotencyMiddleware(), func(c *gin.Context) {
+		if !panicked {
+			panicked = true
+			panic("boom")
+		}
+		c.JSON(http.StatusCreated, handler.Response{Status: http.StatusCreated})
+	})
+	s.router = r
+
+	s.expect(http.StatusInternalServerError, "POST", "/panic", "", nil, nil, "Idempotency-Key", "panic-1")
+	s.expect(http.StatusCreated, "POST", "/panic", "", nil, nil, "Idempotency-Key", "panic-1")
+}
+
+func TestSoftDeleteAndRestore(t *testing.T) {
+
+	s := newTestServer(t)
+	f := newOrderFixture(s)
+
+	var order models.Order
+	s.expect(http.StatusCreated, "POST", "/order", f.admin, f.createOrder(1), &order)
+
+	// Records referenced by orders can be deleted; the orders keep them.
+	s.expect(http.StatusOK, "DELETE", "/product/"+f.product.Id, f.admin, nil, nil)
+	s.expect(http.StatusOK, "DELETE", "/customer/"+f.customer.Id, f.admin, nil, nil)
+	s.expect(http.StatusOK, "GET", "/order/"+order.Id, f.admin, nil, nil)
+
+	s.expect(http.StatusNotFound, "DELETE", "/product/"+f.product.Id, f.admin, nil, nil)
+	s.expect(http.StatusNotFound, "GET", "/product/"+f.product.Id, f.admin, nil, nil)
+
+	var products models.GetListProductResponse
+	s.expect(http.StatusOK, "GET", "/product", f.admin, nil, &products)
+
+	if products.Count != 0 {
+		t.Fatalf("list returned %d products, want the deleted one excluded", products.Count)
+	}
+
+	s.expect(http.StatusOK, "GET", "/product?include_deleted=true", f.admin, nil, &products)
+
+	if products.Count != 1 || len(products.Products[0].DeletedAt) <= 0 {
+		t.Fatalf("list with include_deleted returned %+v", products.Products)
+	}
+
+	var product models.Product
+	s.expect(http.StatusOK, "GET", "/product/"+f.product.Id+"?include_deleted=true", f.admin, nil, &product)
+
+	resp := s.expect(http.StatusUnprocessableEntity, "POST", "/order", f.admin, f.createOrder(1), nil)
+
+	if resp.Code != "foreign_key_violation" {
+		t.Fatalf("code %q, want foreign_key_violation", resp.Code)
+	}
+
+	// Only the deleted product used the category, so it can go too, but the
+	// product cannot come back before it.
+	s.expect(http.StatusOK, "DELETE", "/category/"+product.Category_id, f.admin, nil, nil)
+	s.expect(http.StatusUnprocessableEntity, "POST", "/product/"+f.product.Id+"/restore", f.admin, nil, nil)
+
+	s.expect(http.StatusOK, "POST", "/category/"+product.Category_id+"/restore", f.admin, nil, nil)
+	s.expect(http.StatusOK, "POST", "/product/"+f.product.Id+"/restore", f.admin, nil, &product)
+
+	if len(product.DeletedAt) > 0 || product.Reserved != 1 {
+		t.Fatalf("unexpected restored product %+v", product)
+	}
+
+	s.expect(http.StatusOK, "POST", "/customer/"+f.customer.Id+"/restore", f.admin, nil, nil)
+	s.expect(http.StatusCreated, "POST", "/order", f.admin, f.createOrder(1), nil)
+
+	s.expect(http.StatusNotFound, "POST", "/courier/6f1c1f8e-3b7a-4c55-9a43-2f0d7f1b9c10/restore", f.admin, nil, nil)
+
+	register := models.RegisterRequest{Name: "Shopper", Login: "shopper_one", Password: "shopper-password"}
+	s.expect(http.StatusCreated, "POST", "/auth/register", "", register, nil)
+
+	token := s.login("shopper_one", "shopper-password")
+
+	var me models.User
+	s.expect(http.StatusOK, "GET", "/me", token, nil, &me)
+	s.expect(http.StatusAccepted, "PUT", "/user/"+me.Id+"/role", f.admin, models.AssignUserRole{Role: "customer"}, nil)
+
+	// Deleted records are for administrators only.
+	s.expect(http.StatusOK, "GET", "/product", token, nil, nil)
+	s.expect(http.StatusForbidden, "GET", "/product?include_deleted=true", token, nil, nil)
+
+	// A deleted user can no longer use their token.
+	s.expect(http.StatusOK, "DELETE", "/user/"+me.Id, f.admin, nil, nil)
+	s.expect(http.StatusUnauthorized, "GET", "/product", token, nil, nil)
+	s.expect(http.StatusUnauthorized, "POST", "/auth/login", "", models.LoginRequest{Login: "shopper_one", Password: "shopper-password"}, nil)
+}
+
+func TestSoftDeleteAndRestoreBooksAndAuthors(t *testing.T) {
+
+	s := newTestServer(t)
+	admin := s.adminToken()
+
+	var book models.Book
+	s.expect(http.StatusCreated, "POST", "/book", admin, models.CreateBook{Name: "Dune", Price: 12, Count: 3}, &book)
+
+	var author models.Author
+	s.expect(http.StatusCreated, "POST", "/author", admin, models.CreateAuthor{Name: "Frank Herbert"}, &author)
+
+	s.expect(http.StatusAccepted, "DELETE", "/book/"+book.Id, admin, nil, nil)
+	s.expect(http.StatusOK, "DELETE", "/author/"+author.Id, admin, nil, nil)
+
+	s.expect(http.StatusNotFound, "DELETE", "/book/"+book.Id, admin, nil, nil)
+	s.expect(http.StatusNotFound, "GET", "/book/"+book.Id, admin, nil, nil)
+	s.expect(http.StatusNotFound, "GET", "/author/"+author.Id, admin, nil, nil)
+
+	var books models.GetListBookResponse
+	s.expect(http.StatusOK, "GET", "/book", admin, nil, &books)
+
+	var authors models.GetListAuthorResponse
+	s.expect(http.StatusOK, "GET", "/author", admin, nil, &authors)
+
+	if books.Count != 0 || authors.Count != 0 {
+		t.Fatalf("lists returned %d books and %d authors, want the deleted ones excluded", books.Count, authors.Count)
+	}
+
+	s.expect(http.StatusOK, "GET", "/book?include_deleted=true", admin, nil, &books)
+	s.expect(http.StatusOK, "GET", "/author?include_deleted=true", admin, nil, &authors)
+
+	if books.Count != 1 || len(books.Books[0].DeletedAt) <= 0 {
+		t.Fatalf("book list with include_deleted returned %+v", books.Books)
+	}
+
+	if authors.Count != 1 || len(authors.Authors[0].DeletedAt) <= 0 {
+		t.Fatalf("author list with include_deleted returned %+v", authors.Authors)
+	}
+
+	s.expect(http.StatusCreated, "GET", "/book/"+book.Id+"?include_deleted=true", admin, nil, nil)
+	s.expect(http.StatusOK, "GET", "/author/"+author.Id+"?include_deleted=true", admin, nil, nil)
+
+	s.expect(http.StatusOK, "POST", "/book/"+book.Id+"/restore", admin, nil, &book)
+	s.expect(http.StatusOK, "POST", "/author/"+author.Id+"/restore", admin, nil, &author)
+
+	if len(book.DeletedAt) > 0 || len(author.DeletedAt) > 0 {
+		t.Fatalf("unexpected restored book %+v and author %+v", book, author)
+	}
+
+	s.expect(http.StatusCreated, "GET", "/book/"+book.Id, admin, nil, nil)
+	s.expect(http.StatusOK, "GET", "/author/"+author.Id, admin, nil, nil)
+
+	s.expect(http.StatusNotFound, "POST", "/author/6f1c1f8e-3b7a-4c55-9a43-2f0d7f1b9c10/restore", admin, nil, nil)
+}
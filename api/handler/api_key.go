@@ -0,0 +1,236 @@
+package handler
+
+import (
+	"app/api/models"
+	"app/pkg/helper"
+	"app/storage"
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Create Api Key godoc
+// @ID create_api_key
+// @Router /api-key [POST]
+// @Summary Create Api Key
+// @Description Create an API key for an integration. Scopes are permission codes granted over all records,
+// @Description and may only include permissions the caller holds over all records.
+// @Description The key is returned only in this response; send it in the X-API-Key header.
+// @Tags Api Key
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param api_key body models.CreateApiKey true "CreateApiKeyRequest"
+// @Success 201 {object} Response{data=models.CreatedApiKey} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 403 {object} Response{data=string} "Forbidden"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) CreateApiKey(c *gin.Context) {
+
+	var createApiKey models.CreateApiKey
+
+	err := c.ShouldBindJSON(&createApiKey)
+	if err != nil {
+		h.handlerResponse(c, "Create Api Key", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if len(createApiKey.Name) <= 0 {
+		h.handlerResponse(c, "Create Api Key", http.StatusBadRequest, "name is required")
+		return
+	}
+
+	if len(createApiKey.Scopes) <= 0 {
+		h.handlerResponse(c, "Create Api Key", http.StatusBadRequest, "at least one scope is required")
+		return
+	}
+
+	if len(createApiKey.Expires_at) > 0 {
+		expiresAt, err := time.Parse(time.RFC3339, createApiKey.Expires_at)
+		if err != nil {
+			h.handlerResponse(c, "Create Api Key", http.StatusBadRequest, "expires_at must be an RFC 3339 time")
+			return
+		}
+
+		if !expiresAt.After(time.Now()) {
+			h.handlerResponse(c, "Create Api Key", http.StatusBadRequest, "expires_at must be in the future")
+			return
+		}
+	}
+
+	// A key must not grant more than its creator holds, or keys would be a way
+	// around own-scoped permissions.
+	principal, err := h.principal(c)
+	if err != nil {
+		h.handlerResponse(c, "Storage Create Api Key Principal", http.StatusInternalServerError, err)
+		return
+	}
+
+	for _, scope := range createApiKey.Scopes {
+		if principal.Permissions[scope] != models.PermissionScopeAll {
+			h.handlerResponse(c, "Create Api Key", http.StatusForbidden, "cannot grant a permission you do not hold over all records: "+scope)
+			return
+		}
+	}
+
+	key, err := helper.GenerateAPIKey()
+	if err != nil {
+		h.handlerResponse(c, "Create Api Key Generate", http.StatusInternalServerError, err)
+		return
+	}
+
+	createApiKey.Prefix = key[:helper.APIKeyDisplayLength]
+	createApiKey.Key_hash = helper.HashAPIKey(key)
+	createApiKey.Created_by = c.GetString(contextUserId)
+
+	id, err := h.storages.ApiKey().CreateApiKey(context.Background(), &createApiKey)
+	if err != nil {
+		var unknown *storage.UnknownPermissionError
+		if errors.As(err, &unknown) {
+			h.handlerResponse(c, "Create Api Key", http.StatusBadRequest, err.Error())
+			return
+		}
+
+		h.handlerResponse(c, "Storage Create Api Key", http.StatusInternalServerError, err)
+		return
+	}
+
+	resp, err := h.storages.ApiKey().GetByIdApiKey(context.Background(), &models.ApiKeyPrimaryKey{Id: id})
+	if err != nil {
+		h.handlerResponse(c, "Storage Create Api Key Get By ID", http.StatusInternalServerError, err)
+		return
+	}
+
+	h.handlerResponse(c, "Create Api Key", http.StatusCreated, &models.CreatedApiKey{
+		Key:     key,
+		Api_key: resp,
+	})
+}
+
+// Get By ID Api Key godoc
+// @ID get_by_id_api_key
+// @Router /api-key/{id} [GET]
+// @Summary Get By ID Api Key
+// @Description Get By ID Api Key
+// @Tags Api Key
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path string true "id"
+// @Success 200 {object} Response{data=models.ApiKey} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) GetByIdApiKey(c *gin.Context) {
+
+	id := c.Param("id")
+	if !helper.IsValidUUID(id) {
+		h.handlerResponse(c, "Get Api Key By Id", http.StatusBadRequest, "Invalid UUID")
+		return
+	}
+
+	resp, err := h.storages.ApiKey().GetByIdApiKey(context.Background(), &models.ApiKeyPrimaryKey{Id: id})
+	if storage.IsKind(err, storage.KindNotFound) {
+		h.handlerResponse(c, "Get Api Key By Id", http.StatusNotFound, "api key not found")
+		return
+	}
+	if err != nil {
+		h.handlerResponse(c, "Storage Get Api Key By Id", http.StatusInternalServerError, err)
+		return
+	}
+
+	h.handlerResponse(c, "Get Api Key By Id", http.StatusOK, resp)
+}
+
+// Get List Api Key godoc
+// @ID get_list_api_key
+// @Router /api-key [GET]
+// @Summary Get List Api Key
+// @Description Get List Api Key, newest first
+// @Tags Api Key
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param offset query string false "offset"
+// @Param limit query string false "limit"
+// @Success 200 {object} Response{data=models.GetListApiKeyResponse} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) GetListApiKey(c *gin.Context) {
+
+	offset, err := h.getOffsetQuery(c.Query("offset"))
+	if err != nil {
+		h.handlerResponse(c, "Get List Api Key", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	limit, err := h.getLimitQuery(c.Query("limit"))
+	if err != nil {
+		h.handlerResponse(c, "Get List Api Key", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	resp, err := h.storages.ApiKey().GetListApiKey(context.Background(), &models.GetListApiKeyRequest{
+		Offset: offset,
+		Limit:  limit,
+	})
+	if err != nil {
+		h.handlerResponse(c, "Storage Get List Api Key", http.StatusInternalServerError, err)
+		return
+	}
+
+	h.handlerResponse(c, "Get List Api Key", http.StatusOK, resp)
+}
+
+// Revoke Api Key godoc
+// @ID revoke_api_key
+// @Router /api-key/{id}/revoke [POST]
+// @Summary Revoke Api Key
+// @Description Revoke an API key. Revoked keys are rejected immediately and cannot be restored.
+// @Tags Api Key
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path string true "id"
+// @Success 200 {object} Response{data=models.ApiKey} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) RevokeApiKey(c *gin.Context) {
+
+	id := c.Param("id")
+	if !helper.IsValidUUID(id) {
+		h.handlerResponse(c, "Revoke Api Key", http.StatusBadRequest, "Invalid UUID")
+		return
+	}
+
+	rowsAffected, err := h.storages.ApiKey().RevokeApiKey(context.Background(), &models.ApiKeyPrimaryKey{Id: id})
+	if err != nil {
+		h.handlerResponse(c, "Storage Revoke Api Key", http.StatusInternalServerError, err)
+		return
+	}
+
+	resp, err := h.storages.ApiKey().GetByIdApiKey(context.Background(), &models.ApiKeyPrimaryKey{Id: id})
+	if storage.IsKind(err, storage.KindNotFound) {
+		h.handlerResponse(c, "Revoke Api Key", http.StatusNotFound, "api key not found")
+		return
+	}
+	if err != nil {
+		h.handlerResponse(c, "Storage Revoke Api Key Get By ID", http.StatusInternalServerError, err)
+		return
+	}
+
+	if rowsAffected <= 0 {
+		h.handlerResponse(c, "Revoke Api Key", http.StatusBadRequest, "api key is already revoked")
+		return
+	}
+
+	h.handlerResponse(c, "Revoke Api Key", http.StatusOK, resp)
+}
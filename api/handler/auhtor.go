@@ -18,6 +18,8 @@ import (
 // @Tags Author
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param author body models.CreateAuthor true "CreateAuthorRequest"
 // @Success 200 {object} Response{data=string} "Success Request"
 // @Response 400 {object} Response{data=string} "Bad Request"
@@ -34,13 +36,13 @@ func (h *Handler) CreateAuhtor(c *gin.Context) {
 
 	id, err := h.storages.Author().CreateAuthor(context.Background(), &createAuhor)
 	if err != nil{
-		h.handlerResponse(c, "Create Auhtor Storage", http.StatusInternalServerError, err.Error())
+		h.handlerResponse(c, "Create Auhtor Storage", http.StatusInternalServerError, err)
 		return
 	}
 
 	resp, err := h.storages.Author().AuthorGetById(context.Background(), &models.AuthorPrimaryKey{Id: id})
 	if err != nil{
-		h.handlerResponse(c, "Create Author Get By ID", 500, err.Error())
+		h.handlerResponse(c, "Create Author Get By ID", 500, err)
 		return
 	}
 
@@ -57,34 +59,61 @@ func (h *Handler) CreateAuhtor(c *gin.Context) {
 // @Tags Author
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param offset query string false "offset"
 // @Param limit query string false "limit"
 // @Param search query string false "search"
+// @Param sort query string false "comma separated fields, prefixed with - for descending: name, created_at"
+// @Param cursor query string false "keyset paging from the newest record: pass an empty cursor to start, then next_cursor"
+// @Param include_deleted query boolean false "include deleted records, requires author.write"
 // @Success 200 {object} Response{data=string} "Success Request"
 // @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 403 {object} Response{data=string} "Forbidden"
 // @Failure 500 {object} Response{data=string} "Server Error"
 func (h *Handler) GetListAuthor(c *gin.Context) {
 
-	offset, err := h.getOffsetQuery(c.Param("offset"))
+	offset, err := h.getOffsetQuery(c.Query("offset"))
 	if err != nil{
 		h.handlerResponse(c, "Get List authot Offset", http.StatusBadRequest, err.Error())
 		return
 	}
 
-	limit, err := h.getLimitQuery(c.Param("limit"))
+	limit, err := h.getLimitQuery(c.Query("limit"))
 	if err != nil{
 		h.handlerResponse(c, "Get List author Limit", http.StatusBadRequest, err.Error())
 		return
 	}
 
+	sort, err := models.ParseSort(c.Query("sort"), models.AuthorSortFields)
+	if err != nil{
+		h.handlerResponse(c, "Get List Author", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	cursor, err := getCursorQuery(c, offset, limit)
+	if err != nil{
+		h.handlerResponse(c, "Get List Author", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	includeDeleted, code, err := getIncludeDeletedQuery(c, "author")
+	if err != nil{
+		h.handlerResponse(c, "Get List Author", code, err.Error())
+		return
+	}
+
 	resp, err := h.storages.Author().GetListAuthor(context.Background(), &models.GetListAuthorRequest{
 		Offset: offset,
 		Limit: limit,
-		Search: c.Param("search"),
+		Search: c.Query("search"),
+		Sort: sort,
+		Cursor: cursor,
+		Include_deleted: includeDeleted,
 	})
 
 	if err != nil{
-		h.handlerResponse(c, "Get List Author Storage", 500, err.Error())
+		h.handlerResponse(c, "Get List Author Storage", 500, err)
 		return
 	}
 
@@ -100,9 +129,13 @@ func (h *Handler) GetListAuthor(c *gin.Context) {
 // @Tags Author
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param id path string true "id"
+// @Param include_deleted query boolean false "include deleted records, requires author.write"
 // @Success 200 {object} Response{data=string} "Success Request"
 // @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 403 {object} Response{data=string} "Forbidden"
 // @Failure 500 {object} Response{data=string} "Server Error"
 func (h *Handler) AuthorGetById(c *gin.Context) {
 
@@ -113,9 +146,15 @@ func (h *Handler) AuthorGetById(c *gin.Context) {
 		return
 	}
 
-	resp, err := h.storages.Author().AuthorGetById(context.Background(), &models.AuthorPrimaryKey{Id: id})
+	includeDeleted, code, err := getIncludeDeletedQuery(c, "author")
+	if err != nil{
+		h.handlerResponse(c, "Auhtor Get By Id", code, err.Error())
+		return
+	}
+
+	resp, err := h.storages.Author().AuthorGetById(context.Background(), &models.AuthorPrimaryKey{Id: id, Include_deleted: includeDeleted})
 	if err != nil{
-		h.handlerResponse(c, "Storage Get Author By id", 500, err.Error())
+		h.handlerResponse(c, "Storage Get Author By id", 500, err)
 		return
 	}
 
@@ -132,6 +171,8 @@ func (h *Handler) AuthorGetById(c *gin.Context) {
 // @Tags Author
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param id path string true "id"
 // @Param book body models.UpdateAuthor true "UpdateAuthorkRequest"
 // @Success 200 {object} Response{data=string} "Success Request"
@@ -157,7 +198,7 @@ func (h *Handler) UpdateAuthor(c *gin.Context) {
 
 	rowsAffected, err := h.storages.Author().UpdateAuthor(context.Background(), &updateAuthor)
 	if err != nil{
-		h.handlerResponse(c, "Update Author Storage", http.StatusInternalServerError, err.Error())
+		h.handlerResponse(c, "Update Author Storage", http.StatusInternalServerError, err)
 		return
 	}
 
@@ -168,7 +209,7 @@ func (h *Handler) UpdateAuthor(c *gin.Context) {
 
 	resp, err := h.storages.Author().AuthorGetById(context.Background(), &models.AuthorPrimaryKey{Id: id})
 	if err != nil{
-		h.handlerResponse(c, "Storage Update Author Get By ID", http.StatusInternalServerError, err.Error())
+		h.handlerResponse(c, "Storage Update Author Get By ID", http.StatusInternalServerError, err)
 		return
 	}	
 
@@ -185,9 +226,12 @@ func (h *Handler) UpdateAuthor(c *gin.Context) {
 // @Tags Author
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param id path string true "id"
 // @Success 200 {object} Response{data=string} "Success Request"
 // @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
 // @Failure 500 {object} Response{data=string} "Server Error
 func (h *Handler) DeleteAuthor(c *gin.Context) {
 
@@ -200,10 +244,49 @@ func (h *Handler) DeleteAuthor(c *gin.Context) {
 
 	err := h.storages.Author().DeleteAuthor(context.Background(), &models.AuthorPrimaryKey{Id: id})
 	if err != nil{
-		h.handlerResponse(c, "Storage Delete Author", http.StatusInternalServerError, err.Error())
+		h.handlerResponse(c, "Storage Delete Author", http.StatusInternalServerError, err)
 		return
 	}
 
 	h.handlerResponse(c, "Delete Author", http.StatusOK, nil)
 
-}
\ No newline at end of file
+}
+
+// Restore Author godoc
+// @ID restore_author
+// @Router /author/{id}/restore [POST]
+// @Summary Restore Author
+// @Description Restore Deleted Author
+// @Tags Author
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path string true "id"
+// @Success 200 {object} Response{data=models.Author} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) RestoreAuthor(c *gin.Context) {
+
+	id := c.Param("id")
+
+	if !helper.IsValidUUID(id){
+		h.handlerResponse(c, "Restore Author", http.StatusBadRequest, "Invalid UUID")
+		return
+	}
+
+	err := h.storages.Author().RestoreAuthor(context.Background(), &models.AuthorPrimaryKey{Id: id})
+	if err != nil{
+		h.handlerResponse(c, "Storage Restore Author", http.StatusInternalServerError, err)
+		return
+	}
+
+	resp, err := h.storages.Author().AuthorGetById(context.Background(), &models.AuthorPrimaryKey{Id: id})
+	if err != nil{
+		h.handlerResponse(c, "Restore Author Storage Get By Id", http.StatusInternalServerError, err)
+		return
+	}
+
+	h.handlerResponse(c, "Restore Author", http.StatusOK, resp)
+}
@@ -0,0 +1,264 @@
+package handler
+
+import (
+	"app/api/models"
+	"app/pkg/helper"
+	"app/pkg/token"
+	"app/storage"
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// minPasswordLength is the shortest password accepted on registration.
+const minPasswordLength = 8
+
+// Register godoc
+// @ID register
+// @Router /auth/register [POST]
+// @Summary Register
+// @Description Create a user with a login and password and return a token pair
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param register body models.RegisterRequest true "RegisterRequest"
+// @Success 201 {object} Response{data=models.TokenResponse} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 409 {object} Response{data=string} "Login Taken"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) Register(c *gin.Context) {
+
+	var register models.RegisterRequest
+
+	err := c.ShouldBindJSON(&register)
+	if err != nil {
+		h.handlerResponse(c, "Register", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if len(register.Name) <= 0 {
+		h.handlerResponse(c, "Register", http.StatusBadRequest, "name is required")
+		return
+	}
+
+	// Accounts without credentials could never sign in again.
+	if len(register.Login) <= 0 || len(register.Password) <= 0 {
+		h.handlerResponse(c, "Register", http.StatusBadRequest, "login and password are required")
+		return
+	}
+
+	createUser := models.CreateUser{
+		Name:     register.Name,
+		Login:    register.Login,
+		Password: register.Password,
+	}
+
+	err = hashUserPassword(&createUser)
+	if err != nil {
+		h.handlerResponse(c, "Register", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	id, err := h.storages.User().CreateUser(context.Background(), &createUser)
+	if err != nil {
+		if errors.Is(err, storage.ErrLoginTaken) {
+			h.handlerResponse(c, "Register", http.StatusConflict, err.Error())
+			return
+		}
+
+		h.handlerResponse(c, "Storage Register", http.StatusInternalServerError, err)
+		return
+	}
+
+	resp, err := h.issueTokens(id)
+	if err != nil {
+		h.handlerResponse(c, "Register Issue Tokens", http.StatusInternalServerError, err)
+		return
+	}
+
+	h.handlerResponse(c, "Register", http.StatusCreated, resp)
+}
+
+// Login godoc
+// @ID login
+// @Router /auth/login [POST]
+// @Summary Login
+// @Description Exchange a login and password for a token pair
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param login body models.LoginRequest true "LoginRequest"
+// @Success 200 {object} Response{data=models.TokenResponse} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 401 {object} Response{data=string} "Unauthorized"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) Login(c *gin.Context) {
+
+	var login models.LoginRequest
+
+	err := c.ShouldBindJSON(&login)
+	if err != nil {
+		h.handlerResponse(c, "Login", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	credentials, err := h.storages.User().GetUserCredentials(context.Background(), &login)
+	if err != nil && !storage.IsKind(err, storage.KindNotFound) {
+		h.handlerResponse(c, "Storage Login", http.StatusInternalServerError, err)
+		return
+	}
+
+	if credentials == nil || !helper.CheckPassword(credentials.Password_hash, login.Password) {
+		h.handlerResponse(c, "Login", http.StatusUnauthorized, "invalid login or password")
+		return
+	}
+
+	resp, err := h.issueTokens(credentials.Id)
+	if err != nil {
+		h.handlerResponse(c, "Login Issue Tokens", http.StatusInternalServerError, err)
+		return
+	}
+
+	h.handlerResponse(c, "Login", http.StatusOK, resp)
+}
+
+// Refresh Token godoc
+// @ID refresh_token
+// @Router /auth/refresh [POST]
+// @Summary Refresh Token
+// @Description Exchange a refresh token for a new token pair
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param refresh body models.RefreshTokenRequest true "RefreshTokenRequest"
+// @Success 200 {object} Response{data=models.TokenResponse} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 401 {object} Response{data=string} "Unauthorized"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) RefreshToken(c *gin.Context) {
+
+	var refresh models.RefreshTokenRequest
+
+	err := c.ShouldBindJSON(&refresh)
+	if err != nil {
+		h.handlerResponse(c, "Refresh Token", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	claims, err := token.Parse([]byte(h.cfg.JWTSecret), refresh.Refresh_token)
+	if err == nil && claims.Type != token.TypeRefresh {
+		err = errors.New("not a refresh token")
+	}
+	if err != nil {
+		h.handlerResponse(c, "Refresh Token", http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	_, err = h.storages.User().UserGetByID(context.Background(), &models.UserPrimaryKey{Id: claims.Subject})
+	if storage.IsKind(err, storage.KindNotFound) {
+		h.handlerResponse(c, "Refresh Token", http.StatusUnauthorized, "user no longer exists")
+		return
+	}
+	if err != nil {
+		h.handlerResponse(c, "Storage Refresh Token", http.StatusInternalServerError, err)
+		return
+	}
+
+	resp, err := h.issueTokens(claims.Subject)
+	if err != nil {
+		h.handlerResponse(c, "Refresh Token Issue Tokens", http.StatusInternalServerError, err)
+		return
+	}
+
+	h.handlerResponse(c, "Refresh Token", http.StatusOK, resp)
+}
+
+// Me godoc
+// @ID me
+// @Router /me [GET]
+// @Summary Me
+// @Description Get the authenticated user
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} Response{data=models.User} "Success Request"
+// @Response 401 {object} Response{data=string} "Unauthorized"
+// @Response 403 {object} Response{data=string} "Forbidden"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) Me(c *gin.Context) {
+
+	if len(c.GetString(contextApiKeyId)) > 0 {
+		h.handlerResponse(c, "Me", http.StatusForbidden, "API keys do not belong to a user")
+		return
+	}
+
+	resp, err := h.storages.User().UserGetByID(context.Background(), &models.UserPrimaryKey{Id: c.GetString(contextUserId)})
+	if storage.IsKind(err, storage.KindNotFound) {
+		h.handlerResponse(c, "Me", http.StatusUnauthorized, "user no longer exists")
+		return
+	}
+	if err != nil {
+		h.handlerResponse(c, "Storage Me", http.StatusInternalServerError, err)
+		return
+	}
+
+	h.handlerResponse(c, "Me", http.StatusOK, resp)
+}
+
+// issueTokens signs a new access and refresh token pair for userId.
+func (h *Handler) issueTokens(userId string) (*models.TokenResponse, error) {
+
+	var (
+		secret     = []byte(h.cfg.JWTSecret)
+		accessTTL  = time.Duration(h.cfg.AccessTokenTTL) * time.Minute
+		refreshTTL = time.Duration(h.cfg.RefreshTokenTTL) * time.Hour
+	)
+
+	access, err := token.Sign(secret, token.NewClaims(userId, token.TypeAccess, accessTTL))
+	if err != nil {
+		return nil, err
+	}
+
+	refresh, err := token.Sign(secret, token.NewClaims(userId, token.TypeRefresh, refreshTTL))
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TokenResponse{
+		Access_token:  access,
+		Refresh_token: refresh,
+		Token_type:    "Bearer",
+		Expires_in:    int(accessTTL.Seconds()),
+	}, nil
+}
+
+// hashUserPassword replaces the password of req with its bcrypt hash. A user
+// may be created without credentials, but a login and password come together.
+func hashUserPassword(req *models.CreateUser) error {
+
+	if len(req.Login) <= 0 && len(req.Password) <= 0 {
+		return nil
+	}
+
+	if len(req.Login) <= 0 {
+		return errors.New("login is required")
+	}
+
+	if len(req.Password) < minPasswordLength {
+		return errors.New("password must be at least 8 characters long")
+	}
+
+	hash, err := helper.HashPassword(req.Password)
+	if err != nil {
+		return err
+	}
+
+	req.Password = ""
+	req.Password_hash = hash
+
+	return nil
+}
@@ -17,6 +17,8 @@ import (
 // @Tags Book
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param book body models.CreateBook true "CreateBookRequest"
 // @Success 200 {object} Response{data=string} "Success Request"
 // @Response 400 {object} Response{data=string} "Bad Request"
@@ -33,13 +35,13 @@ func (h *Handler) CreateBook(c *gin.Context) {
 
 	id, err := h.storages.Book().Create(context.Background(), &createBook)
 	if err != nil {
-		h.handlerResponse(c, "storage.book.create", http.StatusInternalServerError, err.Error())
+		h.handlerResponse(c, "storage.book.create", http.StatusInternalServerError, err)
 		return
 	}
 
 	resp, err := h.storages.Book().GetByID(context.Background(), &models.BookPrimaryKey{Id: id})
 	if err != nil {
-		h.handlerResponse(c, "storage.book.getByID", http.StatusInternalServerError, err.Error())
+		h.handlerResponse(c, "storage.book.getByID", http.StatusInternalServerError, err)
 		return
 	}
 
@@ -54,9 +56,13 @@ func (h *Handler) CreateBook(c *gin.Context) {
 // @Tags Book
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param id path string true "id"
+// @Param include_deleted query boolean false "include deleted records, requires book.write"
 // @Success 200 {object} Response{data=string} "Success Request"
 // @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 403 {object} Response{data=string} "Forbidden"
 // @Failure 500 {object} Response{data=string} "Server Error"
 func (h *Handler) GetByIdBook(c *gin.Context) {
 
@@ -67,9 +73,15 @@ func (h *Handler) GetByIdBook(c *gin.Context) {
 		return
 	}
 
-	resp, err := h.storages.Book().GetByID(context.Background(), &models.BookPrimaryKey{Id: id})
+	includeDeleted, code, err := getIncludeDeletedQuery(c, "book")
 	if err != nil {
-		h.handlerResponse(c, "storage.book.getByID", http.StatusInternalServerError, err.Error())
+		h.handlerResponse(c, "get by id book", code, err.Error())
+		return
+	}
+
+	resp, err := h.storages.Book().GetByID(context.Background(), &models.BookPrimaryKey{Id: id, Include_deleted: includeDeleted})
+	if err != nil {
+		h.handlerResponse(c, "storage.book.getByID", http.StatusInternalServerError, err)
 		return
 	}
 
@@ -85,11 +97,19 @@ func (h *Handler) GetByIdBook(c *gin.Context) {
 // @Tags Book
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param offset query string false "offset"
 // @Param limit query string false "limit"
-// @Param search query string false "search"
+// @Param search query string false "search by name"
+// @Param sort query string false "comma separated fields, prefixed with - for descending: name, price, count, sell_price, profit, created_at, updated_at"
+// @Param cursor query string false "keyset paging from the newest record: pass an empty cursor to start, then next_cursor"
+// @Param min_price query number false "min_price"
+// @Param max_price query number false "max_price"
+// @Param include_deleted query boolean false "include deleted records, requires book.write"
 // @Success 200 {object} Response{data=string} "Success Request"
 // @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 403 {object} Response{data=string} "Forbidden"
 // @Failure 500 {object} Response{data=string} "Server Error"
 func (h *Handler) GetListBook(c *gin.Context) {
 
@@ -105,13 +125,48 @@ func (h *Handler) GetListBook(c *gin.Context) {
 		return
 	}
 
+	sort, err := models.ParseSort(c.Query("sort"), models.BookSortFields)
+	if err != nil {
+		h.handlerResponse(c, "get list book", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	cursor, err := getCursorQuery(c, offset, limit)
+	if err != nil {
+		h.handlerResponse(c, "get list book", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	minPrice, err := getFloatQuery(c, "min_price")
+	if err != nil {
+		h.handlerResponse(c, "get list book", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	maxPrice, err := getFloatQuery(c, "max_price")
+	if err != nil {
+		h.handlerResponse(c, "get list book", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	includeDeleted, code, err := getIncludeDeletedQuery(c, "book")
+	if err != nil {
+		h.handlerResponse(c, "get list book", code, err.Error())
+		return
+	}
+
 	resp, err := h.storages.Book().GetList(context.Background(), &models.GetListBookRequest{
-		Offset: offset,
-		Limit:  limit,
-		Search: c.Query("search"),
+		Offset:          offset,
+		Limit:           limit,
+		Search:          c.Query("search"),
+		Sort:            sort,
+		Cursor:          cursor,
+		Min_price:       minPrice,
+		Max_price:       maxPrice,
+		Include_deleted: includeDeleted,
 	})
 	if err != nil {
-		h.handlerResponse(c, "storage.book.getlist", http.StatusInternalServerError, err.Error())
+		h.handlerResponse(c, "storage.book.getlist", http.StatusInternalServerError, err)
 		return
 	}
 
@@ -127,6 +182,8 @@ func (h *Handler) GetListBook(c *gin.Context) {
 // @Tags Book
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param id path string true "id"
 // @Param book body models.UpdateBook true "UpdateBookRequest"
 // @Success 200 {object} Response{data=string} "Success Request"
@@ -153,7 +210,7 @@ func (h *Handler) UpdateBook(c *gin.Context) {
 
 	rowsAffected, err := h.storages.Book().Update(context.Background(), &updateBook)
 	if err != nil {
-		h.handlerResponse(c, "storage.book.update", http.StatusInternalServerError, err.Error())
+		h.handlerResponse(c, "storage.book.update", http.StatusInternalServerError, err)
 		return
 	}
 
@@ -164,7 +221,7 @@ func (h *Handler) UpdateBook(c *gin.Context) {
 
 	resp, err := h.storages.Book().GetByID(context.Background(), &models.BookPrimaryKey{Id: id})
 	if err != nil {
-		h.handlerResponse(c, "storage.book.getByID", http.StatusInternalServerError, err.Error())
+		h.handlerResponse(c, "storage.book.getByID", http.StatusInternalServerError, err)
 		return
 	}
 
@@ -180,9 +237,12 @@ func (h *Handler) UpdateBook(c *gin.Context) {
 // @Tags Book
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param id path string true "id"
 // @Success 200 {object} Response{data=string} "Success Request"
 // @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
 // @Failure 500 {object} Response{data=string} "Server Error
 func (h *Handler) DeleteBook(c *gin.Context) {
 
@@ -195,9 +255,48 @@ func (h *Handler) DeleteBook(c *gin.Context) {
 
 	err := h.storages.Book().Delete(context.Background(), &models.BookPrimaryKey{Id: id})
 	if err != nil {
-		h.handlerResponse(c, "storage.book.update", http.StatusInternalServerError, err.Error())
+		h.handlerResponse(c, "storage.book.update", http.StatusInternalServerError, err)
 		return
 	}
 
 	h.handlerResponse(c, "update book", http.StatusAccepted, nil)
 }
+
+// Restore Book godoc
+// @ID restore_book
+// @Router /book/{id}/restore [POST]
+// @Summary Restore Book
+// @Description Restore Deleted Book
+// @Tags Book
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path string true "id"
+// @Success 200 {object} Response{data=models.Book} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) RestoreBook(c *gin.Context) {
+
+	id := c.Param("id")
+
+	if !helper.IsValidUUID(id) {
+		h.handlerResponse(c, "restore book", http.StatusBadRequest, "invalid book id")
+		return
+	}
+
+	err := h.storages.Book().Restore(context.Background(), &models.BookPrimaryKey{Id: id})
+	if err != nil {
+		h.handlerResponse(c, "storage.book.restore", http.StatusInternalServerError, err)
+		return
+	}
+
+	resp, err := h.storages.Book().GetByID(context.Background(), &models.BookPrimaryKey{Id: id})
+	if err != nil {
+		h.handlerResponse(c, "storage.book.getByID", http.StatusInternalServerError, err)
+		return
+	}
+
+	h.handlerResponse(c, "restore book", http.StatusOK, resp)
+}
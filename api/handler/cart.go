@@ -0,0 +1,349 @@
+package handler
+
+import (
+	"app/api/models"
+	"app/pkg/helper"
+	"app/storage"
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Create Cart godoc
+// @ID create_cart
+// @Router /cart [POST]
+// @Summary Create cart
+// @Description Get the cart of a customer, creating it if the customer has none yet
+// @Tags Cart
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param cart body models.CreateCart true "CreateCartRequest"
+// @Success 201 {object} Response{data=models.Cart} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) CreateCart(c *gin.Context) {
+
+	var createCart models.CreateCart
+
+	err := c.ShouldBindJSON(&createCart)
+	if err != nil {
+		h.handlerResponse(c, "Create Cart", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if principal := ownScope(c); principal != nil {
+		createCart.Customer_id = principal.Customer_id
+	}
+
+	if !helper.IsValidUUID(createCart.Customer_id) {
+		h.handlerResponse(c, "Create Cart", http.StatusBadRequest, "Invalid customer_id UUID")
+		return
+	}
+
+	id, err := h.storages.Cart().CreateCart(context.Background(), &createCart)
+	if err != nil {
+		h.handlerResponse(c, "Storage Create Cart", http.StatusInternalServerError, err)
+		return
+	}
+
+	resp, err := h.storages.Cart().GetByIdCart(context.Background(), &models.CartPrimaryKey{Id: id})
+	if err != nil {
+		h.handlerResponse(c, "Create Cart Get By ID", http.StatusInternalServerError, err)
+		return
+	}
+
+	h.handlerResponse(c, "Create Cart", http.StatusCreated, resp)
+}
+
+// Get By ID Cart godoc
+// @ID get_by_id_cart
+// @Router /cart/{id} [GET]
+// @Summary Get By ID Cart
+// @Description Get a cart with totals computed from current product prices
+// @Tags Cart
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path string true "id"
+// @Success 200 {object} Response{data=models.Cart} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) GetByIdCart(c *gin.Context) {
+
+	id := c.Param("id")
+	if !helper.IsValidUUID(id) {
+		h.handlerResponse(c, "Get Cart By Id", http.StatusBadRequest, "Invalid UUID")
+		return
+	}
+
+	resp, err := h.storages.Cart().GetByIdCart(context.Background(), &models.CartPrimaryKey{Id: id})
+	if err != nil {
+		h.handlerResponse(c, "Storage Get Cart By Id", http.StatusInternalServerError, err)
+		return
+	}
+
+	h.handlerResponse(c, "Get Cart By Id", http.StatusOK, resp)
+}
+
+// Add Cart Item godoc
+// @ID add_cart_item
+// @Router /cart/{id}/items [POST]
+// @Summary Add Cart Item
+// @Description Add a product to the cart, increasing its quantity if it is already there
+// @Tags Cart
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path string true "id"
+// @Param item body models.AddCartItem true "AddCartItemRequest"
+// @Success 200 {object} Response{data=models.Cart} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) AddCartItem(c *gin.Context) {
+
+	id := c.Param("id")
+	if !helper.IsValidUUID(id) {
+		h.handlerResponse(c, "Add Cart Item", http.StatusBadRequest, "Invalid UUID")
+		return
+	}
+
+	var addItem models.AddCartItem
+
+	err := c.ShouldBindJSON(&addItem)
+	if err != nil {
+		h.handlerResponse(c, "Add Cart Item", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if !helper.IsValidUUID(addItem.Product_id) {
+		h.handlerResponse(c, "Add Cart Item", http.StatusBadRequest, "Invalid product_id UUID")
+		return
+	}
+
+	if addItem.Quantity <= 0 {
+		h.handlerResponse(c, "Add Cart Item", http.StatusBadRequest, "quantity must be greater than zero")
+		return
+	}
+
+	addItem.Cart_id = id
+
+	rowsAffected, err := h.storages.Cart().AddCartItem(context.Background(), &addItem)
+	if err != nil {
+		h.handlerResponse(c, "Storage Add Cart Item", http.StatusInternalServerError, err)
+		return
+	}
+
+	if rowsAffected <= 0 {
+		h.handlerResponse(c, "Add Cart Item", http.StatusBadRequest, "product not found")
+		return
+	}
+
+	resp, err := h.storages.Cart().GetByIdCart(context.Background(), &models.CartPrimaryKey{Id: id})
+	if err != nil {
+		h.handlerResponse(c, "Add Cart Item Get By ID", http.StatusInternalServerError, err)
+		return
+	}
+
+	h.handlerResponse(c, "Add Cart Item", http.StatusOK, resp)
+}
+
+// Update Cart Item godoc
+// @ID update_cart_item
+// @Router /cart/{id}/items/{product_id} [PUT]
+// @Summary Update Cart Item
+// @Description Set the quantity of a product in the cart
+// @Tags Cart
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path string true "id"
+// @Param product_id path string true "product_id"
+// @Param item body models.UpdateCartItem true "UpdateCartItemRequest"
+// @Success 200 {object} Response{data=models.Cart} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) UpdateCartItem(c *gin.Context) {
+
+	id := c.Param("id")
+	productId := c.Param("product_id")
+	if !helper.IsValidUUID(id) || !helper.IsValidUUID(productId) {
+		h.handlerResponse(c, "Update Cart Item", http.StatusBadRequest, "Invalid UUID")
+		return
+	}
+
+	var updateItem models.UpdateCartItem
+
+	err := c.ShouldBindJSON(&updateItem)
+	if err != nil {
+		h.handlerResponse(c, "Update Cart Item", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if updateItem.Quantity <= 0 {
+		h.handlerResponse(c, "Update Cart Item", http.StatusBadRequest, "quantity must be greater than zero")
+		return
+	}
+
+	updateItem.Cart_id = id
+	updateItem.Product_id = productId
+
+	rowsAffected, err := h.storages.Cart().UpdateCartItem(context.Background(), &updateItem)
+	if err != nil {
+		h.handlerResponse(c, "Storage Update Cart Item", http.StatusInternalServerError, err)
+		return
+	}
+
+	if rowsAffected <= 0 {
+		h.handlerResponse(c, "Update Cart Item", http.StatusBadRequest, "No Rows Affected")
+		return
+	}
+
+	resp, err := h.storages.Cart().GetByIdCart(context.Background(), &models.CartPrimaryKey{Id: id})
+	if err != nil {
+		h.handlerResponse(c, "Update Cart Item Get By ID", http.StatusInternalServerError, err)
+		return
+	}
+
+	h.handlerResponse(c, "Update Cart Item", http.StatusOK, resp)
+}
+
+// Delete Cart Item godoc
+// @ID delete_cart_item
+// @Router /cart/{id}/items/{product_id} [DELETE]
+// @Summary Delete Cart Item
+// @Description Remove a product from the cart
+// @Tags Cart
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path string true "id"
+// @Param product_id path string true "product_id"
+// @Success 200 {object} Response{data=models.Cart} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) DeleteCartItem(c *gin.Context) {
+
+	id := c.Param("id")
+	productId := c.Param("product_id")
+	if !helper.IsValidUUID(id) || !helper.IsValidUUID(productId) {
+		h.handlerResponse(c, "Delete Cart Item", http.StatusBadRequest, "Invalid UUID")
+		return
+	}
+
+	err := h.storages.Cart().DeleteCartItem(context.Background(), &models.CartItemPrimaryKey{Cart_id: id, Product_id: productId})
+	if err != nil {
+		h.handlerResponse(c, "Storage Delete Cart Item", http.StatusInternalServerError, err)
+		return
+	}
+
+	resp, err := h.storages.Cart().GetByIdCart(context.Background(), &models.CartPrimaryKey{Id: id})
+	if err != nil {
+		h.handlerResponse(c, "Delete Cart Item Get By ID", http.StatusInternalServerError, err)
+		return
+	}
+
+	h.handlerResponse(c, "Delete Cart Item", http.StatusOK, resp)
+}
+
+// Checkout Cart godoc
+// @ID checkout_cart
+// @Router /cart/{id}/checkout [POST]
+// @Summary Checkout Cart
+// @Description Turn the cart into an order priced from current product prices and empty the cart
+// @Tags Cart
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path string true "id"
+// @Param checkout body models.CheckoutCart true "CheckoutCartRequest"
+// @Param Idempotency-Key header string false "makes retries of the same checkout return the first response"
+// @Success 201 {object} Response{data=models.Order} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 402 {object} Response{data=storage.InsufficientBalanceError} "Insufficient Balance"
+// @Response 409 {object} Response{data=storage.UnavailableProductsError} "Unavailable Products"
+// @Response 422 {object} Response{data=string} "Idempotency Key Reused"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) CheckoutCart(c *gin.Context) {
+
+	id := c.Param("id")
+	if !helper.IsValidUUID(id) {
+		h.handlerResponse(c, "Checkout Cart", http.StatusBadRequest, "Invalid UUID")
+		return
+	}
+
+	var checkout models.CheckoutCart
+
+	err := c.ShouldBindJSON(&checkout)
+	if err != nil {
+		h.handlerResponse(c, "Checkout Cart", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if principal := ownScope(c); principal != nil {
+		checkout.User_id = principal.User_id
+	}
+
+	checkout.Api_key_id = c.GetString(contextApiKeyId)
+
+	if !helper.IsValidUUID(checkout.User_id) {
+		h.handlerResponse(c, "Checkout Cart", http.StatusBadRequest, "Invalid user_id UUID")
+		return
+	}
+
+	if len(checkout.Courier_id) > 0 && !helper.IsValidUUID(checkout.Courier_id) {
+		h.handlerResponse(c, "Checkout Cart", http.StatusBadRequest, "Invalid courier_id UUID")
+		return
+	}
+
+	if len(checkout.Address_id) > 0 {
+		if !helper.IsValidUUID(checkout.Address_id) {
+			h.handlerResponse(c, "Checkout Cart", http.StatusBadRequest, "Invalid address_id UUID")
+			return
+		}
+	} else if !helper.IsValidCoordinate(checkout.Latitude, checkout.Longtitude) {
+		h.handlerResponse(c, "Checkout Cart", http.StatusBadRequest, "Invalid Coordinates")
+		return
+	}
+
+	checkout.Cart_id = id
+
+	var resp *models.Order
+
+	// The order is read in the transaction of the checkout, so the response
+	// is the order as it was placed.
+	err = h.storages.WithTx(context.Background(), func(tx storage.StorageI) error {
+
+		orderId, err := tx.Cart().Checkout(context.Background(), &checkout)
+		if err != nil {
+			return err
+		}
+
+		resp, err = tx.Order().GetByIdOrder(context.Background(), &models.OrderPrimaryKey{Id: orderId})
+		return err
+	})
+	if err != nil {
+		if code, message, ok := orderCreationError(err); ok {
+			h.handlerResponse(c, "Checkout Cart", code, message)
+			return
+		}
+
+		if errors.Is(err, storage.ErrCartEmpty) {
+			h.handlerResponse(c, "Checkout Cart", http.StatusBadRequest, err.Error())
+			return
+		}
+
+		h.handlerResponse(c, "Storage Checkout Cart", http.StatusInternalServerError, err)
+		return
+	}
+
+	h.handlerResponse(c, "Checkout Cart", http.StatusCreated, resp)
+}
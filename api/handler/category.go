@@ -17,6 +17,8 @@ import (
 // @Tags Category
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param category body models.CreateCategory true "CreateCategoryRequest"
 // @Success 200 {object} Response{data=string} "Success Request"
 // @Response 400 {object} Response{data=string} "Bad Request"
@@ -33,13 +35,13 @@ func (h *Handler) CreateCategory(c *gin.Context) {
 
 	id, err := h.storages.Category().CreateCategory(context.Background(), &createCategory)
 	if err != nil{
-		h.handlerResponse(c, "Storage Create Category", 500, err.Error())
+		h.handlerResponse(c, "Storage Create Category", 500, err)
 		return
 	}
 
 	resp, err := h.storages.Category().GetByIdCategory(context.Background(), &models.CategoryPrimaryKey{Id: id})
 	if err != nil{
-		h.handlerResponse(c, "Storage Create Category Get By ID", 500, err.Error())
+		h.handlerResponse(c, "Storage Create Category Get By ID", 500, err)
 		return
 	}
 
@@ -55,9 +57,13 @@ func (h *Handler) CreateCategory(c *gin.Context) {
 // @Tags Category
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param id path string true "id"
+// @Param include_deleted query boolean false "include deleted records, requires category.write"
 // @Success 200 {object} Response{data=string} "Success Request"
 // @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 403 {object} Response{data=string} "Forbidden"
 // @Failure 500 {object} Response{data=string} "Server Error"
 func (h *Handler) GetByIdCategory(c *gin.Context) {
 
@@ -67,9 +73,15 @@ func (h *Handler) GetByIdCategory(c *gin.Context) {
 		return
 	}
 
-	resp, err := h.storages.Category().GetByIdCategory(context.Background(), &models.CategoryPrimaryKey{Id: id})
+	includeDeleted, code, err := getIncludeDeletedQuery(c, "category")
+	if err != nil{
+		h.handlerResponse(c, "Get By ID Category", code, err.Error())
+		return
+	}
+
+	resp, err := h.storages.Category().GetByIdCategory(context.Background(), &models.CategoryPrimaryKey{Id: id, Include_deleted: includeDeleted})
 	if err != nil{
-		h.handlerResponse(c, "Storage Get By ID", 500, err.Error())
+		h.handlerResponse(c, "Storage Get By ID", 500, err)
 		return
 	}
 
@@ -85,34 +97,61 @@ func (h *Handler) GetByIdCategory(c *gin.Context) {
 // @Tags Category
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param offset query string false "offset"
 // @Param limit query string false "limit"
 // @Param search query string false "search"
+// @Param sort query string false "comma separated fields, prefixed with - for descending: name"
+// @Param cursor query string false "keyset paging from the newest record: pass an empty cursor to start, then next_cursor"
+// @Param include_deleted query boolean false "include deleted records, requires category.write"
 // @Success 200 {object} Response{data=string} "Success Request"
 // @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 403 {object} Response{data=string} "Forbidden"
 // @Failure 500 {object} Response{data=string} "Server Error"
 func (h *Handler) GetListCategory(c *gin.Context) {
 
-	offset, err := h.getOffsetQuery(c.Param("offset"))
+	offset, err := h.getOffsetQuery(c.Query("offset"))
+	if err != nil{
+		h.handlerResponse(c, "Get List Category", 400, err.Error())
+		return
+	}
+
+	limit, err := h.getLimitQuery(c.Query("limit"))
+	if err != nil{
+		h.handlerResponse(c, "Get List Category", 400, err.Error())
+		return
+	}
+
+	sort, err := models.ParseSort(c.Query("sort"), models.CategorySortFields)
 	if err != nil{
 		h.handlerResponse(c, "Get List Category", 400, err.Error())
 		return
 	}
 
-	limit, err := h.getLimitQuery(c.Param("limit"))
+	cursor, err := getCursorQuery(c, offset, limit)
 	if err != nil{
 		h.handlerResponse(c, "Get List Category", 400, err.Error())
 		return
 	}
 
+	includeDeleted, code, err := getIncludeDeletedQuery(c, "category")
+	if err != nil{
+		h.handlerResponse(c, "Get List Category", code, err.Error())
+		return
+	}
+
 	resp, err := h.storages.Category().GetListCategory(context.Background(), &models.GetListCatogoryRequest{
 		Offset: offset,
 		Limit: limit,
-		Search: c.Param("search"),
+		Search: c.Query("search"),
+		Sort: sort,
+		Include_deleted: includeDeleted,
+		Cursor: cursor,
 	})
 
 	if err != nil{
-		h.handlerResponse(c, "Storage Get List Category", 500, err.Error())
+		h.handlerResponse(c, "Storage Get List Category", 500, err)
 		return
 	}
 
@@ -128,6 +167,8 @@ func (h *Handler) GetListCategory(c *gin.Context) {
 // @Tags Category
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param id path string true "id"
 // @Param category body models.UpdateCategory true "UpdateCategoryRequest"
 // @Success 200 {object} Response{data=string} "Success Request"
@@ -153,7 +194,7 @@ func (h *Handler) UpdateCategory(c *gin.Context) {
 
 	rowsAffected, err := h.storages.Category().UpdateCategory(context.Background(), &update_category)
 	if err != nil{
-		h.handlerResponse(c, "Storage Update Category", 500, err.Error())
+		h.handlerResponse(c, "Storage Update Category", 500, err)
 		return
 	}
 
@@ -164,7 +205,7 @@ func (h *Handler) UpdateCategory(c *gin.Context) {
 
 	resp, err := h.storages.Category().GetByIdCategory(context.Background(), &models.CategoryPrimaryKey{Id: id})
 	if err != nil{
-		h.handlerResponse(c, "Storage Update Category Get By ID", 500, err.Error())
+		h.handlerResponse(c, "Storage Update Category Get By ID", 500, err)
 		return
 	}
 
@@ -180,9 +221,12 @@ func (h *Handler) UpdateCategory(c *gin.Context) {
 // @Tags Category
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param id path string true "id"
 // @Success 200 {object} Response{data=string} "Success Request"
 // @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
 // @Failure 500 {object} Response{data=string} "Server Error
 func (h *Handler) DeleteCategory(c *gin.Context) {
 
@@ -194,9 +238,47 @@ func (h *Handler) DeleteCategory(c *gin.Context) {
 
 	err := h.storages.Category().DeleteCategory(context.Background(), &models.CategoryPrimaryKey{Id: id})
 	if err != nil{
-		h.handlerResponse(c, "Storage Delete Category", 500, err.Error())
+		h.handlerResponse(c, "Storage Delete Category", 500, err)
 		return
 	}
 
 	h.handlerResponse(c, "Delete Category", http.StatusOK, nil)
-}
\ No newline at end of file
+}
+
+// Restore Category godoc
+// @ID restore_category
+// @Router /category/{id}/restore [POST]
+// @Summary Restore Category
+// @Description Restore Deleted Category
+// @Tags Category
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path string true "id"
+// @Success 200 {object} Response{data=models.Category} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) RestoreCategory(c *gin.Context) {
+
+	id := c.Param("id")
+	if !helper.IsValidUUID(id){
+		h.handlerResponse(c, "Restore Category", http.StatusBadRequest, "Invalid UUID")
+		return
+	}
+
+	err := h.storages.Category().RestoreCategory(context.Background(), &models.CategoryPrimaryKey{Id: id})
+	if err != nil{
+		h.handlerResponse(c, "Storage Restore Category", 500, err)
+		return
+	}
+
+	resp, err := h.storages.Category().GetByIdCategory(context.Background(), &models.CategoryPrimaryKey{Id: id})
+	if err != nil{
+		h.handlerResponse(c, "Restore Category Storage Get By Id", 500, err)
+		return
+	}
+
+	h.handlerResponse(c, "Restore Category", http.StatusOK, resp)
+}
@@ -4,7 +4,10 @@ import (
 	"app/api/models"
 	"app/pkg/helper"
 	"context"
+	"errors"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -17,6 +20,8 @@ import (
 // @Tags Courier
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param courier body models.CreateCourier true "CreateCourierRequest"
 // @Success 200 {object} Response{data=string} "Success Request"
 // @Response 400 {object} Response{data=string} "Bad Request"
@@ -31,15 +36,21 @@ func (h *Handler) CreateCourier(c *gin.Context) {
 		return 
 	}
 
+	err = validateCourierLocation(createCourier.Latitude, createCourier.Longitude)
+	if err != nil{
+		h.handlerResponse(c, "Create Courier", http.StatusBadRequest, err.Error())
+		return
+	}
+
 	id, err := h.storages.Courier().CreateCourier(context.Background(), &createCourier)
 	if err != nil{
-		h.handlerResponse(c, "Storage Create Courier", 500, err.Error())
+		h.handlerResponse(c, "Storage Create Courier", 500, err)
 		return
 	}
 
 	resp, err := h.storages.Courier().GetByIDCourier(context.Background(), &models.CourierPrimaryKey{Id: id})
 	if err != nil{
-		h.handlerResponse(c, "Create Courier Storage GET BY ID", 500, err.Error())
+		h.handlerResponse(c, "Create Courier Storage GET BY ID", 500, err)
 		return
 	}
 
@@ -55,9 +66,13 @@ func (h *Handler) CreateCourier(c *gin.Context) {
 // @Tags Courier
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param id path string true "id"
+// @Param include_deleted query boolean false "include deleted records, requires courier.write"
 // @Success 200 {object} Response{data=string} "Success Request"
 // @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 403 {object} Response{data=string} "Forbidden"
 // @Failure 500 {object} Response{data=string} "Server Error"
 func (h *Handler) GetByIDCourier(c *gin.Context) {
 
@@ -67,9 +82,16 @@ func (h *Handler) GetByIDCourier(c *gin.Context) {
 		return
 	}
 
-	resp, err := h.storages.Courier().GetByIDCourier(context.Background(), &models.CourierPrimaryKey{Id: id})
+	includeDeleted, code, err := getIncludeDeletedQuery(c, "courier")
+	if err != nil{
+		h.handlerResponse(c, "Get Courier By Id", code, err.Error())
+		return
+	}
+
+	resp, err := h.storages.Courier().GetByIDCourier(context.Background(), &models.CourierPrimaryKey{Id: id, Include_deleted: includeDeleted})
 	if err != nil{
-		h.handlerResponse(c, "Storage Get Courier By ID", 500, err.Error())
+		h.handlerResponse(c, "Storage Get Courier By ID", 500, err)
+		return
 	}
 	
 	h.handlerResponse(c, "Courier Get By Id", http.StatusOK, resp)
@@ -84,34 +106,69 @@ func (h *Handler) GetByIDCourier(c *gin.Context) {
 // @Tags Courier
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param offset query string false "offset"
 // @Param limit query string false "limit"
-// @Param search query string false "search"
+// @Param search query string false "search by name or phone number"
+// @Param sort query string false "comma separated fields, prefixed with - for descending: name, phone_number, created_at, updated_at"
+// @Param cursor query string false "keyset paging from the newest record: pass an empty cursor to start, then next_cursor"
+// @Param is_available query boolean false "is_available"
+// @Param include_deleted query boolean false "include deleted records, requires courier.write"
 // @Success 200 {object} Response{data=string} "Success Request"
 // @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 403 {object} Response{data=string} "Forbidden"
 // @Failure 500 {object} Response{data=string} "Server Error"
 func (h *Handler) GetListCourier(c *gin.Context) {
 
-	offset, err := h.getOffsetQuery(c.Param("offset"))
+	offset, err := h.getOffsetQuery(c.Query("offset"))
+	if err != nil{
+		h.handlerResponse(c, "Get List Courier", 400, err.Error())
+		return
+	}
+
+	limit, err := h.getLimitQuery(c.Query("limit"))
 	if err != nil{
 		h.handlerResponse(c, "Get List Courier", 400, err.Error())
 		return
 	}
 
-	limit, err := h.getLimitQuery(c.Param("limit"))
+	sort, err := models.ParseSort(c.Query("sort"), models.CourierSortFields)
 	if err != nil{
 		h.handlerResponse(c, "Get List Courier", 400, err.Error())
 		return
 	}
 
+	cursor, err := getCursorQuery(c, offset, limit)
+	if err != nil{
+		h.handlerResponse(c, "Get List Courier", 400, err.Error())
+		return
+	}
+
+	isAvailable, err := getBoolQuery(c, "is_available")
+	if err != nil{
+		h.handlerResponse(c, "Get List Courier", 400, err.Error())
+		return
+	}
+
+	includeDeleted, code, err := getIncludeDeletedQuery(c, "courier")
+	if err != nil{
+		h.handlerResponse(c, "Get List Courier", code, err.Error())
+		return
+	}
+
 	resp, err := h.storages.Courier().GetListCourier(context.Background(), &models.GetListCourierRequest{
 		Offset: offset,
 		Limit: limit,
-		Search: c.Param("search"),
+		Search: c.Query("search"),
+		Sort: sort,
+		Cursor: cursor,
+		Is_available: isAvailable,
+		Include_deleted: includeDeleted,
 	})
 
 	if err != nil{
-		h.handlerResponse(c, "Storage Get List Courier", 500, err.Error())
+		h.handlerResponse(c, "Storage Get List Courier", 500, err)
 		return 
 	}
 
@@ -127,6 +184,8 @@ func (h *Handler) GetListCourier(c *gin.Context) {
 // @Tags Courier
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param id path string true "id"
 // @Param courier body models.UpdateCourier true "UpdateCourierRequest"
 // @Success 200 {object} Response{data=string} "Success Request"
@@ -149,11 +208,17 @@ func (h *Handler) UpdateCourier(c *gin.Context) {
 		return
 	}
 
+	err = validateCourierLocation(updateCourier.Latitude, updateCourier.Longitude)
+	if err != nil{
+		h.handlerResponse(c, "Update Courier", http.StatusBadRequest, err.Error())
+		return
+	}
+
 	updateCourier.Id = id
 
 	rows, err := h.storages.Courier().UpdateCourier(context.Background(), &updateCourier)
 	if err != nil{
-		h.handlerResponse(c, "Storage Update Courier", 500, err.Error())
+		h.handlerResponse(c, "Storage Update Courier", 500, err)
 		return
 	}
 
@@ -164,7 +229,7 @@ func (h *Handler) UpdateCourier(c *gin.Context) {
 	
 	resp, err := h.storages.Courier().GetByIDCourier(context.Background(), &models.CourierPrimaryKey{Id: id})
 	if err != nil{
-		h.handlerResponse(c, "Update Courier Get By Id Storage", 500, err.Error())
+		h.handlerResponse(c, "Update Courier Get By Id Storage", 500, err)
 		return
 	}
 
@@ -180,9 +245,12 @@ func (h *Handler) UpdateCourier(c *gin.Context) {
 // @Tags Courier
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param id path string true "id"
 // @Success 200 {object} Response{data=string} "Success Request"
 // @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
 // @Failure 500 {object} Response{data=string} "Server Error
 func (h *Handler) DeleteCourier(c *gin.Context){
 
@@ -194,9 +262,255 @@ func (h *Handler) DeleteCourier(c *gin.Context){
 
 	err := h.storages.Courier().DeleteCourier(context.Background(), &models.CourierPrimaryKey{Id: id})
 	if err != nil{
-		h.handlerResponse(c, "Storage Delete Courier", 500, err.Error())
+		h.handlerResponse(c, "Storage Delete Courier", 500, err)
 		return
 	}
 
 	h.handlerResponse(c, "Delte Courier", 200, nil)
-}
\ No newline at end of file
+}
+
+// Restore Courier godoc
+// @ID restore_courier
+// @Router /courier/{id}/restore [POST]
+// @Summary Restore Courier
+// @Description Restore Deleted Courier
+// @Tags Courier
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path string true "id"
+// @Success 200 {object} Response{data=models.Courier} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) RestoreCourier(c *gin.Context) {
+
+	id := c.Param("id")
+	if !helper.IsValidUUID(id){
+		h.handlerResponse(c, "Restore Courier", http.StatusBadRequest, "Invalid UUID")
+		return
+	}
+
+	err := h.storages.Courier().RestoreCourier(context.Background(), &models.CourierPrimaryKey{Id: id})
+	if err != nil{
+		h.handlerResponse(c, "Storage Restore Courier", 500, err)
+		return
+	}
+
+	resp, err := h.storages.Courier().GetByIDCourier(context.Background(), &models.CourierPrimaryKey{Id: id})
+	if err != nil{
+		h.handlerResponse(c, "Restore Courier Storage Get By Id", 500, err)
+		return
+	}
+
+	h.handlerResponse(c, "Restore Courier", http.StatusOK, resp)
+}
+
+// maxLocationClockSkew is how far in the future a courier's clock may date a
+// location. Later points would hold back every real update after them.
+const maxLocationClockSkew = time.Minute
+
+// Create Courier Location godoc
+// @ID create_courier_location
+// @Router /courier/{id}/location [POST]
+// @Summary Create Courier Location
+// @Description Report the current GPS position of a courier. recorded_at may not be more than a minute in the future.
+// @Tags Courier
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path string true "id"
+// @Param location body models.CreateCourierLocation true "CreateCourierLocationRequest"
+// @Success 201 {object} Response{data=models.CourierLocation} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) CreateCourierLocation(c *gin.Context) {
+
+	id := c.Param("id")
+	if !helper.IsValidUUID(id){
+		h.handlerResponse(c, "Create Courier Location", http.StatusBadRequest, "Invalid UUID")
+		return
+	}
+
+	var location models.CreateCourierLocation
+
+	err := c.ShouldBindJSON(&location)
+	if err != nil{
+		h.handlerResponse(c, "Create Courier Location", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if !helper.IsValidCoordinate(location.Latitude, location.Longitude){
+		h.handlerResponse(c, "Create Courier Location", http.StatusBadRequest, "Invalid Coordinates")
+		return
+	}
+
+	if len(location.Recorded_at) > 0{
+		recordedAt, err := time.Parse(time.RFC3339, location.Recorded_at)
+		if err != nil{
+			h.handlerResponse(c, "Create Courier Location", http.StatusBadRequest, "recorded_at must be an RFC3339 timestamp")
+			return
+		}
+
+		if recordedAt.After(time.Now().Add(maxLocationClockSkew)){
+			h.handlerResponse(c, "Create Courier Location", http.StatusBadRequest, "recorded_at cannot be in the future")
+			return
+		}
+	}
+
+	location.Courier_id = id
+
+	rowsAffected, err := h.storages.Courier().CreateCourierLocation(context.Background(), &location)
+	if err != nil{
+		h.handlerResponse(c, "Storage Create Courier Location", http.StatusInternalServerError, err)
+		return
+	}
+
+	if rowsAffected <= 0{
+		h.handlerResponse(c, "Create Courier Location", http.StatusBadRequest, "No Rows Affected")
+		return
+	}
+
+	resp, err := h.storages.Courier().GetCourierLocation(context.Background(), &models.CourierPrimaryKey{Id: id})
+	if err != nil{
+		h.handlerResponse(c, "Create Courier Location Get", http.StatusInternalServerError, err)
+		return
+	}
+
+	h.handlerResponse(c, "Create Courier Location", http.StatusCreated, resp)
+}
+
+
+// Get Courier Location godoc
+// @ID get_courier_location
+// @Router /courier/{id}/location [GET]
+// @Summary Get Courier Location
+// @Description Get the latest reported position of a courier
+// @Tags Courier
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path string true "id"
+// @Success 200 {object} Response{data=models.CourierLocation} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "No Location Reported"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) GetCourierLocation(c *gin.Context) {
+
+	id := c.Param("id")
+	if !helper.IsValidUUID(id){
+		h.handlerResponse(c, "Get Courier Location", http.StatusBadRequest, "Invalid UUID")
+		return
+	}
+
+	resp, err := h.storages.Courier().GetCourierLocation(context.Background(), &models.CourierPrimaryKey{Id: id})
+	if err != nil{
+		h.handlerResponse(c, "Storage Get Courier Location", http.StatusInternalServerError, err)
+		return
+	}
+
+	if resp == nil{
+		h.handlerResponse(c, "Get Courier Location", http.StatusNotFound, "courier has not reported a location")
+		return
+	}
+
+	h.handlerResponse(c, "Get Courier Location", http.StatusOK, resp)
+}
+
+
+// Get Courier Track godoc
+// @ID get_courier_track
+// @Router /courier/{id}/track [GET]
+// @Summary Get Courier Track
+// @Description Get the path of a courier between two RFC3339 timestamps, the last 24 hours by default
+// @Tags Courier
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path string true "id"
+// @Param from query string false "from"
+// @Param to query string false "to"
+// @Param limit query string false "limit"
+// @Success 200 {object} Response{data=models.GetCourierTrackResponse} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) GetCourierTrack(c *gin.Context) {
+
+	id := c.Param("id")
+	if !helper.IsValidUUID(id){
+		h.handlerResponse(c, "Get Courier Track", http.StatusBadRequest, "Invalid UUID")
+		return
+	}
+
+	to := time.Now()
+	if len(c.Query("to")) > 0{
+		parsed, err := time.Parse(time.RFC3339, c.Query("to"))
+		if err != nil{
+			h.handlerResponse(c, "Get Courier Track", http.StatusBadRequest, "to must be an RFC3339 timestamp")
+			return
+		}
+		to = parsed
+	}
+
+	from := to.Add(-24 * time.Hour)
+	if len(c.Query("from")) > 0{
+		parsed, err := time.Parse(time.RFC3339, c.Query("from"))
+		if err != nil{
+			h.handlerResponse(c, "Get Courier Track", http.StatusBadRequest, "from must be an RFC3339 timestamp")
+			return
+		}
+		from = parsed
+	}
+
+	if from.After(to){
+		h.handlerResponse(c, "Get Courier Track", http.StatusBadRequest, "from must not be after to")
+		return
+	}
+
+	limit := 0
+	if len(c.Query("limit")) > 0{
+		var err error
+		limit, err = strconv.Atoi(c.Query("limit"))
+		if err != nil || limit <= 0{
+			h.handlerResponse(c, "Get Courier Track", http.StatusBadRequest, "Invalid Limit")
+			return
+		}
+	}
+
+	resp, err := h.storages.Courier().GetCourierTrack(context.Background(), &models.GetCourierTrackRequest{
+		Courier_id: id,
+		From: from.Format(time.RFC3339Nano),
+		To: to.Format(time.RFC3339Nano),
+		Limit: limit,
+	})
+	if err != nil{
+		h.handlerResponse(c, "Storage Get Courier Track", http.StatusInternalServerError, err)
+		return
+	}
+
+	h.handlerResponse(c, "Get Courier Track", http.StatusOK, resp)
+}
+
+
+// validateCourierLocation checks that a courier location is either omitted or
+// given in full with coordinates in range.
+func validateCourierLocation(latitude, longitude *float64) error {
+
+	if latitude == nil && longitude == nil {
+		return nil
+	}
+
+	if latitude == nil || longitude == nil {
+		return errors.New("latitude and longitude must be given together")
+	}
+
+	if !helper.IsValidCoordinate(*latitude, *longitude) {
+		return errors.New("invalid coordinates")
+	}
+
+	return nil
+}
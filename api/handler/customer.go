@@ -17,6 +17,8 @@ import (
 // @Tags Customer
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param customer body models.CreateCustomer true "CreateCustomerRequest"
 // @Success 200 {object} Response{data=string} "Success Request"
 // @Response 400 {object} Response{data=string} "Bad Request"
@@ -33,14 +35,14 @@ func (h *Handler) CreateCustomer(c *gin.Context) {
 
 	id, err := h.storages.Customer().CreateCustomer(context.Background(), &createCustomer)
 	if err != nil{
-		h.handlerResponse(c, "Storage Crate Customer", 500, err.Error())
+		h.handlerResponse(c, "Storage Crate Customer", 500, err)
 		return
 	}
 
 	
 	resp, err := h.storages.Customer().GetByIdCustomer(context.Background(), &models.CustomerPrimaryKey{Id: id})
 	if err != nil{
-		h.handlerResponse(c, "Storage Create Customer GET_BY_ID", 500, err.Error())
+		h.handlerResponse(c, "Storage Create Customer GET_BY_ID", 500, err)
 		return
 	}
 
@@ -56,9 +58,13 @@ func (h *Handler) CreateCustomer(c *gin.Context) {
 // @Tags Customer
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param id path string true "id"
+// @Param include_deleted query boolean false "include deleted records, requires customer.write"
 // @Success 200 {object} Response{data=string} "Success Request"
 // @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 403 {object} Response{data=string} "Forbidden"
 // @Failure 500 {object} Response{data=string} "Server Error"
 func (h *Handler) GetByIdCustomer(c *gin.Context) {
 
@@ -69,9 +75,15 @@ func (h *Handler) GetByIdCustomer(c *gin.Context) {
 		return
 	}
 
-	resp, err := h.storages.Customer().GetByIdCustomer(context.Background(), &models.CustomerPrimaryKey{Id: id})
+	includeDeleted, code, err := getIncludeDeletedQuery(c, "customer")
+	if err != nil{
+		h.handlerResponse(c, "Customer Get By Id", code, err.Error())
+		return
+	}
+
+	resp, err := h.storages.Customer().GetByIdCustomer(context.Background(), &models.CustomerPrimaryKey{Id: id, Include_deleted: includeDeleted})
 	if err != nil{
-		h.handlerResponse(c, "Storage Customer Get By Id", 500, err.Error())
+		h.handlerResponse(c, "Storage Customer Get By Id", 500, err)
 		return
 	}
 
@@ -87,33 +99,60 @@ func (h *Handler) GetByIdCustomer(c *gin.Context) {
 // @Tags Customer
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param offset query string false "offset"
 // @Param limit query string false "limit"
-// @Param search query string false "search"
+// @Param search query string false "search by name or phone"
+// @Param sort query string false "comma separated fields, prefixed with - for descending: name, phone, created_at, updated_at"
+// @Param cursor query string false "keyset paging from the newest record: pass an empty cursor to start, then next_cursor"
+// @Param include_deleted query boolean false "include deleted records, requires customer.write"
 // @Success 200 {object} Response{data=string} "Success Request"
 // @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 403 {object} Response{data=string} "Forbidden"
 // @Failure 500 {object} Response{data=string} "Server Error"
 func (h *Handler) GetListCustomer(c *gin.Context) {
 
-	offset, err := h.getOffsetQuery(c.Param("offset"))
+	offset, err := h.getOffsetQuery(c.Query("offset"))
 	if err != nil{
 		h.handlerResponse(c, "Get List Customer", http.StatusBadRequest, err.Error())
 		return
 	}
 
-	limit, err := h.getLimitQuery(c.Param("limit"))
+	limit, err := h.getLimitQuery(c.Query("limit"))
 	if err != nil{
 		h.handlerResponse(c, "Get List Customer", http.StatusBadRequest, err.Error())
 		return
 	}
 
+	sort, err := models.ParseSort(c.Query("sort"), models.CustomerSortFields)
+	if err != nil{
+		h.handlerResponse(c, "Get List Customer", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	cursor, err := getCursorQuery(c, offset, limit)
+	if err != nil{
+		h.handlerResponse(c, "Get List Customer", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	includeDeleted, code, err := getIncludeDeletedQuery(c, "customer")
+	if err != nil{
+		h.handlerResponse(c, "Get List Customer", code, err.Error())
+		return
+	}
+
 	resp, err := h.storages.Customer().GetListCustomer(context.Background(), &models.GetListCustomerRequest{
 		Offset: offset,
 		Limit: limit,
-		Search: c.Param("search"),
+		Search: c.Query("search"),
+		Sort: sort,
+		Cursor: cursor,
+		Include_deleted: includeDeleted,
 	})
 	if err != nil{
-		h.handlerResponse(c, "Storage GEt List Customer", 500, err.Error())
+		h.handlerResponse(c, "Storage GEt List Customer", 500, err)
 		return
 	}
 
@@ -129,6 +168,8 @@ func (h *Handler) GetListCustomer(c *gin.Context) {
 // @Tags Customer
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param id path string true "id"
 // @Param customer body models.UpdateCustomer true "UpdateCustomerRequest"
 // @Success 200 {object} Response{data=string} "Success Request"
@@ -154,7 +195,7 @@ func (h *Handler) UpdateCustomer(c *gin.Context) {
 
 	rowsAffected, err := h.storages.Customer().UpdateCustomer(context.Background(), &updatecustomer)
 	if err != nil{
-		h.handlerResponse(c, "Storage Update Customer", http.StatusInternalServerError, err.Error())
+		h.handlerResponse(c, "Storage Update Customer", http.StatusInternalServerError, err)
 		return
 	}
 
@@ -165,7 +206,7 @@ func (h *Handler) UpdateCustomer(c *gin.Context) {
 
 	resp, err := h.storages.Customer().GetByIdCustomer(context.Background(), &models.CustomerPrimaryKey{Id: id})
 	if err != nil{
-		h.handlerResponse(c, "Storage Get By Id Update Customer", 500, err.Error())
+		h.handlerResponse(c, "Storage Get By Id Update Customer", 500, err)
 		return
 	}
 
@@ -181,9 +222,12 @@ func (h *Handler) UpdateCustomer(c *gin.Context) {
 // @Tags Customer
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param id path string true "id"
 // @Success 200 {object} Response{data=string} "Success Request"
 // @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
 // @Failure 500 {object} Response{data=string} "Server Error
 func (h *Handler) DeleteCustomer(c *gin.Context) {
 
@@ -195,9 +239,47 @@ func (h *Handler) DeleteCustomer(c *gin.Context) {
 
 	err := h.storages.Customer().DeleteCustomer(context.Background(), &models.CustomerPrimaryKey{Id: id})
 	if err != nil{
-		h.handlerResponse(c, "Storage Delete Customer", http.StatusServiceUnavailable, err.Error())
+		h.handlerResponse(c, "Storage Delete Customer", 500, err)
 		return
 	}
 
 	h.handlerResponse(c, "Delete Customer", http.StatusOK, nil)
-}
\ No newline at end of file
+}
+
+// Restore Customer godoc
+// @ID restore_customer
+// @Router /customer/{id}/restore [POST]
+// @Summary Restore Customer
+// @Description Restore Deleted Customer
+// @Tags Customer
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path string true "id"
+// @Success 200 {object} Response{data=models.Customer} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) RestoreCustomer(c *gin.Context) {
+
+	id := c.Param("id")
+	if !helper.IsValidUUID(id){
+		h.handlerResponse(c, "Restore Customer", http.StatusBadRequest, "Invalid UUID")
+		return
+	}
+
+	err := h.storages.Customer().RestoreCustomer(context.Background(), &models.CustomerPrimaryKey{Id: id})
+	if err != nil{
+		h.handlerResponse(c, "Storage Restore Customer", 500, err)
+		return
+	}
+
+	resp, err := h.storages.Customer().GetByIdCustomer(context.Background(), &models.CustomerPrimaryKey{Id: id})
+	if err != nil{
+		h.handlerResponse(c, "Restore Customer Storage Get By Id", 500, err)
+		return
+	}
+
+	h.handlerResponse(c, "Restore Customer", http.StatusOK, resp)
+}
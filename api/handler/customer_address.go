@@ -0,0 +1,237 @@
+package handler
+
+import (
+	"app/api/models"
+	"app/pkg/helper"
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Create Customer Address godoc
+// @ID create_customer_address
+// @Router /customer/{id}/addresses [POST]
+// @Summary Create Customer Address
+// @Description Add an address to the customer's address book. The first address becomes the default
+// @Tags Customer Address
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path string true "id"
+// @Param address body models.CreateCustomerAddress true "CreateCustomerAddressRequest"
+// @Success 201 {object} Response{data=models.CustomerAddress} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) CreateCustomerAddress(c *gin.Context) {
+
+	customerId := c.Param("id")
+	if !helper.IsValidUUID(customerId) {
+		h.handlerResponse(c, "Create Customer Address", http.StatusBadRequest, "Invalid UUID")
+		return
+	}
+
+	var createAddress models.CreateCustomerAddress
+
+	err := c.ShouldBindJSON(&createAddress)
+	if err != nil {
+		h.handlerResponse(c, "Create Customer Address", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = validateCustomerAddress(createAddress.Street, createAddress.Latitude, createAddress.Longitude)
+	if err != nil {
+		h.handlerResponse(c, "Create Customer Address", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	createAddress.Customer_id = customerId
+
+	id, err := h.storages.CustomerAddress().CreateCustomerAddress(context.Background(), &createAddress)
+	if err != nil {
+		h.handlerResponse(c, "Storage Create Customer Address", http.StatusInternalServerError, err)
+		return
+	}
+
+	resp, err := h.storages.CustomerAddress().GetByIdCustomerAddress(context.Background(), &models.CustomerAddressPrimaryKey{Id: id, Customer_id: customerId})
+	if err != nil {
+		h.handlerResponse(c, "Storage Create Customer Address Get By ID", http.StatusInternalServerError, err)
+		return
+	}
+
+	h.handlerResponse(c, "Create Customer Address", http.StatusCreated, resp)
+}
+
+// Get By ID Customer Address godoc
+// @ID get_by_id_customer_address
+// @Router /customer/{id}/addresses/{address_id} [GET]
+// @Summary Get By ID Customer Address
+// @Description Get By ID Customer Address
+// @Tags Customer Address
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path string true "id"
+// @Param address_id path string true "address_id"
+// @Success 200 {object} Response{data=models.CustomerAddress} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) GetByIdCustomerAddress(c *gin.Context) {
+
+	customerId := c.Param("id")
+	addressId := c.Param("address_id")
+	if !helper.IsValidUUID(customerId) || !helper.IsValidUUID(addressId) {
+		h.handlerResponse(c, "Get Customer Address By Id", http.StatusBadRequest, "Invalid UUID")
+		return
+	}
+
+	resp, err := h.storages.CustomerAddress().GetByIdCustomerAddress(context.Background(), &models.CustomerAddressPrimaryKey{Id: addressId, Customer_id: customerId})
+	if err != nil {
+		h.handlerResponse(c, "Storage Get Customer Address By Id", http.StatusInternalServerError, err)
+		return
+	}
+
+	h.handlerResponse(c, "Get Customer Address By Id", http.StatusOK, resp)
+}
+
+// Get List Customer Address godoc
+// @ID get_list_customer_address
+// @Router /customer/{id}/addresses [GET]
+// @Summary Get List Customer Address
+// @Description Get every address of a customer, default first
+// @Tags Customer Address
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path string true "id"
+// @Success 200 {object} Response{data=models.GetListCustomerAddressResponse} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) GetListCustomerAddress(c *gin.Context) {
+
+	customerId := c.Param("id")
+	if !helper.IsValidUUID(customerId) {
+		h.handlerResponse(c, "Get List Customer Address", http.StatusBadRequest, "Invalid UUID")
+		return
+	}
+
+	resp, err := h.storages.CustomerAddress().GetListCustomerAddress(context.Background(), &models.GetListCustomerAddressRequest{Customer_id: customerId})
+	if err != nil {
+		h.handlerResponse(c, "Storage Get List Customer Address", http.StatusInternalServerError, err)
+		return
+	}
+
+	h.handlerResponse(c, "Get List Customer Address", http.StatusOK, resp)
+}
+
+// Update Customer Address godoc
+// @ID update_customer_address
+// @Router /customer/{id}/addresses/{address_id} [PUT]
+// @Summary Update Customer Address
+// @Description Update an address. Past orders keep the address they were placed with
+// @Tags Customer Address
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path string true "id"
+// @Param address_id path string true "address_id"
+// @Param address body models.UpdateCustomerAddress true "UpdateCustomerAddressRequest"
+// @Success 202 {object} Response{data=models.CustomerAddress} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) UpdateCustomerAddress(c *gin.Context) {
+
+	customerId := c.Param("id")
+	addressId := c.Param("address_id")
+	if !helper.IsValidUUID(customerId) || !helper.IsValidUUID(addressId) {
+		h.handlerResponse(c, "Update Customer Address", http.StatusBadRequest, "Invalid UUID")
+		return
+	}
+
+	var updateAddress models.UpdateCustomerAddress
+
+	err := c.ShouldBindJSON(&updateAddress)
+	if err != nil {
+		h.handlerResponse(c, "Update Customer Address", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = validateCustomerAddress(updateAddress.Street, updateAddress.Latitude, updateAddress.Longitude)
+	if err != nil {
+		h.handlerResponse(c, "Update Customer Address", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	updateAddress.Id = addressId
+	updateAddress.Customer_id = customerId
+
+	rowsAffected, err := h.storages.CustomerAddress().UpdateCustomerAddress(context.Background(), &updateAddress)
+	if err != nil {
+		h.handlerResponse(c, "Storage Update Customer Address", http.StatusInternalServerError, err)
+		return
+	}
+
+	if rowsAffected <= 0 {
+		h.handlerResponse(c, "Update Customer Address", http.StatusBadRequest, "No Rows Affected")
+		return
+	}
+
+	resp, err := h.storages.CustomerAddress().GetByIdCustomerAddress(context.Background(), &models.CustomerAddressPrimaryKey{Id: addressId, Customer_id: customerId})
+	if err != nil {
+		h.handlerResponse(c, "Storage Update Customer Address Get By ID", http.StatusInternalServerError, err)
+		return
+	}
+
+	h.handlerResponse(c, "Update Customer Address", http.StatusAccepted, resp)
+}
+
+// Delete Customer Address godoc
+// @ID delete_customer_address
+// @Router /customer/{id}/addresses/{address_id} [DELETE]
+// @Summary Delete Customer Address
+// @Description Delete an address. If it was the default the oldest remaining address becomes the default
+// @Tags Customer Address
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path string true "id"
+// @Param address_id path string true "address_id"
+// @Success 200 {object} Response{data=string} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) DeleteCustomerAddress(c *gin.Context) {
+
+	customerId := c.Param("id")
+	addressId := c.Param("address_id")
+	if !helper.IsValidUUID(customerId) || !helper.IsValidUUID(addressId) {
+		h.handlerResponse(c, "Delete Customer Address", http.StatusBadRequest, "Invalid UUID")
+		return
+	}
+
+	err := h.storages.CustomerAddress().DeleteCustomerAddress(context.Background(), &models.CustomerAddressPrimaryKey{Id: addressId, Customer_id: customerId})
+	if err != nil {
+		h.handlerResponse(c, "Storage Delete Customer Address", http.StatusInternalServerError, err)
+		return
+	}
+
+	h.handlerResponse(c, "Delete Customer Address", http.StatusOK, nil)
+}
+
+func validateCustomerAddress(street string, latitude, longitude float64) error {
+
+	if len(street) <= 0 {
+		return errors.New("street is required")
+	}
+
+	if !helper.IsValidCoordinate(latitude, longitude) {
+		return errors.New("invalid coordinates")
+	}
+
+	return nil
+}
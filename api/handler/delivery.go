@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"app/pkg/delivery"
+	"app/pkg/geo"
+	"app/pkg/helper"
+	"app/storage"
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Delivery Quote godoc
+// @ID delivery_quote
+// @Router /delivery/quote [GET]
+// @Summary Delivery Quote
+// @Description Quote the delivery fee from the store to the given coordinates. When delivery zones exist the coordinates must fall inside an open one
+// @Tags Delivery
+// @Accept json
+// @Produce json
+// @Param lat query number true "lat"
+// @Param lng query number true "lng"
+// @Success 200 {object} Response{data=models.DeliveryQuote} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) DeliveryQuote(c *gin.Context) {
+
+	latitude, err := strconv.ParseFloat(c.Query("lat"), 64)
+	if err != nil {
+		h.handlerResponse(c, "Delivery Quote", http.StatusBadRequest, "invalid lat")
+		return
+	}
+
+	longitude, err := strconv.ParseFloat(c.Query("lng"), 64)
+	if err != nil {
+		h.handlerResponse(c, "Delivery Quote", http.StatusBadRequest, "invalid lng")
+		return
+	}
+
+	if !helper.IsValidCoordinate(latitude, longitude) {
+		h.handlerResponse(c, "Delivery Quote", http.StatusBadRequest, "Invalid Coordinates")
+		return
+	}
+
+	resp, err := h.storages.DeliveryZone().QuoteDelivery(context.Background(), geo.Point{Latitude: latitude, Longitude: longitude})
+	if err != nil {
+		if code, message, ok := deliveryError(err); ok {
+			h.handlerResponse(c, "Delivery Quote", code, message)
+			return
+		}
+
+		h.handlerResponse(c, "Storage Delivery Quote", http.StatusInternalServerError, err)
+		return
+	}
+
+	h.handlerResponse(c, "Delivery Quote", http.StatusOK, resp)
+}
+
+// deliveryError maps the errors that reject a delivery address to a response
+// status and body.
+func deliveryError(err error) (int, interface{}, bool) {
+
+	var (
+		outsideErr  *storage.OutsideDeliveryZoneError
+		closedErr   *storage.DeliveryZoneClosedError
+		minOrderErr *storage.BelowMinimumOrderError
+	)
+
+	switch {
+	case errors.Is(err, delivery.ErrOutOfRange):
+		return http.StatusBadRequest, err.Error(), true
+	case errors.As(err, &outsideErr):
+		return http.StatusBadRequest, outsideErr, true
+	case errors.As(err, &closedErr):
+		return http.StatusConflict, closedErr, true
+	case errors.As(err, &minOrderErr):
+		return http.StatusBadRequest, minOrderErr, true
+	}
+
+	return 0, nil, false
+}
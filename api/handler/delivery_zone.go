@@ -0,0 +1,288 @@
+package handler
+
+import (
+	"app/api/models"
+	"app/pkg/geo"
+	"app/pkg/helper"
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Create Delivery Zone godoc
+// @ID create_delivery_zone
+// @Router /delivery-zone [POST]
+// @Summary Create Delivery Zone
+// @Description Create a delivery zone from a GeoJSON polygon
+// @Tags Delivery Zone
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param zone body models.CreateDeliveryZone true "CreateDeliveryZoneRequest"
+// @Success 201 {object} Response{data=models.DeliveryZone} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) CreateDeliveryZone(c *gin.Context) {
+
+	var createZone models.CreateDeliveryZone
+
+	err := c.ShouldBindJSON(&createZone)
+	if err != nil {
+		h.handlerResponse(c, "Create Delivery Zone", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = validateDeliveryZone(createZone.Name, &createZone.Area, createZone.Delivery_fee, createZone.Min_order, createZone.Opens_at, createZone.Closes_at)
+	if err != nil {
+		h.handlerResponse(c, "Create Delivery Zone", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	id, err := h.storages.DeliveryZone().CreateDeliveryZone(context.Background(), &createZone)
+	if err != nil {
+		h.handlerResponse(c, "Storage Create Delivery Zone", http.StatusInternalServerError, err)
+		return
+	}
+
+	resp, err := h.storages.DeliveryZone().GetByIdDeliveryZone(context.Background(), &models.DeliveryZonePrimaryKey{Id: id})
+	if err != nil {
+		h.handlerResponse(c, "Storage Create Delivery Zone Get By ID", http.StatusInternalServerError, err)
+		return
+	}
+
+	h.handlerResponse(c, "Create Delivery Zone", http.StatusCreated, resp)
+}
+
+// Get By ID Delivery Zone godoc
+// @ID get_by_id_delivery_zone
+// @Router /delivery-zone/{id} [GET]
+// @Summary Get By ID Delivery Zone
+// @Description Get By ID Delivery Zone
+// @Tags Delivery Zone
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path string true "id"
+// @Success 200 {object} Response{data=models.DeliveryZone} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) GetByIdDeliveryZone(c *gin.Context) {
+
+	id := c.Param("id")
+	if !helper.IsValidUUID(id) {
+		h.handlerResponse(c, "Get Delivery Zone By Id", http.StatusBadRequest, "Invalid UUID")
+		return
+	}
+
+	resp, err := h.storages.DeliveryZone().GetByIdDeliveryZone(context.Background(), &models.DeliveryZonePrimaryKey{Id: id})
+	if err != nil {
+		h.handlerResponse(c, "Storage Get Delivery Zone By Id", http.StatusInternalServerError, err)
+		return
+	}
+
+	h.handlerResponse(c, "Get Delivery Zone By Id", http.StatusOK, resp)
+}
+
+// Get List Delivery Zone godoc
+// @ID get_list_delivery_zone
+// @Router /delivery-zone [GET]
+// @Summary Get List Delivery Zone
+// @Description Get List Delivery Zone
+// @Tags Delivery Zone
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param offset query string false "offset"
+// @Param limit query string false "limit"
+// @Param search query string false "search by name"
+// @Param sort query string false "comma separated fields, prefixed with - for descending: name, delivery_fee, min_order, created_at, updated_at"
+// @Param cursor query string false "keyset paging from the newest record: pass an empty cursor to start, then next_cursor"
+// @Param is_active query boolean false "is_active"
+// @Success 200 {object} Response{data=models.GetListDeliveryZoneResponse} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) GetListDeliveryZone(c *gin.Context) {
+
+	offset, err := h.getOffsetQuery(c.Query("offset"))
+	if err != nil {
+		h.handlerResponse(c, "Get List Delivery Zone", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	limit, err := h.getLimitQuery(c.Query("limit"))
+	if err != nil {
+		h.handlerResponse(c, "Get List Delivery Zone", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	sort, err := models.ParseSort(c.Query("sort"), models.DeliveryZoneSortFields)
+	if err != nil {
+		h.handlerResponse(c, "Get List Delivery Zone", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	cursor, err := getCursorQuery(c, offset, limit)
+	if err != nil {
+		h.handlerResponse(c, "Get List Delivery Zone", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	isActive, err := getBoolQuery(c, "is_active")
+	if err != nil {
+		h.handlerResponse(c, "Get List Delivery Zone", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	resp, err := h.storages.DeliveryZone().GetListDeliveryZone(context.Background(), &models.GetListDeliveryZoneRequest{
+		Offset:    offset,
+		Limit:     limit,
+		Search:    c.Query("search"),
+		Sort:      sort,
+		Cursor:    cursor,
+		Is_active: isActive,
+	})
+	if err != nil {
+		h.handlerResponse(c, "Storage Get List Delivery Zone", http.StatusInternalServerError, err)
+		return
+	}
+
+	h.handlerResponse(c, "Get List Delivery Zone", http.StatusOK, resp)
+}
+
+// Update Delivery Zone godoc
+// @ID update_delivery_zone
+// @Router /delivery-zone/{id} [PUT]
+// @Summary Update Delivery Zone
+// @Description Update Delivery Zone
+// @Tags Delivery Zone
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path string true "id"
+// @Param zone body models.UpdateDeliveryZone true "UpdateDeliveryZoneRequest"
+// @Success 202 {object} Response{data=models.DeliveryZone} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) UpdateDeliveryZone(c *gin.Context) {
+
+	id := c.Param("id")
+	if !helper.IsValidUUID(id) {
+		h.handlerResponse(c, "Update Delivery Zone", http.StatusBadRequest, "Invalid UUID")
+		return
+	}
+
+	var updateZone models.UpdateDeliveryZone
+
+	err := c.ShouldBindJSON(&updateZone)
+	if err != nil {
+		h.handlerResponse(c, "Update Delivery Zone", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = validateDeliveryZone(updateZone.Name, &updateZone.Area, updateZone.Delivery_fee, updateZone.Min_order, updateZone.Opens_at, updateZone.Closes_at)
+	if err != nil {
+		h.handlerResponse(c, "Update Delivery Zone", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	updateZone.Id = id
+
+	rowsAffected, err := h.storages.DeliveryZone().UpdateDeliveryZone(context.Background(), &updateZone)
+	if err != nil {
+		h.handlerResponse(c, "Storage Update Delivery Zone", http.StatusInternalServerError, err)
+		return
+	}
+
+	if rowsAffected <= 0 {
+		h.handlerResponse(c, "Update Delivery Zone", http.StatusBadRequest, "No Rows Affected")
+		return
+	}
+
+	resp, err := h.storages.DeliveryZone().GetByIdDeliveryZone(context.Background(), &models.DeliveryZonePrimaryKey{Id: id})
+	if err != nil {
+		h.handlerResponse(c, "Storage Update Delivery Zone Get By ID", http.StatusInternalServerError, err)
+		return
+	}
+
+	h.handlerResponse(c, "Update Delivery Zone", http.StatusAccepted, resp)
+}
+
+// Delete Delivery Zone godoc
+// @ID delete_delivery_zone
+// @Router /delivery-zone/{id} [DELETE]
+// @Summary Delete Delivery Zone
+// @Description Delete a delivery zone. Orders placed in it keep their fee but lose the zone reference
+// @Tags Delivery Zone
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path string true "id"
+// @Success 200 {object} Response{data=string} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) DeleteDeliveryZone(c *gin.Context) {
+
+	id := c.Param("id")
+	if !helper.IsValidUUID(id) {
+		h.handlerResponse(c, "Delete Delivery Zone", http.StatusBadRequest, "Invalid UUID")
+		return
+	}
+
+	err := h.storages.DeliveryZone().DeleteDeliveryZone(context.Background(), &models.DeliveryZonePrimaryKey{Id: id})
+	if err != nil {
+		h.handlerResponse(c, "Storage Delete Delivery Zone", http.StatusInternalServerError, err)
+		return
+	}
+
+	h.handlerResponse(c, "Delete Delivery Zone", http.StatusOK, nil)
+}
+
+// validateDeliveryZone checks the fields shared by zone create and update
+// requests. Active hours are "HH:MM" and must be given together.
+func validateDeliveryZone(name string, area *models.GeoJSONPolygon, fee *float64, minOrder float64, opensAt, closesAt string) error {
+
+	if len(name) <= 0 {
+		return errors.New("name is required")
+	}
+
+	if area.Type != "Polygon" {
+		return errors.New("area must be a GeoJSON Polygon")
+	}
+
+	_, err := geo.PolygonFromGeoJSON(area.Coordinates)
+	if err != nil {
+		return err
+	}
+
+	if fee != nil && *fee < 0 {
+		return errors.New("delivery_fee must not be negative")
+	}
+
+	if minOrder < 0 {
+		return errors.New("min_order must not be negative")
+	}
+
+	if (len(opensAt) > 0) != (len(closesAt) > 0) {
+		return errors.New("opens_at and closes_at must be set together")
+	}
+
+	for _, clock := range []string{opensAt, closesAt} {
+		if len(clock) <= 0 {
+			continue
+		}
+
+		if _, err := time.Parse("15:04", clock); err != nil {
+			return errors.New("opens_at and closes_at must be formatted as HH:MM")
+		}
+	}
+
+	return nil
+}
@@ -1,10 +1,17 @@
 package handler
 
 import (
+	"app/api/models"
 	"app/config"
+	"app/pkg/helper"
 	"app/pkg/logger"
+	"app/pkg/sms"
 	"app/storage"
+	"errors"
+	"fmt"
+	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -13,22 +20,47 @@ type Handler struct {
 	cfg      *config.Config
 	logger   logger.LoggerI
 	storages storage.StorageI
+	sms      sms.Sender
 }
 
 type Response struct {
 	Status      int
 	Description string
-	Data        interface{}
+	// Code is a stable, machine-readable error code, set on errors raised by
+	// storage.
+	Code string `json:",omitempty"`
+	Data interface{}
 }
 
-func NewHandler(cfg *config.Config, store storage.StorageI, logger logger.LoggerI) *Handler {
+// Error codes of responses to storage failures.
+const (
+	ErrorCodeNotFound   = "not_found"
+	ErrorCodeConflict   = "conflict"
+	ErrorCodeForeignKey = "foreign_key_violation"
+	ErrorCodeValidation = "validation_failed"
+	ErrorCodeInternal   = "internal_error"
+)
+
+func NewHandler(cfg *config.Config, store storage.StorageI, log logger.LoggerI) *Handler {
+
+	sender, err := sms.New(cfg.SmsDriver, cfg.SmsFilePath, log)
+	if err != nil {
+		log.Warn("falling back to the log sms driver", logger.Error(err))
+		sender, _ = sms.New(sms.DriverLog, "", log)
+	}
+
 	return &Handler{
 		cfg:      cfg,
-		logger:   logger,
+		logger:   log,
 		storages: store,
+		sms:      sender,
 	}
 }
 
+// handlerResponse writes message as the response data. An error passed with a
+// 5xx code is a storage failure: storage.Translate classifies it into a 404,
+// 409 or 422 with an error code, and any other error is reported as a
+// generic internal error so driver messages never reach clients.
 func (h *Handler) handlerResponse(c *gin.Context, path string, code int, message interface{}) {
 
 	response := Response{
@@ -36,16 +68,62 @@ func (h *Handler) handlerResponse(c *gin.Context, path string, code int, message
 		Data:   message,
 	}
 
+	fields := []logger.Field{}
+
+	if err, ok := message.(error); ok && code >= http.StatusInternalServerError {
+		response = storageErrorResponse(err)
+		code = response.Status
+		fields = append(fields, logger.Error(err))
+	}
+
+	fields = append(fields, logger.Any("info", response))
+
+	if id := c.GetString(contextUserId); len(id) > 0 {
+		fields = append(fields, logger.String("user_id", id))
+	}
+
+	if id := c.GetString(contextApiKeyId); len(id) > 0 {
+		fields = append(fields, logger.String("api_key_id", id))
+	}
+
 	switch {
 	case code < 300:
-		h.logger.Info(path, logger.Any("info", response))
+		h.logger.Info(path, fields...)
 	case code >= 400:
-		h.logger.Error(path, logger.Any("info", response))
+		h.logger.Error(path, fields...)
 	}
 
 	c.JSON(code, response)
 }
 
+// storageErrorResponse maps a storage failure to its response.
+func storageErrorResponse(err error) Response {
+
+	var storageErr *storage.Error
+	if !errors.As(storage.Translate(err), &storageErr) {
+		return Response{
+			Status: http.StatusInternalServerError,
+			Code:   ErrorCodeInternal,
+			Data:   "internal server error",
+		}
+	}
+
+	response := Response{Data: storageErr.Message}
+
+	switch storageErr.Kind {
+	case storage.KindNotFound:
+		response.Status, response.Code = http.StatusNotFound, ErrorCodeNotFound
+	case storage.KindConflict:
+		response.Status, response.Code = http.StatusConflict, ErrorCodeConflict
+	case storage.KindForeignKey:
+		response.Status, response.Code = http.StatusUnprocessableEntity, ErrorCodeForeignKey
+	default:
+		response.Status, response.Code = http.StatusUnprocessableEntity, ErrorCodeValidation
+	}
+
+	return response
+}
+
 func (h *Handler) getOffsetQuery(offset string) (int, error) {
 
 	if len(offset) <= 0 {
@@ -63,3 +141,118 @@ func (h *Handler) getLimitQuery(limit string) (int, error) {
 
 	return strconv.Atoi(limit)
 }
+
+// getFloatQuery parses the numeric query parameter name. A missing parameter
+// is 0.
+func getFloatQuery(c *gin.Context, name string) (float64, error) {
+
+	value := c.Query(name)
+	if len(value) <= 0 {
+		return 0, nil
+	}
+
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil || parsed < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative number", name)
+	}
+
+	return parsed, nil
+}
+
+// getBoolQuery parses the boolean query parameter name. A missing parameter
+// is nil.
+func getBoolQuery(c *gin.Context, name string) (*bool, error) {
+
+	value := c.Query(name)
+	if len(value) <= 0 {
+		return nil, nil
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be true or false", name)
+	}
+
+	return &parsed, nil
+}
+
+// getIncludeDeletedQuery parses the include_deleted query parameter. Soft
+// deleted records of resource are only shown to principals allowed to write
+// all of its records; anyone else asking for them gets the status code to
+// fail with.
+func getIncludeDeletedQuery(c *gin.Context, resource string) (bool, int, error) {
+
+	include, err := getBoolQuery(c, "include_deleted")
+	if err != nil {
+		return false, http.StatusBadRequest, err
+	}
+
+	if include == nil || !*include {
+		return false, 0, nil
+	}
+
+	principal, _ := c.Get(contextPrincipal)
+	if principal == nil || principal.(*models.Principal).Permissions[resource+".write"] != models.PermissionScopeAll {
+		return false, http.StatusForbidden, errors.New("include_deleted requires permission " + resource + ".write")
+	}
+
+	return true, 0, nil
+}
+
+// getDateQuery validates the query parameter name as a YYYY-MM-DD date.
+func getDateQuery(c *gin.Context, name string) (string, error) {
+
+	value := c.Query(name)
+	if len(value) <= 0 {
+		return "", nil
+	}
+
+	_, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return "", fmt.Errorf("%s must be a date formatted as YYYY-MM-DD", name)
+	}
+
+	return value, nil
+}
+
+// getUUIDQuery validates the query parameter name as a UUID.
+func getUUIDQuery(c *gin.Context, name string) (string, error) {
+
+	value := c.Query(name)
+	if len(value) > 0 && !helper.IsValidUUID(value) {
+		return "", fmt.Errorf("%s must be a UUID", name)
+	}
+
+	return value, nil
+}
+
+// getCursorQuery parses the cursor query parameter. A missing parameter is
+// nil and keeps offset paging; an empty one starts keyset paging from the
+// newest record. Keyset pages have their own order, so a cursor cannot be
+// combined with sort or offset.
+func getCursorQuery(c *gin.Context, offset, limit int) (*models.Cursor, error) {
+
+	value, ok := c.GetQuery("cursor")
+	if !ok {
+		return nil, nil
+	}
+
+	cursor, err := models.ParseCursor(value)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(c.Query("sort")) > 0 {
+		return nil, errors.New("cursor cannot be combined with sort")
+	}
+
+	if offset > 0 {
+		return nil, errors.New("cursor cannot be combined with offset")
+	}
+
+	if limit <= 0 {
+		return nil, errors.New("limit must be positive with a cursor")
+	}
+
+	return cursor, nil
+}
@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"app/api/models"
+	"app/pkg/logger"
+	"app/storage"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// idempotencyKeyHeader is the header clients send to make a POST safe to
+	// retry.
+	idempotencyKeyHeader = "Idempotency-Key"
+	// idempotencyReplayedHeader marks responses replayed from a previous
+	// request.
+	idempotencyReplayedHeader = "Idempotent-Replayed"
+	// maxIdempotencyKeyLength bounds the keys clients may send.
+	maxIdempotencyKeyLength = 255
+)
+
+// IdempotencyMiddleware makes POST requests sent with an Idempotency-Key
+// header safe to retry. The first response of a key is stored per user or
+// API key and replayed for retries until it expires; reusing a key with a
+// different request is rejected. Only successful responses and rejections of
+// invalid requests are stored: after any other response, or a panic, the key
+// is released so the request may be retried. It must run after
+// AuthMiddleware.
+func (h *Handler) IdempotencyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		key := c.GetHeader(idempotencyKeyHeader)
+		if c.Request.Method != http.MethodPost || len(key) <= 0 {
+			c.Next()
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			h.handlerResponse(c, "Idempotency Middleware", http.StatusBadRequest, "Idempotency-Key must be at most 255 characters long")
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			h.handlerResponse(c, "Idempotency Middleware", http.StatusBadRequest, err.Error())
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.New()
+		hash.Write([]byte(c.Request.Method + " " + c.Request.URL.RequestURI() + "\n"))
+		hash.Write(body)
+
+		request := &models.IdempotencyKey{
+			Owner:        idempotencyOwner(c),
+			Key:          key,
+			Method:       c.Request.Method,
+			Path:         c.Request.URL.RequestURI(),
+			Request_hash: hex.EncodeToString(hash.Sum(nil)),
+		}
+
+		stored, err := h.storages.Idempotency().BeginIdempotentRequest(context.Background(), request)
+		if err != nil {
+			switch {
+			case errors.Is(err, storage.ErrIdempotencyKeyReused):
+				h.handlerResponse(c, "Idempotency Middleware", http.StatusUnprocessableEntity, err.Error())
+			case errors.Is(err, storage.ErrIdempotencyKeyInProgress):
+				h.handlerResponse(c, "Idempotency Middleware", http.StatusConflict, err.Error())
+			default:
+				h.handlerResponse(c, "Storage Idempotency Middleware", http.StatusInternalServerError, err)
+			}
+			c.Abort()
+			return
+		}
+
+		if stored != nil {
+			h.logger.Info("Idempotency Middleware Replay", logger.String("key", key), logger.Int("status", stored.Status_code))
+			c.Header(idempotencyReplayedHeader, "true")
+			c.Data(stored.Status_code, "application/json; charset=utf-8", stored.Body)
+			c.Abort()
+			return
+		}
+
+		writer := &bufferedWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		defer func() {
+			if p := recover(); p != nil {
+				err := h.storages.Idempotency().ReleaseIdempotentRequest(context.Background(), request)
+				if err != nil {
+					h.logger.Error("Storage Idempotency Middleware", logger.String("key", key), logger.Error(err))
+				}
+				panic(p)
+			}
+		}()
+
+		c.Next()
+
+		if !replayableStatus(c.Writer.Status()) {
+			err = h.storages.Idempotency().ReleaseIdempotentRequest(context.Background(), request)
+		} else {
+			err = h.storages.Idempotency().FinishIdempotentRequest(context.Background(), request, &models.IdempotentResponse{
+				Status_code: c.Writer.Status(),
+				Body:        writer.body.Bytes(),
+			})
+		}
+		if err != nil {
+			h.logger.Error("Storage Idempotency Middleware", logger.String("key", key), logger.Error(err))
+		}
+	}
+}
+
+// replayableStatus reports whether a response with status is stored for
+// replay. Responses that depend on more than the request, such as failed
+// authorization, conflicts or server errors, may change when it is retried.
+func replayableStatus(status int) bool {
+
+	switch {
+	case status >= http.StatusOK && status < http.StatusMultipleChoices:
+		return true
+	case status == http.StatusBadRequest || status == http.StatusUnprocessableEntity:
+		return true
+	}
+
+	return false
+}
+
+// idempotencyOwner returns who a request's idempotency key belongs to, so
+// clients cannot collide with or replay each other's keys.
+func idempotencyOwner(c *gin.Context) string {
+
+	if id := c.GetString(contextApiKeyId); len(id) > 0 {
+		return "api_key:" + id
+	}
+
+	return "user:" + c.GetString(contextUserId)
+}
+
+// bufferedWriter copies the response body while writing it through.
+type bufferedWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
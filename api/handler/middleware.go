@@ -0,0 +1,252 @@
+package handler
+
+import (
+	"app/api/models"
+	"app/pkg/helper"
+	"app/pkg/token"
+	"app/storage"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// contextUserId is the gin context key holding the id of the authenticated user.
+	contextUserId = "user_id"
+	// contextApiKeyId is the gin context key holding the id of the API key a
+	// request was authenticated with.
+	contextApiKeyId = "api_key_id"
+	// apiKeyHeader is the header integrations send their API key in.
+	apiKeyHeader = "X-API-Key"
+)
+
+// AuthMiddleware rejects requests without a valid access token and stores the
+// id of the authenticated user in the context. The token is read from the
+// Authorization header as "Bearer <token>", or from the access_token query
+// parameter for clients such as EventSource that cannot set headers.
+// Integrations may instead send an API key in the X-API-Key header.
+func (h *Handler) AuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		if key := c.GetHeader(apiKeyHeader); len(key) > 0 {
+			h.authenticateApiKey(c, key)
+			return
+		}
+
+		raw := c.Query("access_token")
+
+		if header := c.GetHeader("Authorization"); len(header) > 0 {
+			scheme, value, ok := strings.Cut(header, " ")
+			if !ok || !strings.EqualFold(scheme, "Bearer") {
+				h.handlerResponse(c, "Auth Middleware", http.StatusUnauthorized, "authorization header must be a Bearer token")
+				c.Abort()
+				return
+			}
+			raw = strings.TrimSpace(value)
+		}
+
+		if len(raw) <= 0 {
+			h.handlerResponse(c, "Auth Middleware", http.StatusUnauthorized, "missing access token")
+			c.Abort()
+			return
+		}
+
+		claims, err := token.Parse([]byte(h.cfg.JWTSecret), raw)
+		if err == nil && claims.Type != token.TypeAccess {
+			err = errors.New("not an access token")
+		}
+		if err != nil {
+			h.handlerResponse(c, "Auth Middleware", http.StatusUnauthorized, err.Error())
+			c.Abort()
+			return
+		}
+
+		c.Set(contextUserId, claims.Subject)
+		c.Next()
+	}
+}
+
+// authenticateApiKey authenticates the request with an API key. The key acts
+// as a principal of its own, granted its scopes over all records.
+func (h *Handler) authenticateApiKey(c *gin.Context, key string) {
+
+	auth, err := h.storages.ApiKey().AuthenticateApiKey(context.Background(), helper.HashAPIKey(key))
+	if storage.IsKind(err, storage.KindNotFound) {
+		h.handlerResponse(c, "Auth Middleware", http.StatusUnauthorized, "invalid API key")
+		c.Abort()
+		return
+	}
+	if err != nil {
+		h.handlerResponse(c, "Storage Auth Middleware", http.StatusInternalServerError, err)
+		c.Abort()
+		return
+	}
+
+	if auth.Revoked {
+		h.handlerResponse(c, "Auth Middleware", http.StatusUnauthorized, "API key has been revoked")
+		c.Abort()
+		return
+	}
+
+	if auth.Expired {
+		h.handlerResponse(c, "Auth Middleware", http.StatusUnauthorized, "API key has expired")
+		c.Abort()
+		return
+	}
+
+	c.Set(contextApiKeyId, auth.Id)
+	c.Set(contextPrincipal, &models.Principal{
+		Api_key_id:  auth.Id,
+		Permissions: auth.Permissions,
+	})
+	c.Next()
+}
+
+const (
+	// contextPrincipal is the gin context key holding the *models.Principal
+	// of the authenticated user.
+	contextPrincipal = "principal"
+	// contextScope is the gin context key holding the scope the current
+	// route's permission was granted with.
+	contextScope = "permission_scope"
+)
+
+// Permission returns a middleware allowing the request only if the role of the
+// authenticated user grants permission. Permissions are named
+// "<resource>.<action>"; when granted with the "own" scope the record named
+// by the :id path parameter must belong to the user. Routes without :id get
+// the principal in the context and must restrict themselves via ownScope.
+// Requests authenticated with an API key are checked against the scopes of
+// the key. It must run after AuthMiddleware.
+func (h *Handler) Permission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		principal, err := h.principal(c)
+		if storage.IsKind(err, storage.KindNotFound) {
+			h.handlerResponse(c, "Permission Middleware", http.StatusUnauthorized, "user no longer exists")
+			c.Abort()
+			return
+		}
+		if err != nil {
+			h.handlerResponse(c, "Storage Permission Middleware", http.StatusInternalServerError, err)
+			c.Abort()
+			return
+		}
+
+		scope, ok := principal.Permissions[permission]
+		if !ok {
+			h.handlerResponse(c, "Permission Middleware", http.StatusForbidden, "missing permission: "+permission)
+			c.Abort()
+			return
+		}
+
+		if scope == models.PermissionScopeOwn {
+
+			resource, _, _ := strings.Cut(permission, ".")
+
+			owns, err := h.owns(c, principal, resource)
+			if err != nil {
+				h.handlerResponse(c, "Storage Permission Middleware", http.StatusInternalServerError, err)
+				c.Abort()
+				return
+			}
+
+			if !owns {
+				h.handlerResponse(c, "Permission Middleware", http.StatusForbidden, "permission "+permission+" only covers your own records")
+				c.Abort()
+				return
+			}
+		}
+
+		c.Set(contextPrincipal, principal)
+		c.Set(contextScope, scope)
+		c.Next()
+	}
+}
+
+// principal returns the principal already in the context, or loads the one of
+// the authenticated user.
+func (h *Handler) principal(c *gin.Context) (*models.Principal, error) {
+
+	if principal, ok := c.Get(contextPrincipal); ok {
+		return principal.(*models.Principal), nil
+	}
+
+	return h.storages.Role().GetPrincipal(context.Background(), &models.UserPrimaryKey{Id: c.GetString(contextUserId)})
+}
+
+// owns reports whether the record of resource named by the :id path parameter
+// belongs to principal. Only order and cart routes handle "own" scope without
+// :id, so for them it reports whether principal is linked to a courier or
+// customer; any other route without :id is denied.
+func (h *Handler) owns(c *gin.Context, principal *models.Principal, resource string) (bool, error) {
+
+	id := c.Param("id")
+
+	switch resource {
+	case "user", "balance":
+		return id != "" && id == principal.User_id, nil
+
+	case "customer", "address":
+		return id != "" && id == principal.Customer_id, nil
+
+	case "courier":
+		return id != "" && id == principal.Courier_id, nil
+
+	case "cart":
+		if principal.Customer_id == "" || id == "" {
+			return principal.Customer_id != "", nil
+		}
+
+		cart, err := h.storages.Cart().GetByIdCart(context.Background(), &models.CartPrimaryKey{Id: id})
+		if storage.IsKind(err, storage.KindNotFound) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+
+		return cart.Customer_id == principal.Customer_id, nil
+
+	case "order":
+		if principal.Courier_id == "" && principal.Customer_id == "" {
+			return false, nil
+		}
+
+		if id == "" {
+			return true, nil
+		}
+
+		order, err := h.storages.Order().GetByIdOrder(context.Background(), &models.OrderPrimaryKey{Id: id})
+		if storage.IsKind(err, storage.KindNotFound) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+
+		return (principal.Courier_id != "" && order.Courier_id == principal.Courier_id) ||
+			(principal.Customer_id != "" && order.Customer_id == principal.Customer_id), nil
+	}
+
+	return false, nil
+}
+
+// ownScope returns the principal of the request if the route's permission was
+// granted only for their own records, and nil if it covers all records.
+func ownScope(c *gin.Context) *models.Principal {
+
+	if c.GetString(contextScope) != models.PermissionScopeOwn {
+		return nil
+	}
+
+	principal, _ := c.Get(contextPrincipal)
+	if principal == nil {
+		return nil
+	}
+
+	return principal.(*models.Principal)
+}
@@ -3,7 +3,10 @@ package handler
 import (
 	"app/api/models"
 	"app/pkg/helper"
+	"app/storage"
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -17,9 +20,16 @@ import (
 // @Tags Order
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param order body models.CreateOrder true "CreateOrderRequest"
-// @Success 200 {object} Response{data=string} "Success Request"
+// @Param Idempotency-Key header string false "makes retries of the same order return the first response"
+// @Success 200 {object} Response{data=models.Order} "Success Request"
 // @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 409 {object} Response{data=storage.InsufficientStockError} "Insufficient Stock"
+// @Response 402 {object} Response{data=storage.InsufficientBalanceError} "Insufficient Balance"
+// @Response 409 {object} Response{data=string} "No Courier Available"
+// @Response 422 {object} Response{data=string} "Idempotency Key Reused"
 // @Failure 500 {object} Response{data=string} "Server Error"
 func (h *Handler) CreateOrder(c *gin.Context) {
 
@@ -28,17 +38,51 @@ func (h *Handler) CreateOrder(c *gin.Context) {
 	err := c.ShouldBindJSON(&createOrder)
 	if err != nil{
 		h.handlerResponse(c, "Create Order", 400, err.Error())
+		return
+	}
+
+	if principal := ownScope(c); principal != nil {
+		createOrder.Customer_id = principal.Customer_id
+		createOrder.User_id = principal.User_id
+	}
+
+	createOrder.Api_key_id = c.GetString(contextApiKeyId)
+
+	err = validateOrderItems(createOrder.Items, true)
+	if err != nil{
+		h.handlerResponse(c, "Create Order", 400, err.Error())
+		return
+	}
+
+	if len(createOrder.Address_id) > 0 {
+		if !helper.IsValidUUID(createOrder.Address_id){
+			h.handlerResponse(c, "Create Order", 400, "Invalid address_id UUID")
+			return
+		}
+	} else if !helper.IsValidCoordinate(createOrder.Latitude, createOrder.Longtitude){
+		h.handlerResponse(c, "Create Order", 400, "Invalid Coordinates")
+		return
+	}
+
+	if len(createOrder.Courier_id) > 0 && !helper.IsValidUUID(createOrder.Courier_id){
+		h.handlerResponse(c, "Create Order", 400, "Invalid courier_id UUID")
+		return
 	}
 
 	id, err := h.storages.Order().CreateOrder(context.Background(), &createOrder)
 	if err != nil{
-		h.handlerResponse(c, "Storage Create Order", 500, err.Error())
+		if code, message, ok := orderCreationError(err); ok{
+			h.handlerResponse(c, "Create Order", code, message)
+			return
+		}
+
+		h.handlerResponse(c, "Storage Create Order", 500, err)
 		return
 	}
 
 	resp, err := h.storages.Order().GetByIdOrder(context.Background(), &models.OrderPrimaryKey{Id: id})
 	if err != nil{
-		h.handlerResponse(c, "Storage Create Order Get By ID", 500, err.Error())
+		h.handlerResponse(c, "Storage Create Order Get By ID", 500, err)
 		return
 	}
 
@@ -54,8 +98,10 @@ func (h *Handler) CreateOrder(c *gin.Context) {
 // @Tags Order
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param id path string true "id"
-// @Success 200 {object} Response{data=string} "Success Request"
+// @Success 200 {object} Response{data=models.Order} "Success Request"
 // @Response 400 {object} Response{data=string} "Bad Request"
 // @Failure 500 {object} Response{data=string} "Server Error"
 func (h *Handler) GetByIdOrder(c *gin.Context) {
@@ -68,7 +114,8 @@ func (h *Handler) GetByIdOrder(c *gin.Context) {
 
 	resp, err := h.storages.Order().GetByIdOrder(context.Background(), &models.OrderPrimaryKey{Id: id})
 	if err != nil{
-		h.handlerResponse(c, "Storage Get By Id Order", 500, err.Error())
+		h.handlerResponse(c, "Storage Get By Id Order", 500, err)
+		return
 	}
 
 	h.handlerResponse(c, "Order Get By Id", http.StatusOK, resp)
@@ -83,34 +130,108 @@ func (h *Handler) GetByIdOrder(c *gin.Context) {
 // @Tags Order
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param offset query string false "offset"
 // @Param limit query string false "limit"
-// @Param search query string false "search"
-// @Success 200 {object} Response{data=string} "Success Request"
+// @Param search query string false "search by name or phone number"
+// @Param sort query string false "comma separated fields, prefixed with - for descending: name, price, delivery_fee, status, created_at, updated_at"
+// @Param cursor query string false "keyset paging from the newest record: pass an empty cursor to start, then next_cursor"
+// @Param customer_id query string false "customer_id"
+// @Param courier_id query string false "courier_id"
+// @Param status query string false "status" Enums(new, accepted, assigned, picked_up, delivered, cancelled, returned)
+// @Param created_from query string false "orders created on or after this day, YYYY-MM-DD"
+// @Param created_to query string false "orders created on or before this day, YYYY-MM-DD"
+// @Param min_price query number false "min_price"
+// @Param max_price query number false "max_price"
+// @Success 200 {object} Response{data=models.GetListOrderResponse} "Success Request"
 // @Response 400 {object} Response{data=string} "Bad Request"
 // @Failure 500 {object} Response{data=string} "Server Error"
 func (h *Handler) GetListOrders(c *gin.Context) {
 
-	offset, err := h.getOffsetQuery(c.Param("offset"))
+	offset, err := h.getOffsetQuery(c.Query("offset"))
 	if err != nil {
 		h.handlerResponse(c, "Get Lsit Orders", 400, err.Error())
 		return
 	}
 
-	limit, err := h.getLimitQuery(c.Param("limit"))
+	limit, err := h.getLimitQuery(c.Query("limit"))
 	if err != nil{
 		h.handlerResponse(c, "Get List Orders", 400, err.Error())
 		return
 	}
 
-	resp, err := h.storages.Order().GetListOrders(context.Background(), &models.GetListOrderRequest{
+	sort, err := models.ParseSort(c.Query("sort"), models.OrderSortFields)
+	if err != nil{
+		h.handlerResponse(c, "Get List Orders", 400, err.Error())
+		return
+	}
+
+	cursor, err := getCursorQuery(c, offset, limit)
+	if err != nil{
+		h.handlerResponse(c, "Get List Orders", 400, err.Error())
+		return
+	}
+
+	request := &models.GetListOrderRequest{
 		Offset: offset,
 		Limit: limit,
-		Search: c.Param("search"),
-	})
+		Search: c.Query("search"),
+		Sort: sort,
+		Cursor: cursor,
+		Status: c.Query("status"),
+	}
+
+	if len(request.Status) > 0 && !models.IsValidOrderStatus(request.Status){
+		h.handlerResponse(c, "Get List Orders", 400, "Invalid Status")
+		return
+	}
 
+	request.Customer_id, err = getUUIDQuery(c, "customer_id")
 	if err != nil{
-		h.handlerResponse(c, "Storage Get List", 500, err.Error())
+		h.handlerResponse(c, "Get List Orders", 400, err.Error())
+		return
+	}
+
+	request.Courier_id, err = getUUIDQuery(c, "courier_id")
+	if err != nil{
+		h.handlerResponse(c, "Get List Orders", 400, err.Error())
+		return
+	}
+
+	request.Created_from, err = getDateQuery(c, "created_from")
+	if err != nil{
+		h.handlerResponse(c, "Get List Orders", 400, err.Error())
+		return
+	}
+
+	request.Created_to, err = getDateQuery(c, "created_to")
+	if err != nil{
+		h.handlerResponse(c, "Get List Orders", 400, err.Error())
+		return
+	}
+
+	request.Min_price, err = getFloatQuery(c, "min_price")
+	if err != nil{
+		h.handlerResponse(c, "Get List Orders", 400, err.Error())
+		return
+	}
+
+	request.Max_price, err = getFloatQuery(c, "max_price")
+	if err != nil{
+		h.handlerResponse(c, "Get List Orders", 400, err.Error())
+		return
+	}
+
+	if principal := ownScope(c); principal != nil {
+		request.Courier_id = principal.Courier_id
+		request.Customer_id = principal.Customer_id
+	}
+
+	resp, err := h.storages.Order().GetListOrders(context.Background(), request)
+
+	if err != nil{
+		h.handlerResponse(c, "Storage Get List", 500, err)
 		return
 	}
 	
@@ -126,10 +247,14 @@ func (h *Handler) GetListOrders(c *gin.Context) {
 // @Tags Order
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param id path string true "id"
 // @Param order body models.UpdateOrder true "UpdateOrderRequest"
-// @Success 200 {object} Response{data=string} "Success Request"
+// @Success 200 {object} Response{data=models.Order} "Success Request"
 // @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 409 {object} Response{data=storage.InsufficientStockError} "Insufficient Stock"
+// @Response 402 {object} Response{data=storage.InsufficientBalanceError} "Insufficient Balance"
 // @Failure 500 {object} Response{data=string} "Server Error"
 func (h *Handler) UpdateOrder(c *gin.Context) {
 
@@ -147,11 +272,54 @@ func (h *Handler) UpdateOrder(c *gin.Context) {
 		return
 	}
 
+	err = validateOrderItems(updateOrder.Items, false)
+	if err != nil{
+		h.handlerResponse(c, "Update Order", 400, err.Error())
+		return
+	}
+
+	if len(updateOrder.Address_id) > 0 {
+		if !helper.IsValidUUID(updateOrder.Address_id){
+			h.handlerResponse(c, "Update Order", 400, "Invalid address_id UUID")
+			return
+		}
+	} else if !helper.IsValidCoordinate(updateOrder.Latitude, updateOrder.Longtitude){
+		h.handlerResponse(c, "Update Order", 400, "Invalid Coordinates")
+		return
+	}
+
 	updateOrder.Id = id
 
 	rowsAffected, err := h.storages.Order().UpdateOrder(context.Background(), &updateOrder)
 	if err != nil{
-		h.handlerResponse(c, "Storage Update Order", 500, err.Error())
+		var stockErr *storage.InsufficientStockError
+		if errors.As(err, &stockErr){
+			h.handlerResponse(c, "Update Order", http.StatusConflict, stockErr)
+			return
+		}
+
+		var balanceErr *storage.InsufficientBalanceError
+		if errors.As(err, &balanceErr){
+			h.handlerResponse(c, "Update Order", http.StatusPaymentRequired, balanceErr)
+			return
+		}
+
+		if errors.Is(err, storage.ErrOrderItemsLocked) || errors.Is(err, storage.ErrCourierUnavailable){
+			h.handlerResponse(c, "Update Order", http.StatusConflict, err.Error())
+			return
+		}
+
+		if errors.Is(err, storage.ErrAddressNotFound){
+			h.handlerResponse(c, "Update Order", http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if code, message, ok := deliveryError(err); ok{
+			h.handlerResponse(c, "Update Order", code, message)
+			return
+		}
+
+		h.handlerResponse(c, "Storage Update Order", 500, err)
 		return
 	}
 
@@ -162,7 +330,8 @@ func (h *Handler) UpdateOrder(c *gin.Context) {
 
 	resp, err := h.storages.Order().GetByIdOrder(context.Background(), &models.OrderPrimaryKey{Id: id})
 	if err != nil{
-		h.handlerResponse(c, "Storage Update Order Get By ID", 500, err.Error())
+		h.handlerResponse(c, "Storage Update Order Get By ID", 500, err)
+		return
 	}
 
 	h.handlerResponse(c, "Update Order", 200, resp)
@@ -173,10 +342,12 @@ func (h *Handler) UpdateOrder(c *gin.Context) {
 // @ID delete_order
 // @Router /order/{id} [DELETE]
 // @Summary Delete Order
-// @Description Delete Order
+// @Description Delete an order. An open order gives back its reserved stock and its payment is refunded.
 // @Tags Order
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param id path string true "id"
 // @Success 200 {object} Response{data=string} "Success Request"
 // @Response 400 {object} Response{data=string} "Bad Request"
@@ -192,7 +363,7 @@ func (h *Handler) DeleteOrder(c *gin.Context) {
 
 	err := h.storages.Order().DeleteOrder(context.Background(), &models.OrderPrimaryKey{Id: id})
 	if err != nil{
-		h.handlerResponse(c, "Storage Delete Order", 500, err.Error())
+		h.handlerResponse(c, "Storage Delete Order", 500, err)
 		return
 	}
 
@@ -204,14 +375,17 @@ func (h *Handler) DeleteOrder(c *gin.Context) {
 // @ID update_patch_order
 // @Router /order/{id} [PATCH]
 // @Summary Update Patch Order
-// @Description Update Patch Order
+// @Description Patch the name, phone_number, latitude, longtitude, user_id or customer_id of an order; any other field is rejected
 // @Tags Order
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param id path string true "id"
 // @Param order body models.PatchRequest true "UpdatPatchOrderRequest"
-// @Success 200 {object} Response{data=string} "Success Request"
+// @Success 200 {object} Response{data=models.Order} "Success Request"
 // @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 402 {object} Response{data=storage.InsufficientBalanceError} "Insufficient Balance"
 // @Failure 500 {object} Response{data=string} "Server Error"
 func (h *Handler) UpdatePatchOrder(c *gin.Context) {
 
@@ -230,11 +404,33 @@ func (h *Handler) UpdatePatchOrder(c *gin.Context) {
 		return
 	}
 
+	if len(object.Fields) <= 0{
+		h.handlerResponse(c, "Update Patch Order", 400, "no fields to update")
+		return
+	}
+
+	err = models.CheckPatchFields(object.Fields, models.OrderPatchFields)
+	if err != nil{
+		h.handlerResponse(c, "Update Patch Order", 400, err.Error())
+		return
+	}
+
 	object.ID = id
 
 	rowsAffected, err := h.storages.Order().PatchOrder(context.Background(), &object)
 	if err != nil{
-		h.handlerResponse(c, "Storage Patch Order", 500, err.Error())
+		var balanceErr *storage.InsufficientBalanceError
+		if errors.As(err, &balanceErr){
+			h.handlerResponse(c, "Patch Order", http.StatusPaymentRequired, balanceErr)
+			return
+		}
+
+		if code, message, ok := deliveryError(err); ok{
+			h.handlerResponse(c, "Patch Order", code, message)
+			return
+		}
+
+		h.handlerResponse(c, "Storage Patch Order", 500, err)
 		return
 	}
 
@@ -245,9 +441,209 @@ func (h *Handler) UpdatePatchOrder(c *gin.Context) {
 
 	resp, err := h.storages.Order().GetByIdOrder(context.Background(), &models.OrderPrimaryKey{Id: id})
 	if err != nil{
-		h.handlerResponse(c, "Patch Order Get By ID", 500, err.Error())
+		h.handlerResponse(c, "Patch Order Get By ID", 500, err)
 		return
 	}
 
 	h.handlerResponse(c, "Patch Order", 200, resp)
-}
\ No newline at end of file
+}
+
+// Update Order Status godoc
+// @ID update_order_status
+// @Router /order/{id}/status [POST]
+// @Summary Update Order Status
+// @Description Move an order to the next status of its lifecycle
+// @Tags Order
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path string true "id"
+// @Param order body models.UpdateOrderStatus true "UpdateOrderStatusRequest"
+// @Success 200 {object} Response{data=models.Order} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 409 {object} Response{data=string} "Illegal Status Transition"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) UpdateOrderStatus(c *gin.Context) {
+
+	var updateStatus models.UpdateOrderStatus
+
+	id := c.Param("id")
+	if !helper.IsValidUUID(id){
+		h.handlerResponse(c, "Update Order Status", 400, "Invalid UUID")
+		return
+	}
+
+	err := c.ShouldBindJSON(&updateStatus)
+	if err != nil{
+		h.handlerResponse(c, "Update Order Status", 400, err.Error())
+		return
+	}
+
+	if !models.IsValidOrderStatus(updateStatus.Status){
+		h.handlerResponse(c, "Update Order Status", 400, "Invalid Status")
+		return
+	}
+
+	updateStatus.Id = id
+	updateStatus.Changed_by = c.GetString(contextUserId)
+	updateStatus.Api_key_id = c.GetString(contextApiKeyId)
+
+	rowsAffected, err := h.storages.Order().UpdateOrderStatus(context.Background(), &updateStatus)
+	if err != nil{
+		var transitionErr *storage.StatusTransitionError
+		if errors.As(err, &transitionErr){
+			h.handlerResponse(c, "Update Order Status", http.StatusConflict, err.Error())
+			return
+		}
+
+		h.handlerResponse(c, "Storage Update Order Status", 500, err)
+		return
+	}
+
+	if rowsAffected <= 0{
+		h.handlerResponse(c, "Update Order Status", 400, "No rows affected")
+		return
+	}
+
+	resp, err := h.storages.Order().GetByIdOrder(context.Background(), &models.OrderPrimaryKey{Id: id})
+	if err != nil{
+		h.handlerResponse(c, "Update Order Status Get By ID", 500, err)
+		return
+	}
+
+	h.handlerResponse(c, "Update Order Status", 200, resp)
+}
+
+
+// Assign Order Courier godoc
+// @ID assign_order_courier
+// @Router /order/{id}/assign [POST]
+// @Summary Assign Order Courier
+// @Description Assign the nearest available courier to an order that has not been picked up yet
+// @Tags Order
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path string true "id"
+// @Success 200 {object} Response{data=models.Order} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 409 {object} Response{data=string} "No Courier Available"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) AssignOrderCourier(c *gin.Context) {
+
+	id := c.Param("id")
+	if !helper.IsValidUUID(id){
+		h.handlerResponse(c, "Assign Order Courier", 400, "Invalid UUID")
+		return
+	}
+
+	rowsAffected, err := h.storages.Order().AssignCourier(context.Background(), &models.OrderPrimaryKey{Id: id})
+	if err != nil{
+		if errors.Is(err, storage.ErrNoCourierAvailable) || errors.Is(err, storage.ErrCourierAssignmentLocked){
+			h.handlerResponse(c, "Assign Order Courier", http.StatusConflict, err.Error())
+			return
+		}
+
+		h.handlerResponse(c, "Storage Assign Order Courier", 500, err)
+		return
+	}
+
+	if rowsAffected <= 0{
+		h.handlerResponse(c, "Assign Order Courier", 400, "No rows affected")
+		return
+	}
+
+	resp, err := h.storages.Order().GetByIdOrder(context.Background(), &models.OrderPrimaryKey{Id: id})
+	if err != nil{
+		h.handlerResponse(c, "Assign Order Courier Get By ID", 500, err)
+		return
+	}
+
+	h.handlerResponse(c, "Assign Order Courier", 200, resp)
+}
+
+
+// Get Order Status History godoc
+// @ID get_order_status_history
+// @Router /order/{id}/status-history [GET]
+// @Summary Get Order Status History
+// @Description Get Order Status History
+// @Tags Order
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path string true "id"
+// @Success 200 {object} Response{data=models.GetOrderStatusHistoryResponse} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) GetOrderStatusHistory(c *gin.Context) {
+
+	id := c.Param("id")
+	if !helper.IsValidUUID(id){
+		h.handlerResponse(c, "Get Order Status History", 400, "Invalid UUID")
+		return
+	}
+
+	resp, err := h.storages.Order().GetOrderStatusHistory(context.Background(), &models.OrderPrimaryKey{Id: id})
+	if err != nil{
+		h.handlerResponse(c, "Storage Get Order Status History", 500, err)
+		return
+	}
+
+	h.handlerResponse(c, "Get Order Status History", 200, resp)
+}
+
+
+// orderCreationError maps the business errors that can stop an order from
+// being placed to a response status and body.
+func orderCreationError(err error) (int, interface{}, bool) {
+
+	var (
+		stockErr		*storage.InsufficientStockError
+		balanceErr		*storage.InsufficientBalanceError
+		unavailableErr	*storage.UnavailableProductsError
+	)
+
+	switch {
+	case errors.As(err, &stockErr):
+		return http.StatusConflict, stockErr, true
+	case errors.As(err, &balanceErr):
+		return http.StatusPaymentRequired, balanceErr, true
+	case errors.As(err, &unavailableErr):
+		return http.StatusConflict, unavailableErr, true
+	case errors.Is(err, storage.ErrNoCourierAvailable), errors.Is(err, storage.ErrCourierUnavailable):
+		return http.StatusConflict, err.Error(), true
+	case errors.Is(err, storage.ErrAddressNotFound):
+		return http.StatusBadRequest, err.Error(), true
+	}
+
+	return deliveryError(err)
+}
+
+// validateOrderItems checks the line items of an order request. Items are
+// mandatory on create, while an update may omit them to keep the current ones.
+func validateOrderItems(items []*models.CreateOrderItem, required bool) error {
+
+	if len(items) <= 0 {
+		if required {
+			return errors.New("order must contain at least one item")
+		}
+		return nil
+	}
+
+	for i, item := range items {
+
+		if item == nil || !helper.IsValidUUID(item.Product_id) {
+			return fmt.Errorf("items[%d]: invalid product_id", i)
+		}
+
+		if item.Quantity <= 0 {
+			return fmt.Errorf("items[%d]: quantity must be greater than zero", i)
+		}
+	}
+
+	return nil
+}
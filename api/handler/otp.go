@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"app/api/models"
+	"app/pkg/helper"
+	"app/storage"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Request Otp godoc
+// @ID request_otp
+// @Router /auth/otp/request [POST]
+// @Summary Request Otp
+// @Description Send a one-time login code to a customer's phone
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param otp body models.RequestOtp true "RequestOtp"
+// @Success 200 {object} Response{data=models.OtpSent} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 429 {object} Response{data=string} "Too Many Requests"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) RequestOtp(c *gin.Context) {
+
+	var request models.RequestOtp
+
+	err := c.ShouldBindJSON(&request)
+	if err != nil {
+		h.handlerResponse(c, "Request Otp", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if !helper.IsValidPhone(request.Phone) {
+		h.handlerResponse(c, "Request Otp", http.StatusBadRequest, "invalid phone")
+		return
+	}
+
+	code, err := helper.GenerateOTP(h.cfg.OtpLength)
+	if err != nil {
+		h.handlerResponse(c, "Request Otp Generate", http.StatusInternalServerError, err)
+		return
+	}
+
+	hash, err := helper.HashPassword(code)
+	if err != nil {
+		h.handlerResponse(c, "Request Otp Hash", http.StatusInternalServerError, err)
+		return
+	}
+
+	_, err = h.storages.Otp().CreateCustomerOtp(context.Background(), &models.CreateCustomerOtp{
+		Phone:     request.Phone,
+		Code_hash: hash,
+	})
+	if err != nil {
+		var tooSoon *storage.OtpTooSoonError
+		if errors.As(err, &tooSoon) {
+			c.Header("Retry-After", strconv.Itoa(tooSoon.RetryAfter))
+			h.handlerResponse(c, "Request Otp", http.StatusTooManyRequests, err.Error())
+			return
+		}
+
+		h.handlerResponse(c, "Storage Request Otp", http.StatusInternalServerError, err)
+		return
+	}
+
+	message := fmt.Sprintf("Your Shopcart code is %s. It expires in %d minutes.", code, h.cfg.OtpTTL)
+
+	err = h.sms.Send(c.Request.Context(), request.Phone, message)
+	if err != nil {
+		h.handlerResponse(c, "Request Otp Send", http.StatusInternalServerError, err)
+		return
+	}
+
+	h.handlerResponse(c, "Request Otp", http.StatusOK, &models.OtpSent{
+		Phone:      request.Phone,
+		Expires_in: h.cfg.OtpTTL * 60,
+	})
+}
+
+// Verify Otp godoc
+// @ID verify_otp
+// @Router /auth/otp/verify [POST]
+// @Summary Verify Otp
+// @Description Exchange a one-time code for a token pair. The phone of the customer is marked verified;
+// @Description a customer and a user account are created on first login.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param otp body models.VerifyOtp true "VerifyOtp"
+// @Success 200 {object} Response{data=models.TokenResponse} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 401 {object} Response{data=string} "Invalid Code"
+// @Response 429 {object} Response{data=string} "Too Many Attempts"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) VerifyOtp(c *gin.Context) {
+
+	var verify models.VerifyOtp
+
+	err := c.ShouldBindJSON(&verify)
+	if err != nil {
+		h.handlerResponse(c, "Verify Otp", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if !helper.IsValidPhone(verify.Phone) {
+		h.handlerResponse(c, "Verify Otp", http.StatusBadRequest, "invalid phone")
+		return
+	}
+
+	if len(verify.Code) <= 0 {
+		h.handlerResponse(c, "Verify Otp", http.StatusBadRequest, "code is required")
+		return
+	}
+
+	login, err := h.storages.Otp().VerifyCustomerOtp(context.Background(), &verify)
+	if err != nil {
+		var invalid *storage.InvalidOtpError
+
+		switch {
+		case errors.As(err, &invalid), errors.Is(err, storage.ErrOtpNotFound):
+			h.handlerResponse(c, "Verify Otp", http.StatusUnauthorized, err.Error())
+		case errors.Is(err, storage.ErrOtpAttemptsExceeded):
+			h.handlerResponse(c, "Verify Otp", http.StatusTooManyRequests, err.Error())
+		default:
+			h.handlerResponse(c, "Storage Verify Otp", http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	resp, err := h.issueTokens(login.User_id)
+	if err != nil {
+		h.handlerResponse(c, "Verify Otp Issue Tokens", http.StatusInternalServerError, err)
+		return
+	}
+
+	h.handlerResponse(c, "Verify Otp", http.StatusOK, resp)
+}
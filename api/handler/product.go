@@ -17,6 +17,8 @@ import (
 // @Tags Product
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param product body models.CreateProduct true "CreateProductRequest"
 // @Success 200 {object} Response{data=string} "Success Request"
 // @Response 400 {object} Response{data=string} "Bad Request"
@@ -31,15 +33,20 @@ func (h *Handler) CreateProduct(c *gin.Context){
 		return
 	}
 
+	if createProduct.Stock < 0{
+		h.handlerResponse(c, "Create Product", 400, "stock must not be negative")
+		return
+	}
+
 	id, err := h.storages.Product().CreateProduct(context.Background(), &createProduct)
 	if err != nil{
-		h.handlerResponse(c, "Storage Create Product", 500, err.Error())
+		h.handlerResponse(c, "Storage Create Product", 500, err)
 		return
 	}
 
 	resp, err := h.storages.Product().GetByIdProduct(context.Background(), &models.ProductPrimaryKey{Id: id})
 	if err != nil{
-		h.handlerResponse(c, "Create Product Storage Get By Id", 500, err.Error())
+		h.handlerResponse(c, "Create Product Storage Get By Id", 500, err)
 		return
 	}
 
@@ -54,9 +61,13 @@ func (h *Handler) CreateProduct(c *gin.Context){
 // @Tags Product
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param id path string true "id"
+// @Param include_deleted query boolean false "include deleted records, requires product.write"
 // @Success 200 {object} Response{data=string} "Success Request"
 // @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 403 {object} Response{data=string} "Forbidden"
 // @Failure 500 {object} Response{data=string} "Server Error"
 func (h *Handler) GetByIdProduct(c *gin.Context) {
 
@@ -66,9 +77,15 @@ func (h *Handler) GetByIdProduct(c *gin.Context) {
 		return
 	}
 
-	resp, err := h.storages.Product().GetByIdProduct(context.Background(), &models.ProductPrimaryKey{Id: id})
+	includeDeleted, code, err := getIncludeDeletedQuery(c, "product")
 	if err != nil{
-		h.handlerResponse(c, "Storage Product Get By id", 500, err.Error())
+		h.handlerResponse(c, "Product Get By Id", code, err.Error())
+		return
+	}
+
+	resp, err := h.storages.Product().GetByIdProduct(context.Background(), &models.ProductPrimaryKey{Id: id, Include_deleted: includeDeleted})
+	if err != nil{
+		h.handlerResponse(c, "Storage Product Get By id", 500, err)
 		return
 	}
 
@@ -84,34 +101,85 @@ func (h *Handler) GetByIdProduct(c *gin.Context) {
 // @Tags Product
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param offset query string false "offset"
 // @Param limit query string false "limit"
-// @Param search query string false "search"
-// @Success 200 {object} Response{data=string} "Success Request"
+// @Param search query string false "search by name"
+// @Param sort query string false "comma separated fields, prefixed with - for descending: name, price, stock, created_at, updated_at"
+// @Param cursor query string false "keyset paging from the newest record: pass an empty cursor to start, then next_cursor"
+// @Param category_id query string false "category_id"
+// @Param min_price query number false "min_price"
+// @Param max_price query number false "max_price"
+// @Param include_deleted query boolean false "include deleted records, requires product.write"
+// @Success 200 {object} Response{data=models.GetListProductResponse} "Success Request"
 // @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 403 {object} Response{data=string} "Forbidden"
 // @Failure 500 {object} Response{data=string} "Server Error"
 func (h *Handler) GetListProduct(c *gin.Context) {
 
-	offset, err := h.getOffsetQuery(c.Param("offset"))
+	offset, err := h.getOffsetQuery(c.Query("offset"))
+	if err != nil{
+		h.handlerResponse(c, "Get List Product", 400, err.Error())
+		return
+	}
+
+	limit, err := h.getLimitQuery(c.Query("limit"))
+	if err != nil{
+		h.handlerResponse(c, "Get List Product", 400, err.Error())
+		return
+	}
+
+	sort, err := models.ParseSort(c.Query("sort"), models.ProductSortFields)
 	if err != nil{
 		h.handlerResponse(c, "Get List Product", 400, err.Error())
 		return
 	}
 
-	limit, err := h.getLimitQuery(c.Param("limit"))
+	cursor, err := getCursorQuery(c, offset, limit)
 	if err != nil{
 		h.handlerResponse(c, "Get List Product", 400, err.Error())
 		return
 	}
 
-	resp, err := h.storages.Product().GetListProduct(context.Background(), &models.GetListProductRequest{
+	request := &models.GetListProductRequest{
 		Offset: offset,
 		Limit: limit,
-		Search: c.Param("search"),
-	})
+		Search: c.Query("search"),
+		Sort: sort,
+		Cursor: cursor,
+	}
+
+	request.Category_id, err = getUUIDQuery(c, "category_id")
+	if err != nil{
+		h.handlerResponse(c, "Get List Product", 400, err.Error())
+		return
+	}
+
+	request.Min_price, err = getFloatQuery(c, "min_price")
+	if err != nil{
+		h.handlerResponse(c, "Get List Product", 400, err.Error())
+		return
+	}
+
+	request.Max_price, err = getFloatQuery(c, "max_price")
+	if err != nil{
+		h.handlerResponse(c, "Get List Product", 400, err.Error())
+		return
+	}
 
+	includeDeleted, code, err := getIncludeDeletedQuery(c, "product")
 	if err != nil{
-		h.handlerResponse(c, "Storage Get List Product", 500, err.Error())
+		h.handlerResponse(c, "Get List Product", code, err.Error())
+		return
+	}
+
+	request.Include_deleted = includeDeleted
+
+	resp, err := h.storages.Product().GetListProduct(context.Background(), request)
+
+	if err != nil{
+		h.handlerResponse(c, "Storage Get List Product", 500, err)
 		return
 	}
 
@@ -127,6 +195,8 @@ func (h *Handler) GetListProduct(c *gin.Context) {
 // @Tags Product
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param id path string true "id"
 // @Param product body models.UpdateProduct true "UpdateProductRequest"
 // @Success 200 {object} Response{data=string} "Success Request"
@@ -148,11 +218,16 @@ func (h *Handler) UpdateProduct(c *gin.Context) {
 		return 
 	}
 
+	if update_product.Stock < 0{
+		h.handlerResponse(c, "Update Product", 400, "stock must not be negative")
+		return
+	}
+
 	update_product.Id = id
 
 	rowsAffected, err := h.storages.Product().UpdateProduct(context.Background(), &update_product)
 	if err != nil{
-		h.handlerResponse(c, "Storage Update Product", 500, err.Error())
+		h.handlerResponse(c, "Storage Update Product", 500, err)
 		return
 	}
 
@@ -163,7 +238,7 @@ func (h *Handler) UpdateProduct(c *gin.Context) {
 
 	resp, err := h.storages.Product().GetByIdProduct(context.Background(), &models.ProductPrimaryKey{Id: id})
 	if err != nil{
-		h.handlerResponse(c, "Storage Update Product Get By Id", 500, err.Error())
+		h.handlerResponse(c, "Storage Update Product Get By Id", 500, err)
 		return
 	}
 
@@ -179,9 +254,12 @@ func (h *Handler) UpdateProduct(c *gin.Context) {
 // @Tags Product
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param id path string true "id"
 // @Success 200 {object} Response{data=string} "Success Request"
 // @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
 // @Failure 500 {object} Response{data=string} "Server Error
 func (h *Handler) DeleteProduct(c *gin.Context) {
 
@@ -193,10 +271,48 @@ func (h *Handler) DeleteProduct(c *gin.Context) {
 
 	err := h.storages.Product().DeleteProduct(context.Background(), &models.ProductPrimaryKey{Id: id})
 	if err != nil{
-		h.handlerResponse(c, "Storage Delete Product", 500, err.Error())
+		h.handlerResponse(c, "Storage Delete Product", 500, err)
 		return
 	}
 
 	h.handlerResponse(c, "Delete Product", http.StatusOK, nil)
-	
-}
\ No newline at end of file
+}
+
+// Restore Product godoc
+// @ID restore_product
+// @Router /product/{id}/restore [POST]
+// @Summary Restore Product
+// @Description Restore Deleted Product
+// @Tags Product
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path string true "id"
+// @Success 200 {object} Response{data=models.Product} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
+// @Response 422 {object} Response{data=string} "Category Deleted"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) RestoreProduct(c *gin.Context) {
+
+	id := c.Param("id")
+	if !helper.IsValidUUID(id){
+		h.handlerResponse(c, "Restore Product", http.StatusBadRequest, "Invalid UUID")
+		return
+	}
+
+	err := h.storages.Product().RestoreProduct(context.Background(), &models.ProductPrimaryKey{Id: id})
+	if err != nil{
+		h.handlerResponse(c, "Storage Restore Product", 500, err)
+		return
+	}
+
+	resp, err := h.storages.Product().GetByIdProduct(context.Background(), &models.ProductPrimaryKey{Id: id})
+	if err != nil{
+		h.handlerResponse(c, "Restore Product Storage Get By Id", 500, err)
+		return
+	}
+
+	h.handlerResponse(c, "Restore Product", http.StatusOK, resp)
+}
@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"app/api/models"
+	"app/pkg/helper"
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Get List Roles godoc
+// @ID get_list_roles
+// @Router /role [GET]
+// @Summary Get List Roles
+// @Description Get every role with the permissions it grants
+// @Tags Role
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Success 200 {object} Response{data=models.GetListRoleResponse} "Success Request"
+// @Response 403 {object} Response{data=string} "Forbidden"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) GetListRoles(c *gin.Context) {
+
+	resp, err := h.storages.Role().GetListRoles(context.Background())
+	if err != nil {
+		h.handlerResponse(c, "Storage Get List Roles", http.StatusInternalServerError, err)
+		return
+	}
+
+	h.handlerResponse(c, "Get List Roles", http.StatusOK, resp)
+}
+
+// Assign User Role godoc
+// @ID assign_user_role
+// @Router /user/{id}/role [PUT]
+// @Summary Assign User Role
+// @Description Set the role of a user and the courier or customer their own records are linked to
+// @Tags Role
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path string true "id"
+// @Param role body models.AssignUserRole true "AssignUserRoleRequest"
+// @Success 202 {object} Response{data=models.User} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 403 {object} Response{data=string} "Forbidden"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) AssignUserRole(c *gin.Context) {
+
+	id := c.Param("id")
+	if !helper.IsValidUUID(id) {
+		h.handlerResponse(c, "Assign User Role", http.StatusBadRequest, "Invalid UUID")
+		return
+	}
+
+	var assignRole models.AssignUserRole
+
+	err := c.ShouldBindJSON(&assignRole)
+	if err != nil {
+		h.handlerResponse(c, "Assign User Role", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if len(assignRole.Courier_id) > 0 && !helper.IsValidUUID(assignRole.Courier_id) {
+		h.handlerResponse(c, "Assign User Role", http.StatusBadRequest, "Invalid courier_id UUID")
+		return
+	}
+
+	if len(assignRole.Customer_id) > 0 && !helper.IsValidUUID(assignRole.Customer_id) {
+		h.handlerResponse(c, "Assign User Role", http.StatusBadRequest, "Invalid customer_id UUID")
+		return
+	}
+
+	assignRole.User_id = id
+
+	rowsAffected, err := h.storages.Role().AssignUserRole(context.Background(), &assignRole)
+	if err != nil {
+		h.handlerResponse(c, "Storage Assign User Role", http.StatusInternalServerError, err)
+		return
+	}
+
+	if rowsAffected <= 0 {
+		h.handlerResponse(c, "Assign User Role", http.StatusBadRequest, "No Rows Affected")
+		return
+	}
+
+	resp, err := h.storages.User().UserGetByID(context.Background(), &models.UserPrimaryKey{Id: id})
+	if err != nil {
+		h.handlerResponse(c, "Storage Assign User Role Get By ID", http.StatusInternalServerError, err)
+		return
+	}
+
+	h.handlerResponse(c, "Assign User Role", http.StatusAccepted, resp)
+}
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"app/api/models"
+	"app/pkg/events"
+	"app/pkg/helper"
+	"app/pkg/logger"
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Stream Order godoc
+// @ID stream_order
+// @Router /order/{id}/stream [GET]
+// @Summary Stream Order
+// @Description Server-Sent Events stream of an order. The current order is sent first as an "order" event,
+// @Description followed by "order.status", "order.courier" and "courier.location" events as they happen,
+// @Description and a "ping" event every few seconds. The stream ends once the order is delivered, cancelled or returned.
+// @Tags Order
+// @Produce text/event-stream
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path string true "id"
+// @Param access_token query string false "access token, for clients that cannot set the Authorization header"
+// @Success 200 {object} events.Event "Event Stream"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) StreamOrder(c *gin.Context) {
+
+	id := c.Param("id")
+	if !helper.IsValidUUID(id) {
+		h.handlerResponse(c, "Stream Order", http.StatusBadRequest, "Invalid UUID")
+		return
+	}
+
+	// Subscribe before reading the snapshot so no event in between is lost.
+	sub := h.storages.Events().Subscribe(events.OrderTopic(id))
+	defer sub.Close()
+
+	order, err := h.storages.Order().GetByIdOrder(context.Background(), &models.OrderPrimaryKey{Id: id})
+	if err != nil {
+		h.handlerResponse(c, "Storage Stream Order Get By ID", http.StatusInternalServerError, err)
+		return
+	}
+
+	courierId := order.Courier_id
+	if len(courierId) > 0 {
+		sub.Subscribe(events.CourierTopic(courierId))
+	}
+
+	heartbeat := time.NewTicker(time.Duration(h.cfg.StreamHeartbeat) * time.Second)
+	defer heartbeat.Stop()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	h.logger.Info("Stream Order", logger.String("order_id", id))
+
+	c.SSEvent("order", order)
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+
+		case <-heartbeat.C:
+			c.SSEvent("ping", time.Now().Format(time.RFC3339))
+			return true
+
+		case event, ok := <-sub.C:
+			if !ok {
+				return false
+			}
+
+			c.SSEvent(event.Type, event)
+
+			switch data := event.Data.(type) {
+			case *models.OrderCourierEvent:
+				if data.Courier_id != courierId {
+					if len(courierId) > 0 {
+						sub.Unsubscribe(events.CourierTopic(courierId))
+					}
+					courierId = data.Courier_id
+					if len(courierId) > 0 {
+						sub.Subscribe(events.CourierTopic(courierId))
+					}
+				}
+			case *models.OrderStatusHistory:
+				if data.To_status == models.OrderStatusCancelled || data.To_status == models.OrderStatusReturned ||
+					data.To_status == models.OrderStatusDelivered {
+					return false
+				}
+			}
+
+			return true
+		}
+	})
+
+	h.logger.Info("Stream Order Closed", logger.String("order_id", id))
+}
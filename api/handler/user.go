@@ -3,7 +3,9 @@ package handler
 import (
 	"app/api/models"
 	"app/pkg/helper"
+	"app/storage"
 	"context"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -17,6 +19,8 @@ import (
 // @Tags User
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param book body models.CreateUser true "CreateUserRequest"
 // @Success 200 {object} Response{data=string} "Success Request"
 // @Response 400 {object} Response{data=string} "Bad Request"
@@ -31,15 +35,26 @@ func (h *Handler) CreateUser(c *gin.Context){
 		return
 	}
 
+	err = hashUserPassword(&createUser)
+	if err != nil{
+		h.handlerResponse(c, "Create User Body", http.StatusBadRequest, err.Error())
+		return
+	}
+
 	id, err := h.storages.User().CreateUser(context.Background(), &createUser)
 	if err != nil{
-		h.handlerResponse(c, "Storage create user", http.StatusInternalServerError, err.Error())
+		if errors.Is(err, storage.ErrLoginTaken){
+			h.handlerResponse(c, "Create User", http.StatusConflict, err.Error())
+			return
+		}
+
+		h.handlerResponse(c, "Storage create user", http.StatusInternalServerError, err)
 		return
 	}
 
 	resp, err := h.storages.User().UserGetByID(context.Background(), &models.UserPrimaryKey{Id: id})
 	if err != nil{
-		h.handlerResponse(c, "Create User Get By id", http.StatusInternalServerError, err.Error())
+		h.handlerResponse(c, "Create User Get By id", http.StatusInternalServerError, err)
 		return
 	}
 
@@ -57,11 +72,18 @@ func (h *Handler) CreateUser(c *gin.Context){
 // @Tags User
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param offset query string false "offset"
 // @Param limit query string false "limit"
-// @Param search query string false "search"
+// @Param search query string false "search by name or login"
+// @Param sort query string false "comma separated fields, prefixed with - for descending: name, login, balance, created_at, updated_at"
+// @Param cursor query string false "keyset paging from the newest record: pass an empty cursor to start, then next_cursor"
+// @Param role query string false "role"
+// @Param include_deleted query boolean false "include deleted records, requires user.write"
 // @Success 200 {object} Response{data=string} "Success Request"
 // @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 403 {object} Response{data=string} "Forbidden"
 // @Failure 500 {object} Response{data=string} "Server Error"
 func (h *Handler) GetListUSer(c *gin.Context) {
 
@@ -77,15 +99,36 @@ func (h *Handler) GetListUSer(c *gin.Context) {
 		return
 	}
 
-	
+	sort, err := models.ParseSort(c.Query("sort"), models.UserSortFields)
+	if err != nil{
+		h.handlerResponse(c, "Get List User", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	cursor, err := getCursorQuery(c, offset, limit)
+	if err != nil{
+		h.handlerResponse(c, "Get List User", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	includeDeleted, code, err := getIncludeDeletedQuery(c, "user")
+	if err != nil{
+		h.handlerResponse(c, "Get List User", code, err.Error())
+		return
+	}
+
 	resp, err := h.storages.User().UserGetList(context.Background(), &models.GetListUserRequest{
 		Offset: offset,
 		Limit: limit,
 		Search: c.Query("search"),
+		Sort: sort,
+		Cursor: cursor,
+		Role: c.Query("role"),
+		Include_deleted: includeDeleted,
 	})
 
 	if err != nil{
-		h.handlerResponse(c, "Get List User", http.StatusInternalServerError, err.Error())
+		h.handlerResponse(c, "Get List User", http.StatusInternalServerError, err)
 		return
 	}
 
@@ -101,9 +144,13 @@ func (h *Handler) GetListUSer(c *gin.Context) {
 // @Tags User
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param id path string true "id"
+// @Param include_deleted query boolean false "include deleted records, requires user.write"
 // @Success 200 {object} Response{data=string} "Success Request"
 // @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 403 {object} Response{data=string} "Forbidden"
 // @Failure 500 {object} Response{data=string} "Server Error"
 func (h *Handler) GetByIDUser(c *gin.Context) {
 
@@ -114,10 +161,16 @@ func (h *Handler) GetByIDUser(c *gin.Context) {
 		return
 	}
 
-	resp, err := h.storages.User().UserGetByID(context.Background(), &models.UserPrimaryKey{Id: id})
+	includeDeleted, code, err := getIncludeDeletedQuery(c, "user")
+	if err != nil{
+		h.handlerResponse(c, "Get User By Id", code, err.Error())
+		return
+	}
+
+	resp, err := h.storages.User().UserGetByID(context.Background(), &models.UserPrimaryKey{Id: id, Include_deleted: includeDeleted})
 	
 	if err != nil{
-		h.handlerResponse(c, "Get User By id", http.StatusInternalServerError, err.Error())
+		h.handlerResponse(c, "Get User By id", http.StatusInternalServerError, err)
 		return
 	}
 
@@ -133,6 +186,8 @@ func (h *Handler) GetByIDUser(c *gin.Context) {
 // @Tags User
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param id path string true "id"
 // @Param user body models.UpdateUser true "UpdateUserRequest"
 // @Success 200 {object} Response{data=string} "Success Request"
@@ -152,13 +207,14 @@ func (h *Handler) UpdateUser(c *gin.Context) {
 	err := c.ShouldBindJSON(&updateUser)
 	if err != nil{
 		h.handlerResponse(c, "Update User", http.StatusBadRequest, err.Error())
+		return
 	}
 
 	updateUser.Id = id
 
 	rowsAffected, err := h.storages.User().UpdateUser(context.Background(), &updateUser)
 	if err != nil{
-		h.handlerResponse(c, "Update User", http.StatusInternalServerError, err.Error())
+		h.handlerResponse(c, "Update User", http.StatusInternalServerError, err)
 		return
 	}
 
@@ -169,7 +225,7 @@ func (h *Handler) UpdateUser(c *gin.Context) {
 
 	resp, err := h.storages.User().UserGetByID(context.Background(), &models.UserPrimaryKey{Id: id})
 	if err != nil{
-		h.handlerResponse(c, "Update User Get By ID", http.StatusInternalServerError, err.Error())
+		h.handlerResponse(c, "Update User Get By ID", http.StatusInternalServerError, err)
 		return
 	}	
 
@@ -187,9 +243,12 @@ func (h *Handler) UpdateUser(c *gin.Context) {
 // @Tags User
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param id path string true "id"
 // @Success 200 {object} Response{data=string} "Success Request"
 // @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
 // @Failure 500 {object} Response{data=string} "Server Error
 func (h *Handler) DeleteUser(c *gin.Context) {
 
@@ -202,10 +261,156 @@ func (h *Handler) DeleteUser(c *gin.Context) {
 
 	err := h.storages.User().DeleteUser(context.Background(), &models.UserPrimaryKey{Id: id})
 	if err != nil{
-		h.handlerResponse(c, "Storage Delete User", http.StatusInternalServerError, err.Error())
+		h.handlerResponse(c, "Storage Delete User", http.StatusInternalServerError, err)
 		return
 	}
 
 	h.handlerResponse(c, "Delete User", http.StatusOK, nil)
 
-}
\ No newline at end of file
+}
+
+// Restore User godoc
+// @ID restore_user
+// @Router /user/{id}/restore [POST]
+// @Summary Restore User
+// @Description Restore Deleted User
+// @Tags User
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path string true "id"
+// @Success 200 {object} Response{data=models.User} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) RestoreUser(c *gin.Context) {
+
+	id := c.Param("id")
+	if !helper.IsValidUUID(id){
+		h.handlerResponse(c, "Restore User", http.StatusBadRequest, "Invalid UUID")
+		return
+	}
+
+	err := h.storages.User().RestoreUser(context.Background(), &models.UserPrimaryKey{Id: id})
+	if err != nil{
+		h.handlerResponse(c, "Storage Restore User", 500, err)
+		return
+	}
+
+	resp, err := h.storages.User().UserGetByID(context.Background(), &models.UserPrimaryKey{Id: id})
+	if err != nil{
+		h.handlerResponse(c, "Restore User Storage Get By Id", 500, err)
+		return
+	}
+
+	h.handlerResponse(c, "Restore User", http.StatusOK, resp)
+}
+
+// Top Up User Balance godoc
+// @ID top_up_user_balance
+// @Router /user/{id}/top-up [POST]
+// @Summary Top Up User Balance
+// @Description Add money to the wallet of a user
+// @Tags User
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path string true "id"
+// @Param top_up body models.TopUpBalance true "TopUpBalanceRequest"
+// @Success 201 {object} Response{data=models.BalanceTransaction} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) TopUpBalance(c *gin.Context) {
+
+	id := c.Param("id")
+
+	if !helper.IsValidUUID(id){
+		h.handlerResponse(c, "Top Up Balance", http.StatusBadRequest, "Invalid UUID")
+		return
+	}
+
+	var topUp models.TopUpBalance
+
+	err := c.ShouldBindJSON(&topUp)
+	if err != nil{
+		h.handlerResponse(c, "Top Up Balance", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if topUp.Amount <= 0{
+		h.handlerResponse(c, "Top Up Balance", http.StatusBadRequest, "amount must be greater than zero")
+		return
+	}
+
+	transactionId, err := h.storages.User().CreateBalanceTransaction(context.Background(), &models.CreateBalanceTransaction{
+		User_id: id,
+		Type: models.BalanceTransactionTopUp,
+		Amount: topUp.Amount,
+		Description: topUp.Description,
+	})
+	if err != nil{
+		h.handlerResponse(c, "Storage Top Up Balance", http.StatusInternalServerError, err)
+		return
+	}
+
+	resp, err := h.storages.User().GetByIdBalanceTransaction(context.Background(), &models.BalanceTransactionPrimaryKey{Id: transactionId})
+	if err != nil{
+		h.handlerResponse(c, "Top Up Balance Get By ID", http.StatusInternalServerError, err)
+		return
+	}
+
+	h.handlerResponse(c, "Top Up Balance", http.StatusCreated, resp)
+}
+
+
+// Get List Balance Transactions godoc
+// @ID get_list_balance_transactions
+// @Router /user/{id}/transactions [GET]
+// @Summary Get List Balance Transactions
+// @Description Get the wallet ledger of a user, newest first
+// @Tags User
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path string true "id"
+// @Param offset query string false "offset"
+// @Param limit query string false "limit"
+// @Success 200 {object} Response{data=models.GetListBalanceTransactionResponse} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) GetListBalanceTransactions(c *gin.Context) {
+
+	id := c.Param("id")
+
+	if !helper.IsValidUUID(id){
+		h.handlerResponse(c, "Get List Balance Transactions", http.StatusBadRequest, "Invalid UUID")
+		return
+	}
+
+	offset, err := h.getOffsetQuery(c.Query("offset"))
+	if err != nil{
+		h.handlerResponse(c, "Get List Balance Transactions", http.StatusBadRequest, "Invalid Offset")
+		return
+	}
+
+	limit, err := h.getLimitQuery(c.Query("limit"))
+	if err != nil{
+		h.handlerResponse(c, "Get List Balance Transactions", http.StatusBadRequest, "Invalid Limit")
+		return
+	}
+
+	resp, err := h.storages.User().GetListBalanceTransactions(context.Background(), &models.GetListBalanceTransactionRequest{
+		User_id: id,
+		Offset: offset,
+		Limit: limit,
+	})
+	if err != nil{
+		h.handlerResponse(c, "Get List Balance Transactions", http.StatusInternalServerError, err)
+		return
+	}
+
+	h.handlerResponse(c, "Get List Balance Transactions", http.StatusOK, resp)
+}
@@ -0,0 +1,52 @@
+package models
+
+type ApiKeyPrimaryKey struct {
+	Id		string	`json:"id"`
+}
+
+type ApiKey struct {
+	Id				string		`json:"id"`
+	Name			string		`json:"name"`
+	Prefix			string		`json:"prefix"`
+	Scopes			[]string	`json:"scopes"`
+	Created_by		string		`json:"created_by"`
+	Expires_at		string		`json:"expires_at"`
+	Last_used_at	string		`json:"last_used_at"`
+	Revoked_at		string		`json:"revoked_at"`
+	CreatedAt		string		`json:"created_at"`
+}
+
+type CreateApiKey struct {
+	Name			string		`json:"name"`
+	Scopes			[]string	`json:"scopes"`
+	Expires_at		string		`json:"expires_at" example:"2027-01-01T00:00:00Z"`
+	Created_by		string		`json:"-"`
+	Prefix			string		`json:"-"`
+	Key_hash		string		`json:"-"`
+}
+
+// CreatedApiKey is returned once, on creation; the key cannot be read back.
+type CreatedApiKey struct {
+	Key			string		`json:"key"`
+	Api_key		*ApiKey		`json:"api_key"`
+}
+
+type GetListApiKeyRequest struct {
+	Offset	int		`json:"offset"`
+	Limit	int		`json:"limit"`
+}
+
+type GetListApiKeyResponse struct {
+	Count		int			`json:"count"`
+	Api_keys	[]*ApiKey	`json:"api_keys"`
+}
+
+// ApiKeyAuth is the API key presented with a request and the permissions
+// its scopes grant.
+type ApiKeyAuth struct {
+	Id				string
+	Name			string
+	Expired			bool
+	Revoked			bool
+	Permissions		map[string]string
+}
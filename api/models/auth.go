@@ -0,0 +1,29 @@
+package models
+
+type RegisterRequest struct {
+	Name		string	`json:"name"`
+	Login		string	`json:"login"`
+	Password	string	`json:"password"`
+}
+
+type LoginRequest struct {
+	Login		string	`json:"login"`
+	Password	string	`json:"password"`
+}
+
+type RefreshTokenRequest struct {
+	Refresh_token	string	`json:"refresh_token"`
+}
+
+type TokenResponse struct {
+	Access_token	string	`json:"access_token"`
+	Refresh_token	string	`json:"refresh_token"`
+	Token_type		string	`json:"token_type"`
+	Expires_in		int		`json:"expires_in"`
+}
+
+// UserCredentials is what login needs to verify a password.
+type UserCredentials struct {
+	Id				string
+	Password_hash	string
+}
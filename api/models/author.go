@@ -4,6 +4,7 @@ type Author struct{
 	Id		string	`json:"id"`
 	Name	string	`json:"name"`
 	CreatedAt string  `json:"created_at"`
+	DeletedAt string  `json:"deleted_at"`
 }
 
 
@@ -14,6 +15,8 @@ type Author_GetBook struct{
 
 type AuthorPrimaryKey struct{
 	Id	string	`json:"id"`
+	// Include_deleted also finds a soft deleted record.
+	Include_deleted	bool	`json:"-"`
 }
 
 type CreateAuthor struct {
@@ -29,9 +32,15 @@ type GetListAuthorRequest struct {
 	Offset int    `json:"offset"`
 	Limit  int    `json:"limit"`
 	Search string `json:"search"`
+	Include_deleted	bool	`json:"include_deleted"`
+	Sort	[]SortField	`json:"sort"`
+	// Cursor switches to keyset paging, ignoring Offset and Sort.
+	Cursor	*Cursor	`json:"-"`
 }
 
 type GetListAuthorResponse struct {
 	Count int     `json:"count"`
 	Authors []*Author `json:"author"`
+	Sort	string	`json:"sort"`
+	Next_cursor	string	`json:"next_cursor,omitempty"`
 }
\ No newline at end of file
@@ -11,10 +11,13 @@ type Book struct {
 	Sell_price		float64	`json:"sell_price"` 
 	CreatedAt 		string  `json:"created_at"`
 	UpdatedAt 		string  `json:"updated_at"`
+	DeletedAt		string	`json:"deleted_at"`
 }
 
 type BookPrimaryKey struct {
 	Id string `json:"id"`
+	// Include_deleted also finds a soft deleted record.
+	Include_deleted	bool	`json:"-"`
 }
 
 type CreateBook struct {
@@ -42,9 +45,17 @@ type GetListBookRequest struct {
 	Offset int    `json:"offset"`
 	Limit  int    `json:"limit"`
 	Search string `json:"search"`
+	Min_price	float64	`json:"min_price"`
+	Max_price	float64	`json:"max_price"`
+	Include_deleted	bool	`json:"include_deleted"`
+	Sort	[]SortField	`json:"sort"`
+	// Cursor switches to keyset paging, ignoring Offset and Sort.
+	Cursor	*Cursor	`json:"-"`
 }
 
 type GetListBookResponse struct {
 	Count int     `json:"count"`
 	Books []*Book `json:"books"`
+	Sort	string	`json:"sort"`
+	Next_cursor	string	`json:"next_cursor,omitempty"`
 }
@@ -0,0 +1,56 @@
+package models
+
+type Cart struct {
+	Id        			string  	`json:"id"`
+	Customer_id			string		`json:"customer_id"`
+	Items				[]*CartItem	`json:"items"`
+	Unavailable_items	[]*CartItem	`json:"unavailable_items"`
+	Total_price			float64		`json:"total_price"`
+	CreatedAt 			string  	`json:"created_at"`
+	UpdatedAt 			string  	`json:"updated_at"`
+}
+
+type CartItem struct {
+	Product_id		string	`json:"product_id"`
+	Name			string	`json:"name"`
+	Quantity		int		`json:"quantity"`
+	Price			float64	`json:"price"`
+	Total_price		float64	`json:"total_price"`
+}
+
+type CartPrimaryKey struct {
+	Id string `json:"id"`
+}
+
+type CreateCart struct {
+	Customer_id		string	`json:"customer_id"`
+}
+
+type CartItemPrimaryKey struct {
+	Cart_id			string	`json:"cart_id"`
+	Product_id		string	`json:"product_id"`
+}
+
+type AddCartItem struct {
+	Cart_id			string	`json:"cart_id"`
+	Product_id		string	`json:"product_id"`
+	Quantity		int		`json:"quantity"`
+}
+
+type UpdateCartItem struct {
+	Cart_id			string	`json:"cart_id"`
+	Product_id		string	`json:"product_id"`
+	Quantity		int		`json:"quantity"`
+}
+
+type CheckoutCart struct {
+	Cart_id			string	`json:"cart_id"`
+	Name      		string  `json:"name"`
+	Phone_number	string	`json:"phone_number"`
+	Address_id		string	`json:"address_id"`
+	Latitude		float64	`json:"latitude"`
+	Longtitude		float64	`json:"longtitude"`
+	User_id			string	`json:"user_id"`
+	Courier_id		string	`json:"courier_id"`
+	Api_key_id		string	`json:"-"`
+}
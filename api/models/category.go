@@ -3,10 +3,14 @@ package models
 type Category struct {
 	Id        	string  `json:"id"`
 	Name      	string  `json:"name"`
+	CreatedAt	string	`json:"created_at"`
+	DeletedAt	string	`json:"deleted_at"`
 }
 
 type CategoryPrimaryKey struct {
 	Id string `json:"id"`
+	// Include_deleted also finds a soft deleted record.
+	Include_deleted	bool	`json:"-"`
 }
 
 type CreateCategory struct {
@@ -22,9 +26,16 @@ type GetListCatogoryRequest struct {
 	Offset int    `json:"offset"`
 	Limit  int    `json:"limit"`
 	Search string `json:"search"`
+	Include_deleted	bool	`json:"include_deleted"`
+	Sort	[]SortField	`json:"sort"`
+	// Cursor switches to keyset paging, ignoring Offset and Sort.
+	Cursor	*Cursor	`json:"-"`
 }
 
 type GetListCategoryResponse struct {
 	Count 		int     		`json:"count"`
 	Categories 	[]*Category 	`json:"categories"`
+	Sort	string	`json:"sort"`
+	// Next_cursor continues a keyset page; it is empty on the last page.
+	Next_cursor	string	`json:"next_cursor,omitempty"`
 }
\ No newline at end of file
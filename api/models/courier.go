@@ -4,32 +4,78 @@ type Courier struct {
 	Id        		string  `json:"id"`
 	Name      		string  `json:"name"`
 	Phone_number    string 	`json:"phone_number"`
+	Latitude		*float64	`json:"latitude"`
+	Longitude		*float64	`json:"longitude"`
+	Location_updated_at	string	`json:"location_updated_at"`
+	Is_available	bool	`json:"is_available"`
 	CreatedAt 		string  `json:"created_at"`
 	UpdatedAt 		string  `json:"updated_at"`
+	DeletedAt	string	`json:"deleted_at"`
 }
 
 type CourierPrimaryKey struct {
 	Id string `json:"id"`
+	// Include_deleted also finds a soft deleted record.
+	Include_deleted	bool	`json:"-"`
 }
 
 type CreateCourier struct {
 	Name  	 		string  	`json:"name"`
 	Phone_number    string 	`json:"phone_number"`
+	Latitude		*float64	`json:"latitude"`
+	Longitude		*float64	`json:"longitude"`
+	Is_available	*bool		`json:"is_available"`
 }
 
 type UpdateCourier struct {
 	Id     			string  	`json:"id"`
 	Name  	 		string  	`json:"name"`
 	Phone_number    string 	`json:"phone_number"`
+	Latitude		*float64	`json:"latitude"`
+	Longitude		*float64	`json:"longitude"`
+	Is_available	*bool		`json:"is_available"`
 }
 
 type GetListCourierRequest struct {
 	Offset int    `json:"offset"`
 	Limit  int    `json:"limit"`
 	Search string `json:"search"`
+	Is_available	*bool	`json:"is_available"`
+	Include_deleted	bool	`json:"include_deleted"`
+	Sort	[]SortField	`json:"sort"`
+	// Cursor switches to keyset paging, ignoring Offset and Sort.
+	Cursor	*Cursor	`json:"-"`
 }
 
 type GetListCourierResponse struct {
 	Count 		int     	`json:"count"`
 	Couriers 	[]*Courier 	`json:"courier"`
-}
\ No newline at end of file
+	Sort	string	`json:"sort"`
+	Next_cursor	string	`json:"next_cursor,omitempty"`
+}
+
+type CourierLocation struct {
+	Courier_id		string	`json:"courier_id"`
+	Latitude		float64	`json:"latitude"`
+	Longitude		float64	`json:"longitude"`
+	Recorded_at		string	`json:"recorded_at"`
+}
+
+type CreateCourierLocation struct {
+	Courier_id		string	`json:"courier_id"`
+	Latitude		float64	`json:"latitude"`
+	Longitude		float64	`json:"longitude"`
+	Recorded_at		string	`json:"recorded_at"`
+}
+
+type GetCourierTrackRequest struct {
+	Courier_id	string	`json:"courier_id"`
+	From		string	`json:"from"`
+	To			string	`json:"to"`
+	Limit		int		`json:"limit"`
+}
+
+type GetCourierTrackResponse struct {
+	Count 		int     			`json:"count"`
+	Points 		[]*CourierLocation 	`json:"points"`
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"app/pkg/helper"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ErrInvalidCursor is returned for a cursor that was not issued by the API.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor marks a position in a list ordered by newest first. The zero Cursor
+// is the start of the list.
+type Cursor struct {
+	Created_at	time.Time	`json:"t"`
+	Id			string		`json:"id"`
+}
+
+// IsZero reports whether c is the start of the list.
+func (c *Cursor) IsZero() bool {
+	return len(c.Id) <= 0
+}
+
+// String encodes c as the opaque value sent to clients.
+func (c *Cursor) String() string {
+
+	data, _ := json.Marshal(c)
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// ParseCursor decodes a cursor sent by a client. An empty value is the start
+// of the list.
+func ParseCursor(value string) (*Cursor, error) {
+
+	cursor := &Cursor{}
+
+	if len(value) <= 0 {
+		return cursor, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	err = json.Unmarshal(data, cursor)
+	if err != nil || !helper.IsValidUUID(cursor.Id) || cursor.Created_at.IsZero() {
+		return nil, ErrInvalidCursor
+	}
+
+	return cursor, nil
+}
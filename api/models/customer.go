@@ -4,12 +4,16 @@ type Customer struct {
 	Id        	string  `json:"id"`
 	Name      	string  `json:"name"`
 	Phone     	string 	`json:"phone"`
+	Phone_verified_at	string	`json:"phone_verified_at"`
 	CreatedAt 	string  `json:"created_at"`
 	UpdatedAt 	string  `json:"updated_at"`
+	DeletedAt	string	`json:"deleted_at"`
 }
 
 type CustomerPrimaryKey struct {
 	Id string `json:"id"`
+	// Include_deleted also finds a soft deleted record.
+	Include_deleted	bool	`json:"-"`
 }
 
 type CreateCustomer struct {
@@ -27,9 +31,15 @@ type GetListCustomerRequest struct {
 	Offset int    `json:"offset"`
 	Limit  int    `json:"limit"`
 	Search string `json:"search"`
+	Include_deleted	bool	`json:"include_deleted"`
+	Sort	[]SortField	`json:"sort"`
+	// Cursor switches to keyset paging, ignoring Offset and Sort.
+	Cursor	*Cursor	`json:"-"`
 }
 
 type GetListCustomerResponse struct {
 	Count 		int     	`json:"count"`
 	Customers 	[]*Customer `json:"customers"`
+	Sort	string	`json:"sort"`
+	Next_cursor	string	`json:"next_cursor,omitempty"`
 }
\ No newline at end of file
@@ -0,0 +1,49 @@
+package models
+
+type CustomerAddress struct {
+	Id				string	`json:"id"`
+	Customer_id		string	`json:"customer_id"`
+	Label			string	`json:"label"`
+	Street			string	`json:"street"`
+	Apartment		string	`json:"apartment"`
+	Latitude		float64	`json:"latitude"`
+	Longitude		float64	`json:"longitude"`
+	Is_default		bool	`json:"is_default"`
+	CreatedAt		string	`json:"created_at"`
+	UpdatedAt		string	`json:"updated_at"`
+}
+
+type CustomerAddressPrimaryKey struct {
+	Id				string	`json:"id"`
+	Customer_id		string	`json:"customer_id"`
+}
+
+type CreateCustomerAddress struct {
+	Customer_id		string	`json:"customer_id"`
+	Label			string	`json:"label"`
+	Street			string	`json:"street"`
+	Apartment		string	`json:"apartment"`
+	Latitude		float64	`json:"latitude"`
+	Longitude		float64	`json:"longitude"`
+	Is_default		bool	`json:"is_default"`
+}
+
+type UpdateCustomerAddress struct {
+	Id				string	`json:"id"`
+	Customer_id		string	`json:"customer_id"`
+	Label			string	`json:"label"`
+	Street			string	`json:"street"`
+	Apartment		string	`json:"apartment"`
+	Latitude		float64	`json:"latitude"`
+	Longitude		float64	`json:"longitude"`
+	Is_default		bool	`json:"is_default"`
+}
+
+type GetListCustomerAddressRequest struct {
+	Customer_id		string	`json:"customer_id"`
+}
+
+type GetListCustomerAddressResponse struct {
+	Count		int					`json:"count"`
+	Addresses	[]*CustomerAddress	`json:"addresses"`
+}
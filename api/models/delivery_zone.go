@@ -0,0 +1,73 @@
+package models
+
+// GeoJSONPolygon is a GeoJSON Polygon geometry. Positions are
+// [longitude, latitude] pairs and every ring must be closed.
+type GeoJSONPolygon struct {
+	Type		string			`json:"type" example:"Polygon"`
+	Coordinates	[][][]float64	`json:"coordinates"`
+}
+
+type DeliveryZone struct {
+	Id				string			`json:"id"`
+	Name			string			`json:"name"`
+	Area			GeoJSONPolygon	`json:"area"`
+	Delivery_fee	*float64		`json:"delivery_fee"`
+	Min_order		float64			`json:"min_order"`
+	Opens_at		string			`json:"opens_at"`
+	Closes_at		string			`json:"closes_at"`
+	Is_active		bool			`json:"is_active"`
+	CreatedAt		string			`json:"created_at"`
+	UpdatedAt		string			`json:"updated_at"`
+}
+
+type DeliveryZonePrimaryKey struct {
+	Id string `json:"id"`
+}
+
+type CreateDeliveryZone struct {
+	Name			string			`json:"name"`
+	Area			GeoJSONPolygon	`json:"area"`
+	Delivery_fee	*float64		`json:"delivery_fee"`
+	Min_order		float64			`json:"min_order"`
+	Opens_at		string			`json:"opens_at" example:"09:00"`
+	Closes_at		string			`json:"closes_at" example:"23:00"`
+	Is_active		*bool			`json:"is_active"`
+}
+
+type UpdateDeliveryZone struct {
+	Id				string			`json:"id"`
+	Name			string			`json:"name"`
+	Area			GeoJSONPolygon	`json:"area"`
+	Delivery_fee	*float64		`json:"delivery_fee"`
+	Min_order		float64			`json:"min_order"`
+	Opens_at		string			`json:"opens_at" example:"09:00"`
+	Closes_at		string			`json:"closes_at" example:"23:00"`
+	Is_active		*bool			`json:"is_active"`
+}
+
+type GetListDeliveryZoneRequest struct {
+	Offset int    `json:"offset"`
+	Limit  int    `json:"limit"`
+	Search string `json:"search"`
+	Is_active	*bool	`json:"is_active"`
+	Sort	[]SortField	`json:"sort"`
+	// Cursor switches to keyset paging, ignoring Offset and Sort.
+	Cursor	*Cursor	`json:"-"`
+}
+
+type GetListDeliveryZoneResponse struct {
+	Count	int				`json:"count"`
+	Zones	[]*DeliveryZone	`json:"zones"`
+	Sort	string	`json:"sort"`
+	Next_cursor	string	`json:"next_cursor,omitempty"`
+}
+
+// DeliveryQuote is the delivery fee for a destination and the zone it falls
+// in, if delivery zones are configured.
+type DeliveryQuote struct {
+	Distance	float64	`json:"distance"`
+	Fee			float64	`json:"fee"`
+	Zone_id		string	`json:"zone_id"`
+	Zone_name	string	`json:"zone_name"`
+	Min_order	float64	`json:"min_order"`
+}
@@ -0,0 +1,17 @@
+package models
+
+// IdempotencyKey identifies a request sent with an Idempotency-Key header.
+// Owner is the user or API key that sent it.
+type IdempotencyKey struct {
+	Owner			string
+	Key				string
+	Method			string
+	Path			string
+	Request_hash	string
+}
+
+// IdempotentResponse is the stored response of a finished request.
+type IdempotentResponse struct {
+	Status_code		int
+	Body			[]byte
+}
@@ -1,71 +1,180 @@
 package models
 
+const (
+	OrderStatusNew			= "new"
+	OrderStatusAccepted		= "accepted"
+	OrderStatusAssigned		= "assigned"
+	OrderStatusPickedUp		= "picked_up"
+	OrderStatusDelivered	= "delivered"
+	OrderStatusCancelled	= "cancelled"
+	OrderStatusReturned		= "returned"
+)
+
+// orderStatusTransitions lists the statuses an order may move to from each status.
+// Cancelled and returned orders are final.
+var orderStatusTransitions = map[string][]string{
+	OrderStatusNew:			{OrderStatusAccepted, OrderStatusCancelled},
+	OrderStatusAccepted:	{OrderStatusAssigned, OrderStatusCancelled},
+	OrderStatusAssigned:	{OrderStatusPickedUp, OrderStatusAccepted, OrderStatusCancelled},
+	OrderStatusPickedUp:	{OrderStatusDelivered, OrderStatusReturned},
+	OrderStatusDelivered:	{OrderStatusReturned},
+	OrderStatusCancelled:	{},
+	OrderStatusReturned:	{},
+}
+
+// IsValidOrderStatus reports whether status is one of the known order statuses.
+func IsValidOrderStatus(status string) bool {
+	_, ok := orderStatusTransitions[status]
+	return ok
+}
+
+// CanTransitionOrderStatus reports whether an order in status from may be moved to status to.
+func CanTransitionOrderStatus(from, to string) bool {
+	for _, next := range orderStatusTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// OrderHoldsReservation reports whether an order in the given status still has stock reserved for it.
+func OrderHoldsReservation(status string) bool {
+
+	switch status {
+	case OrderStatusNew, OrderStatusAccepted, OrderStatusAssigned, OrderStatusPickedUp:
+		return true
+	}
+
+	return false
+}
+
 type Order struct {
 	Id        		string  `json:"id"`
 	Name      		string  `json:"name"`
 	Price    		float64 	`json:"price"`
+	Delivery_fee		float64	`json:"delivery_fee"`
+	Delivery_distance	*float64	`json:"delivery_distance"`
+	Delivery_zone_id	string	`json:"delivery_zone_id"`
 	Phone_number	string	`json:"phone_number"`
-	Latitude		int		`json:"latitude"`
-	Longtitude		int		`json:"longtitude"`
+	Address_id		string	`json:"address_id"`
+	Address_street		string	`json:"address_street"`
+	Address_apartment	string	`json:"address_apartment"`
+	Latitude		float64	`json:"latitude"`
+	Longtitude		float64	`json:"longtitude"`
 	User_id			string	`json:"user_id"`
 	Customer_id		string	`json:"customer_id"`
 	Courier_id		string	`json:"courier_id"`
-	Product_id		string	`json:"product_id"`
-	Quantity		int		`json:"quantity"`
+	Courier_distance	*float64	`json:"courier_distance"`
+	Assignment_mode	string	`json:"assignment_mode"`
+	Assigned_at		string	`json:"assigned_at"`
+	Status			string	`json:"status"`
+	Items			[]*OrderItem	`json:"items"`
 	CreatedAt 		string  `json:"created_at"`
 	UpdatedAt 		string  `json:"updated_at"`
 }
 
+type OrderItem struct {
+	Id				string	`json:"id"`
+	Order_id		string	`json:"order_id"`
+	Product_id		string	`json:"product_id"`
+	Quantity		int		`json:"quantity"`
+	Price			float64	`json:"price"`
+	Total_price		float64	`json:"total_price"`
+}
+
 type OrderPrimaryKey struct {
 	Id string `json:"id"`
 }
 
-type CreateOrderSwagger struct {
-	Name      		string  `json:"name"`
-	Phone_number	string	`json:"phone_number"`
-	Latitude		int		`json:"latitude"`
-	Longtitude		int		`json:"longtitude"`
-	User_id			string	`json:"user_id"`
-	Customer_id		string	`json:"customer_id"`
-	Courier_id		string	`json:"courier_id"`
+type CreateOrderItem struct {
 	Product_id		string	`json:"product_id"`
 	Quantity		int		`json:"quantity"`
 }
 
 type CreateOrder struct {
 	Name      		string  `json:"name"`
-	Price    		float64 	`json:"price"`
 	Phone_number	string	`json:"phone_number"`
-	Latitude		int		`json:"latitude"`
-	Longtitude		int		`json:"longtitude"`
+	Address_id		string	`json:"address_id"`
+	Latitude		float64	`json:"latitude"`
+	Longtitude		float64	`json:"longtitude"`
 	User_id			string	`json:"user_id"`
 	Customer_id		string	`json:"customer_id"`
 	Courier_id		string	`json:"courier_id"`
-	Product_id		string	`json:"product_id"`
-	Quantity		int		`json:"quantity"`
+	Items			[]*CreateOrderItem	`json:"items"`
+	Api_key_id		string	`json:"-"`
 }
 
 type UpdateOrder struct {
 	Id        		string  `json:"id"`
 	Name      		string  `json:"name"`
-	Price    		float64 	`json:"price"`
 	Phone_number	string	`json:"phone_number"`
-	Latitude		int		`json:"latitude"`
-	Longtitude		int		`json:"longtitude"`
+	Address_id		string	`json:"address_id"`
+	Latitude		float64	`json:"latitude"`
+	Longtitude		float64	`json:"longtitude"`
 	User_id			string	`json:"user_id"`
 	Customer_id		string	`json:"customer_id"`
 	Courier_id		string	`json:"courier_id"`
-	Product_id		string	`json:"product_id"`
-	Quantity		int		`json:"quantity"`
+	Items			[]*CreateOrderItem	`json:"items"`
+}
+
+// UpdateOrderStatus is recorded in the status history as changed by the
+// authenticated user, or by the API key the request was sent with.
+type UpdateOrderStatus struct {
+	Id				string	`json:"id"`
+	Status			string	`json:"status"`
+	Changed_by		string	`json:"-"`
+	Reason			string	`json:"reason"`
+	Api_key_id		string	`json:"-"`
+}
+
+type OrderStatusHistory struct {
+	Id				string	`json:"id"`
+	Order_id		string	`json:"order_id"`
+	From_status		string	`json:"from_status"`
+	To_status		string	`json:"to_status"`
+	Changed_by		string	`json:"changed_by"`
+	Api_key_id		string	`json:"api_key_id"`
+	Reason			string	`json:"reason"`
+	CreatedAt 		string  `json:"created_at"`
+}
+
+type GetOrderStatusHistoryResponse struct {
+	Count 	int     				`json:"count"`
+	History	[]*OrderStatusHistory	`json:"history"`
+}
+
+const (
+	AssignmentModeManual	= "manual"
+	AssignmentModeAuto		= "auto"
+)
+
+type OrderCourierEvent struct {
+	Order_id			string		`json:"order_id"`
+	Courier_id			string		`json:"courier_id"`
+	Courier_distance	*float64	`json:"courier_distance"`
+	Assignment_mode		string		`json:"assignment_mode"`
 }
 
 type GetListOrderRequest struct {
 	Offset int    `json:"offset"`
 	Limit  int    `json:"limit"`
 	Search string `json:"search"`
+	Customer_id	string	`json:"customer_id"`
+	Courier_id	string	`json:"courier_id"`
+	Status		string	`json:"status"`
+	Created_from	string	`json:"created_from"`
+	Created_to		string	`json:"created_to"`
+	Min_price	float64	`json:"min_price"`
+	Max_price	float64	`json:"max_price"`
+	Sort	[]SortField	`json:"sort"`
+	// Cursor switches to keyset paging, ignoring Offset and Sort.
+	Cursor	*Cursor	`json:"-"`
 }
 
 type GetListOrderResponse struct {
 	Count 	int     	`json:"count"`
 	Orders	[]*Order	`json:"orders"`
-}
\ No newline at end of file
+	Sort	string	`json:"sort"`
+	Next_cursor	string	`json:"next_cursor,omitempty"`
+}
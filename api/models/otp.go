@@ -0,0 +1,27 @@
+package models
+
+type RequestOtp struct {
+	Phone		string	`json:"phone" example:"+998901234567"`
+}
+
+type OtpSent struct {
+	Phone		string	`json:"phone"`
+	Expires_in	int		`json:"expires_in"`
+}
+
+type VerifyOtp struct {
+	Phone		string	`json:"phone" example:"+998901234567"`
+	Code		string	`json:"code"`
+}
+
+type CreateCustomerOtp struct {
+	Phone		string
+	Code_hash	string
+}
+
+// OtpLogin is the customer whose phone a one-time code verified and the user
+// account they log in as.
+type OtpLogin struct {
+	Customer_id		string
+	User_id			string
+}
@@ -1,6 +1,39 @@
 package models
 
+import (
+	"fmt"
+	"strings"
+)
+
 type PatchRequest struct {
 	ID     string `json:"id"`
 	Fields map[string]interface{}
-}
\ No newline at end of file
+}
+
+// OrderPatchFields are the order columns PATCH /order/{id} may set. Status,
+// prices, delivery, address and courier are derived from them or have their
+// own endpoints.
+var OrderPatchFields = []string{"name", "phone_number", "latitude", "longtitude", "user_id", "customer_id"}
+
+// CheckPatchFields rejects a patch setting any field outside allowed. Names
+// must match exactly, so they can be trusted as column names.
+func CheckPatchFields(fields map[string]interface{}, allowed []string) error {
+
+	for key := range fields {
+
+		found := false
+
+		for _, field := range allowed {
+			if key == field {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return fmt.Errorf("%s cannot be patched, allowed fields: %s", key, strings.Join(allowed, ", "))
+		}
+	}
+
+	return nil
+}
@@ -5,18 +5,24 @@ type Product struct {
 	Name      	string  `json:"name"`
 	Price    	string 	`json:"price"`
 	Category_id	string	`json:"category_id"`
+	Stock		int		`json:"stock"`
+	Reserved	int		`json:"reserved"`
 	CreatedAt 	string  `json:"created_at"`
 	UpdatedAt 	string  `json:"updated_at"`
+	DeletedAt	string	`json:"deleted_at"`
 }
 
 type ProductPrimaryKey struct {
 	Id string `json:"id"`
+	// Include_deleted also finds a soft deleted record.
+	Include_deleted	bool	`json:"-"`
 }
 
 type CreateProduct struct {
 	Name  	 	string  	`json:"name"`
 	Price    	float64 	`json:"price"`
 	Category_id	string		`json:"category_id"`
+	Stock		int			`json:"stock"`
 }
 
 type UpdateProduct struct {
@@ -24,15 +30,31 @@ type UpdateProduct struct {
 	Name  	 	string  	`json:"name"`
 	Price    	float64 	`json:"price"`
 	Category_id	string			`json:"category_id"`
+	Stock		int				`json:"stock"`
 }
 
 type GetListProductRequest struct {
 	Offset int    `json:"offset"`
 	Limit  int    `json:"limit"`
 	Search string `json:"search"`
+	Category_id	string	`json:"category_id"`
+	Min_price	float64	`json:"min_price"`
+	Max_price	float64	`json:"max_price"`
+	Include_deleted	bool	`json:"include_deleted"`
+	Sort	[]SortField	`json:"sort"`
+	// Cursor switches to keyset paging, ignoring Offset and Sort.
+	Cursor	*Cursor	`json:"-"`
 }
 
 type GetListProductResponse struct {
 	Count 		int     	`json:"count"`
 	Products 	[]*Product 	`json:"product"`
-}
\ No newline at end of file
+	Sort	string	`json:"sort"`
+	Next_cursor	string	`json:"next_cursor,omitempty"`
+}
+
+type StockShortage struct {
+	Product_id	string	`json:"product_id"`
+	Requested	int		`json:"requested"`
+	Available	int		`json:"available"`
+}
@@ -0,0 +1,41 @@
+package models
+
+const (
+	PermissionScopeAll	= "all"
+	PermissionScopeOwn	= "own"
+)
+
+type Role struct {
+	Name			string				`json:"name"`
+	Description		string				`json:"description"`
+	Permissions		[]*RolePermission	`json:"permissions"`
+}
+
+type RolePermission struct {
+	Permission		string	`json:"permission"`
+	Scope			string	`json:"scope"`
+}
+
+type GetListRoleResponse struct {
+	Count	int		`json:"count"`
+	Roles	[]*Role	`json:"roles"`
+}
+
+type AssignUserRole struct {
+	User_id			string	`json:"user_id"`
+	Role			string	`json:"role"`
+	Courier_id		string	`json:"courier_id"`
+	Customer_id		string	`json:"customer_id"`
+}
+
+// Principal is an authenticated user with the permissions of their role,
+// keyed by permission code with the granted scope as value. Requests made
+// with an API key have a principal with only Api_key_id and Permissions set.
+type Principal struct {
+	User_id			string				`json:"user_id"`
+	Api_key_id		string				`json:"api_key_id"`
+	Role			string				`json:"role"`
+	Courier_id		string				`json:"courier_id"`
+	Customer_id		string				`json:"customer_id"`
+	Permissions		map[string]string	`json:"permissions"`
+}
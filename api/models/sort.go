@@ -0,0 +1,90 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SortField orders a list by one field, ascending unless Desc is set.
+type SortField struct {
+	Field	string	`json:"field"`
+	Desc	bool	`json:"desc"`
+}
+
+// Fields each list endpoint can be sorted by. They are column names of the
+// listed table.
+var (
+	AuthorSortFields		= []string{"name", "created_at"}
+	BookSortFields			= []string{"name", "price", "count", "sell_price", "profit", "created_at", "updated_at"}
+	CategorySortFields		= []string{"name"}
+	CourierSortFields		= []string{"name", "phone_number", "created_at", "updated_at"}
+	CustomerSortFields		= []string{"name", "phone", "created_at", "updated_at"}
+	DeliveryZoneSortFields	= []string{"name", "delivery_fee", "min_order", "created_at", "updated_at"}
+	OrderSortFields			= []string{"name", "price", "delivery_fee", "status", "created_at", "updated_at"}
+	ProductSortFields		= []string{"name", "price", "stock", "created_at", "updated_at"}
+	UserSortFields			= []string{"name", "login", "balance", "created_at", "updated_at"}
+)
+
+// ParseSort parses a sort parameter such as "price,-created_at": a comma
+// separated list of fields, each descending when prefixed with "-". Every
+// field must be in allowed and appear once.
+func ParseSort(value string, allowed []string) ([]SortField, error) {
+
+	if len(strings.TrimSpace(value)) <= 0 {
+		return nil, nil
+	}
+
+	var (
+		sort	[]SortField
+		seen	= make(map[string]bool)
+	)
+
+	for _, part := range strings.Split(value, ",") {
+
+		field := SortField{Field: strings.TrimSpace(part)}
+
+		if strings.HasPrefix(field.Field, "-") {
+			field.Field, field.Desc = field.Field[1:], true
+		}
+
+		if !contains(allowed, field.Field) {
+			return nil, fmt.Errorf("cannot sort by %q, allowed fields: %s", field.Field, strings.Join(allowed, ", "))
+		}
+
+		if seen[field.Field] {
+			return nil, fmt.Errorf("cannot sort by %q twice", field.Field)
+		}
+		seen[field.Field] = true
+
+		sort = append(sort, field)
+	}
+
+	return sort, nil
+}
+
+// FormatSort is the inverse of ParseSort.
+func FormatSort(sort []SortField) string {
+
+	parts := make([]string, 0, len(sort))
+
+	for _, field := range sort {
+		if field.Desc {
+			parts = append(parts, "-"+field.Field)
+		} else {
+			parts = append(parts, field.Field)
+		}
+	}
+
+	return strings.Join(parts, ",")
+}
+
+func contains(values []string, value string) bool {
+
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -3,33 +3,95 @@ package models
 type User struct {
 	Id        	string  `json:"id"`
 	Name      	string  `json:"name"`
+	Login		string	`json:"login"`
+	Role		string	`json:"role"`
+	Courier_id	string	`json:"courier_id"`
+	Customer_id	string	`json:"customer_id"`
 	Balance     float64 `json:"balance"`
 	CreatedAt 	string  `json:"created_at"`
 	UpdatedAt string  `json:"updated_at"`
+	DeletedAt	string	`json:"deleted_at"`
 }
 
 type UserPrimaryKey struct {
 	Id string `json:"id"`
+	// Include_deleted also finds a soft deleted record.
+	Include_deleted	bool	`json:"-"`
 }
 
 type CreateUser struct {
 	Name  	 string  `json:"name"`
 	Balance  float64 `json:"balance"`
+	Login	 string	 `json:"login"`
+	Password string	 `json:"password"`
+	Password_hash	string	`json:"-"`
 }
 
 type UpdateUser struct {
 	Id     string  `json:"id"`
 	Name   string  `json:"name"`
-	Balance  float64 `json:"balance"`
 }
 
 type GetListUserRequest struct {
 	Offset int    `json:"offset"`
 	Limit  int    `json:"limit"`
 	Search string `json:"search"`
+	Role	string	`json:"role"`
+	Include_deleted	bool	`json:"include_deleted"`
+	Sort	[]SortField	`json:"sort"`
+	// Cursor switches to keyset paging, ignoring Offset and Sort.
+	Cursor	*Cursor	`json:"-"`
 }
 
 type GetListUserResponse struct {
 	Count int     `json:"count"`
 	Users []*User `json:"users"`
-}
\ No newline at end of file
+	Sort	string	`json:"sort"`
+	Next_cursor	string	`json:"next_cursor,omitempty"`
+}
+
+const (
+	BalanceTransactionTopUp			= "top_up"
+	BalanceTransactionOrderDebit	= "order_debit"
+	BalanceTransactionRefund		= "refund"
+	BalanceTransactionAdjustment	= "adjustment"
+)
+
+type BalanceTransaction struct {
+	Id				string	`json:"id"`
+	User_id			string	`json:"user_id"`
+	Type			string	`json:"type"`
+	Amount			float64	`json:"amount"`
+	Balance_after	float64	`json:"balance_after"`
+	Order_id		string	`json:"order_id"`
+	Description		string	`json:"description"`
+	CreatedAt 		string  `json:"created_at"`
+}
+
+type BalanceTransactionPrimaryKey struct {
+	Id string `json:"id"`
+}
+
+type TopUpBalance struct {
+	Amount			float64	`json:"amount"`
+	Description		string	`json:"description"`
+}
+
+type CreateBalanceTransaction struct {
+	User_id			string	`json:"user_id"`
+	Type			string	`json:"type"`
+	Amount			float64	`json:"amount"`
+	Order_id		string	`json:"order_id"`
+	Description		string	`json:"description"`
+}
+
+type GetListBalanceTransactionRequest struct {
+	User_id	string `json:"user_id"`
+	Offset	int    `json:"offset"`
+	Limit	int    `json:"limit"`
+}
+
+type GetListBalanceTransactionResponse struct {
+	Count			int						`json:"count"`
+	Transactions	[]*BalanceTransaction	`json:"transactions"`
+}
@@ -2,12 +2,15 @@ package main
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/gin-gonic/gin"
 
 	"app/api"
 	"app/config"
 	"app/pkg/logger"
+	"app/storage"
+	"app/storage/memory"
 	"app/storage/postgresql"
 )
 
@@ -38,11 +41,36 @@ func main() {
 		}
 	}()
 
-	store, err := postgresql.NewConnectPostgresql(&cfg)
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		err := runMigrate(&cfg, os.Args[2:])
+		if err != nil {
+			log.Fatal("Error migrate: ", logger.Error(err))
+		}
+		return
+	}
+
+	err := cfg.Validate()
 	if err != nil {
-		log.Panic("Error connect to postgresql: ", logger.Error(err))
+		log.Fatal("Invalid config: ", logger.Error(err))
 		return
 	}
+
+	var store storage.StorageI
+
+	switch cfg.StorageDriver {
+	case "memory":
+		store, err = memory.NewStore(&cfg)
+		if err != nil {
+			log.Panic("Error create memory storage: ", logger.Error(err))
+			return
+		}
+	default:
+		store, err = postgresql.NewConnectPostgresql(&cfg)
+		if err != nil {
+			log.Panic("Error connect to postgresql: ", logger.Error(err))
+			return
+		}
+	}
 	defer store.CloseDB()
 
 	r := gin.New()
@@ -50,6 +78,7 @@ func main() {
 	r.Use(gin.Recovery(), gin.Logger())
 
 	api.NewApi(r, &cfg, store, log)
+	api.NewApiAuth(r, &cfg, store, log)
 	api.NewApiUser(r, &cfg, store, log)
 	api.NewApiAuthor(r, &cfg, store, log)
 	api.NewApiCustomer(r, &cfg, store, log)
@@ -57,6 +86,10 @@ func main() {
 	api.NewApiProduct(r, &cfg, store, log)
 	api.NewApiCategory(r, &cfg, store, log)
 	api.NewApiOrder(r, &cfg, store, log)
+	api.NewApiCart(r, &cfg, store, log)
+	api.NewApiDelivery(r, &cfg, store, log)
+	api.NewApiDeliveryZone(r, &cfg, store, log)
+	api.NewApiApiKey(r, &cfg, store, log)
 
 	fmt.Println("Listening Server", cfg.ServerHost+cfg.ServerPort)
 	err = r.Run(cfg.ServerHost + cfg.ServerPort)
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"app/config"
+	"app/migrations"
+	"app/storage/postgresql"
+)
+
+const migrateUsage = "usage: migrate up | down | status | goto <version>"
+
+// runMigrate runs the migrate subcommand against the configured PostgreSQL
+// database with the migrations embedded in the binary.
+func runMigrate(cfg *config.Config, args []string) error {
+
+	if len(args) <= 0 {
+		return errors.New(migrateUsage)
+	}
+
+	switch {
+	case args[0] == "goto" && len(args) == 2:
+	case (args[0] == "up" || args[0] == "down" || args[0] == "status") && len(args) == 1:
+	default:
+		return errors.New(migrateUsage)
+	}
+
+	pool, err := postgresql.NewPool(cfg)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	migrator, err := postgresql.NewMigrator(pool, migrations.Postgres())
+	if err != nil {
+		return err
+	}
+
+	var (
+		ctx  = context.Background()
+		done []postgresql.Migration
+	)
+
+	switch args[0] {
+	case "up":
+		done, err = migrator.Up(ctx)
+	case "down":
+		done, err = migrator.Down(ctx)
+	case "goto":
+		version, convErr := strconv.Atoi(args[1])
+		if convErr != nil {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		done, err = migrator.Goto(ctx, version)
+	case "status":
+		return printMigrationStatus(ctx, migrator)
+	}
+
+	for _, migration := range done {
+		fmt.Printf("%02d_%s\n", migration.Version, migration.Name)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if len(done) <= 0 {
+		fmt.Println("no change")
+	}
+
+	return nil
+}
+
+func printMigrationStatus(ctx context.Context, migrator *postgresql.Migrator) error {
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+
+	for _, status := range statuses {
+
+		appliedAt := "pending"
+		if !status.AppliedAt.IsZero() {
+			appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+
+		fmt.Fprintf(w, "%02d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+	}
+
+	return w.Flush()
+}
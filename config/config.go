@@ -1,9 +1,12 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
+	"app/pkg/delivery"
+
 	"github.com/joho/godotenv"
 	"github.com/spf13/cast"
 )
@@ -23,16 +26,83 @@ type Config struct {
 	ServerHost string
 	ServerPort string
 
+	// StorageDriver selects the storage backend: "postgres" or "memory".
+	// The memory driver keeps everything in process and loses it on exit.
+	StorageDriver string
+
 	PostgresHost     string
 	PostgresUser     string
 	PostgresDatabase string
 	PostgresPassword string
 	PostgresPort     string
 
+	// MigrateOnStart applies pending migrations when the server connects to
+	// PostgreSQL.
+	MigrateOnStart bool
+
+	// MemoryAdminLogin and MemoryAdminPassword, when set, create an
+	// administrator on start with the memory driver.
+	MemoryAdminLogin    string
+	MemoryAdminPassword string
+
 	DefaultOffset int
 	DefaultLimit  int
+
+	// CourierMaxActiveOrders is the number of open orders a courier may carry
+	// before automatic assignment skips them.
+	CourierMaxActiveOrders int
+	// CourierLocationTTL is how old, in minutes, the last known location of a
+	// courier may be for them to be considered by automatic assignment.
+	CourierLocationTTL int
+
+	// EventBufferSize is how many events a live subscriber may lag behind
+	// before it is disconnected.
+	EventBufferSize int
+	// StreamHeartbeat is the interval, in seconds, between keep-alive
+	// messages on event streams.
+	StreamHeartbeat int
+
+	// StoreLatitude and StoreLongitude locate the store deliveries start from.
+	StoreLatitude  float64
+	StoreLongitude float64
+	// DeliveryTiers prices deliveries by distance from the store.
+	DeliveryTiers []delivery.Tier
+
+	// JWTSecret signs access and refresh tokens. It has no default, so
+	// tokens cannot be forged with a secret published in the source.
+	JWTSecret string
+	// AccessTokenTTL is the lifetime of access tokens in minutes.
+	AccessTokenTTL int
+	// RefreshTokenTTL is the lifetime of refresh tokens in hours.
+	RefreshTokenTTL int
+
+	// SmsDriver selects how text messages are delivered: "log" or "file".
+	SmsDriver string
+	// SmsFilePath is where the file driver appends messages.
+	SmsFilePath string
+
+	// OtpLength is the number of digits in a one-time code.
+	OtpLength int
+	// OtpTTL is how long, in minutes, a one-time code stays valid.
+	OtpTTL int
+	// OtpMaxAttempts is how many wrong guesses invalidate a one-time code.
+	OtpMaxAttempts int
+	// OtpResendInterval is the minimum time, in seconds, between codes sent
+	// to the same phone.
+	OtpResendInterval int
+
+	// IdempotencyTTL is how long, in hours, responses to requests sent with an
+	// Idempotency-Key are kept for replay.
+	IdempotencyTTL int
+
+	// TxMaxAttempts is how many times a storage transaction is run before a
+	// serialization failure is returned to the caller.
+	TxMaxAttempts int
 }
 
+// defaultDeliveryTiers is used when DELIVERY_TIERS is unset or invalid.
+const defaultDeliveryTiers = "3:5000,7:10000,15:15000"
+
 func Load() Config {
 
 	if err := godotenv.Load("./app.env"); err != nil {
@@ -44,18 +114,71 @@ func Load() Config {
 	cfg.ServerHost = cast.ToString(getOrReturnDefaultValue("SERVICE_HOST", "localhost"))
 	cfg.ServerPort = cast.ToString(getOrReturnDefaultValue("HTTP_PORT", ":8001"))
 
+	cfg.StorageDriver = cast.ToString(getOrReturnDefaultValue("STORAGE_DRIVER", "postgres"))
+
 	cfg.PostgresHost = cast.ToString(getOrReturnDefaultValue("POSTGRES_HOST", "localhost"))
 	cfg.PostgresPort = cast.ToString(getOrReturnDefaultValue("POSTGRES_PORT", 5432))
 	cfg.PostgresUser = cast.ToString(getOrReturnDefaultValue("POSTGRES_USER", "alee"))
 	cfg.PostgresPassword = cast.ToString(getOrReturnDefaultValue("POSTGRES_PASSWORD", "12345"))
 	cfg.PostgresDatabase = cast.ToString(getOrReturnDefaultValue("POSTGRES_DATABASE", "shopcart"))
 
+	cfg.MigrateOnStart = cast.ToBool(getOrReturnDefaultValue("MIGRATE_ON_START", false))
+
+	cfg.MemoryAdminLogin = cast.ToString(getOrReturnDefaultValue("MEMORY_ADMIN_LOGIN", ""))
+	cfg.MemoryAdminPassword = cast.ToString(getOrReturnDefaultValue("MEMORY_ADMIN_PASSWORD", ""))
+
 	cfg.DefaultOffset = cast.ToInt(getOrReturnDefaultValue("OFFSET", 0))
 	cfg.DefaultLimit = cast.ToInt(getOrReturnDefaultValue("LIMIT", 10))
 
+	cfg.CourierMaxActiveOrders = cast.ToInt(getOrReturnDefaultValue("COURIER_MAX_ACTIVE_ORDERS", 3))
+	cfg.CourierLocationTTL = cast.ToInt(getOrReturnDefaultValue("COURIER_LOCATION_TTL", 30))
+
+	cfg.EventBufferSize = cast.ToInt(getOrReturnDefaultValue("EVENT_BUFFER_SIZE", 64))
+	cfg.StreamHeartbeat = cast.ToInt(getOrReturnDefaultValue("STREAM_HEARTBEAT", 15))
+
+	cfg.StoreLatitude = cast.ToFloat64(getOrReturnDefaultValue("STORE_LATITUDE", 41.311081))
+	cfg.StoreLongitude = cast.ToFloat64(getOrReturnDefaultValue("STORE_LONGITUDE", 69.240562))
+
+	tiers, err := delivery.ParseTiers(cast.ToString(getOrReturnDefaultValue("DELIVERY_TIERS", defaultDeliveryTiers)))
+	if err != nil {
+		fmt.Println("Invalid DELIVERY_TIERS, using defaults:", err)
+		tiers, _ = delivery.ParseTiers(defaultDeliveryTiers)
+	}
+	cfg.DeliveryTiers = tiers
+
+	cfg.JWTSecret = cast.ToString(getOrReturnDefaultValue("JWT_SECRET", ""))
+	cfg.AccessTokenTTL = cast.ToInt(getOrReturnDefaultValue("ACCESS_TOKEN_TTL", 15))
+	cfg.RefreshTokenTTL = cast.ToInt(getOrReturnDefaultValue("REFRESH_TOKEN_TTL", 720))
+
+	cfg.SmsDriver = cast.ToString(getOrReturnDefaultValue("SMS_DRIVER", "log"))
+	cfg.SmsFilePath = cast.ToString(getOrReturnDefaultValue("SMS_FILE_PATH", "sms.log"))
+
+	cfg.OtpLength = cast.ToInt(getOrReturnDefaultValue("OTP_LENGTH", 6))
+	cfg.OtpTTL = cast.ToInt(getOrReturnDefaultValue("OTP_TTL", 5))
+	cfg.OtpMaxAttempts = cast.ToInt(getOrReturnDefaultValue("OTP_MAX_ATTEMPTS", 5))
+	cfg.OtpResendInterval = cast.ToInt(getOrReturnDefaultValue("OTP_RESEND_INTERVAL", 60))
+
+	cfg.IdempotencyTTL = cast.ToInt(getOrReturnDefaultValue("IDEMPOTENCY_TTL", 24))
+
+	cfg.TxMaxAttempts = cast.ToInt(getOrReturnDefaultValue("TX_MAX_ATTEMPTS", 3))
+
 	return cfg
 }
 
+// Validate reports a setting the server cannot run with.
+func (c *Config) Validate() error {
+
+	if len(c.JWTSecret) <= 0 {
+		return errors.New("JWT_SECRET must be set to sign access tokens")
+	}
+
+	if c.StreamHeartbeat <= 0 {
+		return errors.New("STREAM_HEARTBEAT must be a positive number of seconds")
+	}
+
+	return nil
+}
+
 func getOrReturnDefaultValue(key string, defaultValue interface{}) interface{} {
 	val, exists := os.LookupEnv(key)
 
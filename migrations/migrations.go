@@ -0,0 +1,19 @@
+// Package migrations embeds the SQL migrations of the schema into the binary.
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed postgres/*.sql
+var files embed.FS
+
+// Postgres returns the PostgreSQL migrations, as pairs of
+// <version>_<name>.up.sql and <version>_<name>.down.sql files.
+func Postgres() fs.FS {
+
+	postgres, _ := fs.Sub(files, "postgres")
+
+	return postgres
+}
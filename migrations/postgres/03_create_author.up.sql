@@ -2,4 +2,4 @@ CREATE TABLE "author" (
     "id" UUID PRIMARY KEY,
     "name" VARCHAR NOT NULL,
     "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-)
\ No newline at end of file
+);
@@ -1,4 +1,4 @@
 CREATE TABLE categories(
     "id" UUID PRIMARY KEY,
     "name" VARCHAR NOT NULL
-) 
\ No newline at end of file
+);
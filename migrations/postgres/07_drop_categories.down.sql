@@ -1 +0,0 @@
-DROP TABLE IF EXISTS "categories"
\ No newline at end of file
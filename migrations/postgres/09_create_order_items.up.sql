@@ -0,0 +1,24 @@
+CREATE TABLE "order_items" (
+    "id" UUID PRIMARY KEY,
+    "order_id" UUID NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    "product_id" UUID NOT NULL REFERENCES products (id),
+    "quantity" INTEGER NOT NULL CHECK ("quantity" > 0),
+    "price" NUMERIC NOT NULL,
+    "total_price" NUMERIC NOT NULL,
+    "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX "order_items_order_id_idx" ON "order_items" ("order_id");
+
+INSERT INTO "order_items" ("id", "order_id", "product_id", "quantity", "price", "total_price")
+SELECT
+    md5(random()::TEXT || "id"::TEXT)::UUID,
+    "id",
+    "product_id",
+    GREATEST(COALESCE("quantity", 1), 1),
+    COALESCE("price", 0) / GREATEST(COALESCE("quantity", 1), 1),
+    COALESCE("price", 0)
+FROM "orders"
+WHERE "product_id" IS NOT NULL;
+
+ALTER TABLE "orders" DROP COLUMN "product_id", DROP COLUMN "quantity";
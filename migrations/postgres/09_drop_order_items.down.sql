@@ -0,0 +1,15 @@
+ALTER TABLE "orders"
+    ADD COLUMN "product_id" UUID REFERENCES products (id),
+    ADD COLUMN "quantity" NUMERIC;
+
+UPDATE "orders" o SET
+    "product_id" = i."product_id",
+    "quantity" = i."quantity"
+FROM (
+    SELECT DISTINCT ON ("order_id") "order_id", "product_id", "quantity"
+    FROM "order_items"
+    ORDER BY "order_id", "created_at"
+) i
+WHERE i."order_id" = o."id";
+
+DROP TABLE IF EXISTS "order_items";
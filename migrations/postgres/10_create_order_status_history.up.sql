@@ -0,0 +1,14 @@
+ALTER TABLE "orders" ADD COLUMN "status" VARCHAR NOT NULL DEFAULT 'new'
+    CHECK ("status" IN ('new', 'accepted', 'assigned', 'picked_up', 'delivered', 'cancelled', 'returned'));
+
+CREATE TABLE "order_status_history" (
+    "id" UUID PRIMARY KEY,
+    "order_id" UUID NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    "from_status" VARCHAR,
+    "to_status" VARCHAR NOT NULL,
+    "changed_by" UUID REFERENCES users (id),
+    "reason" VARCHAR,
+    "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX "order_status_history_order_id_idx" ON "order_status_history" ("order_id");
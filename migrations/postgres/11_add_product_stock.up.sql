@@ -0,0 +1,4 @@
+ALTER TABLE "products"
+    ADD COLUMN "stock" INTEGER NOT NULL DEFAULT 0 CHECK ("stock" >= 0),
+    ADD COLUMN "reserved" INTEGER NOT NULL DEFAULT 0 CHECK ("reserved" >= 0),
+    ADD CONSTRAINT "products_reserved_within_stock" CHECK ("reserved" <= "stock");
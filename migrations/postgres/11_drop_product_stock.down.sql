@@ -0,0 +1,4 @@
+ALTER TABLE "products"
+    DROP CONSTRAINT IF EXISTS "products_reserved_within_stock",
+    DROP COLUMN IF EXISTS "reserved",
+    DROP COLUMN IF EXISTS "stock";
@@ -0,0 +1,31 @@
+UPDATE "users" SET "balance" = 0 WHERE "balance" IS NULL;
+
+ALTER TABLE "users"
+    ALTER COLUMN "balance" SET DEFAULT 0,
+    ALTER COLUMN "balance" SET NOT NULL,
+    ADD CONSTRAINT "users_balance_non_negative" CHECK ("balance" >= 0);
+
+CREATE TABLE "balance_transactions" (
+    "id" UUID PRIMARY KEY,
+    "user_id" UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    "type" VARCHAR NOT NULL CHECK ("type" IN ('top_up', 'order_debit', 'refund', 'adjustment')),
+    "amount" NUMERIC NOT NULL,
+    "balance_after" NUMERIC NOT NULL,
+    "order_id" UUID REFERENCES orders (id) ON DELETE SET NULL,
+    "description" VARCHAR,
+    "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX "balance_transactions_user_id_idx" ON "balance_transactions" ("user_id", "created_at");
+CREATE INDEX "balance_transactions_order_id_idx" ON "balance_transactions" ("order_id");
+
+INSERT INTO "balance_transactions" ("id", "user_id", "type", "amount", "balance_after", "description")
+SELECT
+    md5(random()::TEXT || "id"::TEXT)::UUID,
+    "id",
+    'adjustment',
+    "balance",
+    "balance",
+    'opening balance'
+FROM "users"
+WHERE "balance" <> 0;
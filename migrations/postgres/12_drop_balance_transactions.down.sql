@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS "balance_transactions";
+
+ALTER TABLE "users"
+    DROP CONSTRAINT IF EXISTS "users_balance_non_negative",
+    ALTER COLUMN "balance" DROP NOT NULL,
+    ALTER COLUMN "balance" SET DEFAULT NULL;
@@ -0,0 +1,15 @@
+ALTER TABLE "courier"
+    ADD COLUMN "latitude" NUMERIC,
+    ADD COLUMN "longitude" NUMERIC,
+    ADD COLUMN "location_updated_at" TIMESTAMP,
+    ADD COLUMN "is_available" BOOLEAN NOT NULL DEFAULT TRUE;
+
+ALTER TABLE "orders"
+    ALTER COLUMN "courier_id" DROP NOT NULL,
+    ADD COLUMN "courier_distance" NUMERIC,
+    ADD COLUMN "assignment_mode" VARCHAR CHECK ("assignment_mode" IN ('manual', 'auto')),
+    ADD COLUMN "assigned_at" TIMESTAMP;
+
+UPDATE "orders" SET "assignment_mode" = 'manual', "assigned_at" = "created_at" WHERE "courier_id" IS NOT NULL;
+
+CREATE INDEX "orders_courier_id_status_idx" ON "orders" ("courier_id", "status");
@@ -0,0 +1,14 @@
+DROP INDEX IF EXISTS "orders_courier_id_status_idx";
+
+-- Orders still waiting for a courier must be assigned before rolling back.
+ALTER TABLE "orders"
+    DROP COLUMN IF EXISTS "assigned_at",
+    DROP COLUMN IF EXISTS "assignment_mode",
+    DROP COLUMN IF EXISTS "courier_distance",
+    ALTER COLUMN "courier_id" SET NOT NULL;
+
+ALTER TABLE "courier"
+    DROP COLUMN IF EXISTS "is_available",
+    DROP COLUMN IF EXISTS "location_updated_at",
+    DROP COLUMN IF EXISTS "longitude",
+    DROP COLUMN IF EXISTS "latitude";
@@ -0,0 +1,9 @@
+CREATE TABLE "courier_locations" (
+    "id" BIGSERIAL PRIMARY KEY,
+    "courier_id" UUID NOT NULL REFERENCES courier (id) ON DELETE CASCADE,
+    "latitude" DOUBLE PRECISION NOT NULL,
+    "longitude" DOUBLE PRECISION NOT NULL,
+    "recorded_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX "courier_locations_courier_id_recorded_at_idx" ON "courier_locations" ("courier_id", "recorded_at");
@@ -0,0 +1,17 @@
+CREATE TABLE "carts" (
+    "id" UUID PRIMARY KEY,
+    "customer_id" UUID NOT NULL UNIQUE REFERENCES customers (id) ON DELETE CASCADE,
+    "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" TIMESTAMP
+);
+
+-- product_id deliberately has no foreign key: a product removed from the
+-- catalog stays in the cart so it can be reported to the customer.
+CREATE TABLE "cart_items" (
+    "cart_id" UUID NOT NULL REFERENCES carts (id) ON DELETE CASCADE,
+    "product_id" UUID NOT NULL,
+    "quantity" INTEGER NOT NULL CHECK ("quantity" > 0),
+    "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" TIMESTAMP,
+    PRIMARY KEY ("cart_id", "product_id")
+);
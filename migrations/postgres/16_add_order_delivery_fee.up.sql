@@ -0,0 +1,3 @@
+ALTER TABLE "orders"
+    ADD COLUMN "delivery_distance" NUMERIC,
+    ADD COLUMN "delivery_fee" NUMERIC NOT NULL DEFAULT 0;
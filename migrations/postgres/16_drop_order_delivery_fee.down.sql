@@ -0,0 +1,5 @@
+UPDATE "orders" SET "price" = "price" - "delivery_fee";
+
+ALTER TABLE "orders"
+    DROP COLUMN IF EXISTS "delivery_fee",
+    DROP COLUMN IF EXISTS "delivery_distance";
@@ -0,0 +1,15 @@
+CREATE TABLE "delivery_zones"(
+    "id" UUID PRIMARY KEY,
+    "name" VARCHAR NOT NULL,
+    "area" JSONB NOT NULL,
+    "delivery_fee" NUMERIC CHECK ("delivery_fee" >= 0),
+    "min_order" NUMERIC NOT NULL DEFAULT 0 CHECK ("min_order" >= 0),
+    "opens_at" TIME,
+    "closes_at" TIME,
+    "is_active" BOOLEAN NOT NULL DEFAULT TRUE,
+    "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" TIMESTAMP
+);
+
+ALTER TABLE "orders"
+    ADD COLUMN "delivery_zone_id" UUID REFERENCES "delivery_zones"("id") ON DELETE SET NULL;
@@ -0,0 +1,4 @@
+ALTER TABLE "orders"
+    DROP COLUMN IF EXISTS "delivery_zone_id";
+
+DROP TABLE IF EXISTS "delivery_zones";
@@ -0,0 +1,21 @@
+CREATE TABLE "customer_addresses" (
+    "id" UUID PRIMARY KEY,
+    "customer_id" UUID NOT NULL REFERENCES customers (id) ON DELETE CASCADE,
+    "label" VARCHAR NOT NULL DEFAULT '',
+    "street" VARCHAR NOT NULL,
+    "apartment" VARCHAR NOT NULL DEFAULT '',
+    "latitude" NUMERIC NOT NULL,
+    "longitude" NUMERIC NOT NULL,
+    "is_default" BOOLEAN NOT NULL DEFAULT FALSE,
+    "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" TIMESTAMP
+);
+
+CREATE UNIQUE INDEX "customer_addresses_default_idx" ON "customer_addresses" ("customer_id") WHERE "is_default";
+
+-- Orders keep a snapshot of the address they were placed with, so editing or
+-- deleting an address does not change past orders.
+ALTER TABLE "orders"
+    ADD COLUMN "address_id" UUID REFERENCES customer_addresses (id) ON DELETE SET NULL,
+    ADD COLUMN "address_street" VARCHAR,
+    ADD COLUMN "address_apartment" VARCHAR;
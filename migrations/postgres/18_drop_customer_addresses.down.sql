@@ -0,0 +1,6 @@
+ALTER TABLE "orders"
+    DROP COLUMN IF EXISTS "address_apartment",
+    DROP COLUMN IF EXISTS "address_street",
+    DROP COLUMN IF EXISTS "address_id";
+
+DROP TABLE IF EXISTS "customer_addresses";
@@ -0,0 +1,88 @@
+CREATE TABLE "roles" (
+    "name" VARCHAR PRIMARY KEY,
+    "description" VARCHAR NOT NULL DEFAULT ''
+);
+
+CREATE TABLE "permissions" (
+    "code" VARCHAR PRIMARY KEY,
+    "description" VARCHAR NOT NULL DEFAULT ''
+);
+
+-- scope "own" limits a permission to the records linked to the user through
+-- users.courier_id, users.customer_id or the user itself.
+CREATE TABLE "role_permissions" (
+    "role" VARCHAR NOT NULL REFERENCES roles (name) ON DELETE CASCADE,
+    "permission" VARCHAR NOT NULL REFERENCES permissions (code) ON DELETE CASCADE,
+    "scope" VARCHAR NOT NULL DEFAULT 'all' CHECK ("scope" IN ('all', 'own')),
+    PRIMARY KEY ("role", "permission")
+);
+
+ALTER TABLE "users"
+    ADD COLUMN "role" VARCHAR REFERENCES roles (name),
+    ADD COLUMN "courier_id" UUID REFERENCES courier (id) ON DELETE SET NULL,
+    ADD COLUMN "customer_id" UUID REFERENCES customers (id) ON DELETE SET NULL;
+
+INSERT INTO "roles" ("name", "description") VALUES
+    ('admin', 'Manages the catalog, couriers, users and every order'),
+    ('courier', 'Sees and progresses the orders assigned to them'),
+    ('customer', 'Places and follows their own orders');
+
+INSERT INTO "permissions" ("code", "description") VALUES
+    ('book.read', 'List and view books'),
+    ('book.write', 'Create, update and delete books'),
+    ('author.read', 'List and view authors'),
+    ('author.write', 'Create, update and delete authors'),
+    ('user.read', 'List and view users'),
+    ('user.write', 'Create, update and delete users'),
+    ('balance.read', 'View balance transactions'),
+    ('balance.write', 'Top up balances'),
+    ('role.read', 'List roles and their permissions'),
+    ('role.write', 'Assign roles to users'),
+    ('customer.read', 'List and view customers'),
+    ('customer.write', 'Create, update and delete customers'),
+    ('address.read', 'View customer addresses'),
+    ('address.write', 'Manage customer addresses'),
+    ('courier.read', 'List and view couriers and their location'),
+    ('courier.write', 'Create, update and delete couriers'),
+    ('courier.location', 'Report courier locations'),
+    ('product.read', 'List and view products'),
+    ('product.write', 'Create, update and delete products'),
+    ('category.read', 'List and view categories'),
+    ('category.write', 'Create, update and delete categories'),
+    ('order.read', 'List, view and follow orders'),
+    ('order.create', 'Place orders'),
+    ('order.write', 'Update and delete orders'),
+    ('order.status', 'Move orders through their lifecycle'),
+    ('order.assign', 'Assign couriers to orders'),
+    ('cart.read', 'View carts'),
+    ('cart.write', 'Manage and check out carts'),
+    ('delivery_zone.read', 'List and view delivery zones'),
+    ('delivery_zone.write', 'Manage delivery zones');
+
+INSERT INTO "role_permissions" ("role", "permission", "scope")
+SELECT 'admin', "code", 'all' FROM "permissions";
+
+INSERT INTO "role_permissions" ("role", "permission", "scope") VALUES
+    ('courier', 'courier.read', 'own'),
+    ('courier', 'courier.location', 'own'),
+    ('courier', 'order.read', 'own'),
+    ('courier', 'order.status', 'own'),
+    ('courier', 'product.read', 'all'),
+    ('courier', 'category.read', 'all'),
+    ('customer', 'customer.read', 'own'),
+    ('customer', 'customer.write', 'own'),
+    ('customer', 'address.read', 'own'),
+    ('customer', 'address.write', 'own'),
+    ('customer', 'order.read', 'own'),
+    ('customer', 'order.create', 'own'),
+    ('customer', 'cart.read', 'own'),
+    ('customer', 'cart.write', 'own'),
+    ('customer', 'balance.read', 'own'),
+    ('customer', 'product.read', 'all'),
+    ('customer', 'category.read', 'all'),
+    ('customer', 'book.read', 'all'),
+    ('customer', 'author.read', 'all'),
+    ('customer', 'delivery_zone.read', 'all');
+
+-- Users start without a role. Grant the first administrator by hand:
+--   UPDATE users SET role = 'admin' WHERE login = '<login>';
@@ -0,0 +1,8 @@
+ALTER TABLE "users"
+    DROP COLUMN IF EXISTS "customer_id",
+    DROP COLUMN IF EXISTS "courier_id",
+    DROP COLUMN IF EXISTS "role";
+
+DROP TABLE IF EXISTS "role_permissions";
+DROP TABLE IF EXISTS "permissions";
+DROP TABLE IF EXISTS "roles";
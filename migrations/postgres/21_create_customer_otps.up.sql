@@ -0,0 +1,14 @@
+ALTER TABLE "customers"
+    ADD COLUMN "phone_verified_at" TIMESTAMP;
+
+CREATE TABLE "customer_otps" (
+    "id" UUID PRIMARY KEY,
+    "phone" VARCHAR NOT NULL,
+    "code_hash" VARCHAR NOT NULL,
+    "attempts" INTEGER NOT NULL DEFAULT 0,
+    "expires_at" TIMESTAMP NOT NULL,
+    "consumed_at" TIMESTAMP,
+    "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX "customer_otps_phone_created_at_idx" ON "customer_otps" ("phone", "created_at" DESC);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS "customer_otps";
+
+ALTER TABLE "customers"
+    DROP COLUMN IF EXISTS "phone_verified_at";
@@ -0,0 +1,31 @@
+-- Only the SHA-256 hash of a key is stored; "prefix" is the leading part of
+-- the key kept in clear so admins can tell keys apart.
+CREATE TABLE "api_keys" (
+    "id" UUID PRIMARY KEY,
+    "name" VARCHAR NOT NULL,
+    "prefix" VARCHAR NOT NULL,
+    "key_hash" VARCHAR NOT NULL UNIQUE,
+    "created_by" UUID REFERENCES users (id) ON DELETE SET NULL,
+    "expires_at" TIMESTAMP,
+    "last_used_at" TIMESTAMP,
+    "revoked_at" TIMESTAMP,
+    "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Scopes are permission codes, always granted with the "all" scope.
+CREATE TABLE "api_key_scopes" (
+    "api_key_id" UUID NOT NULL REFERENCES api_keys (id) ON DELETE CASCADE,
+    "permission" VARCHAR NOT NULL REFERENCES permissions (code) ON DELETE CASCADE,
+    PRIMARY KEY ("api_key_id", "permission")
+);
+
+ALTER TABLE "order_status_history"
+    ADD COLUMN "api_key_id" UUID REFERENCES api_keys (id) ON DELETE SET NULL;
+
+INSERT INTO "permissions" ("code", "description") VALUES
+    ('api_key.read', 'List and view API keys'),
+    ('api_key.write', 'Create and revoke API keys');
+
+INSERT INTO "role_permissions" ("role", "permission", "scope") VALUES
+    ('admin', 'api_key.read', 'all'),
+    ('admin', 'api_key.write', 'all');
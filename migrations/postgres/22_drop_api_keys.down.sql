@@ -0,0 +1,7 @@
+DELETE FROM "permissions" WHERE "code" IN ('api_key.read', 'api_key.write');
+
+ALTER TABLE "order_status_history"
+    DROP COLUMN IF EXISTS "api_key_id";
+
+DROP TABLE IF EXISTS "api_key_scopes";
+DROP TABLE IF EXISTS "api_keys";
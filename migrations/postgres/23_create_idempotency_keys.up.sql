@@ -0,0 +1,17 @@
+-- A row is reserved when a request with an Idempotency-Key starts and holds
+-- its response once it finishes. "owner" scopes keys to the user or API key
+-- that sent them.
+CREATE TABLE "idempotency_keys" (
+    "owner" VARCHAR NOT NULL,
+    "key" VARCHAR NOT NULL,
+    "method" VARCHAR NOT NULL,
+    "path" VARCHAR NOT NULL,
+    "request_hash" VARCHAR NOT NULL,
+    "status_code" INTEGER,
+    "response" BYTEA,
+    "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    "expires_at" TIMESTAMP NOT NULL,
+    PRIMARY KEY ("owner", "key")
+);
+
+CREATE INDEX "idempotency_keys_expires_at_idx" ON "idempotency_keys" ("expires_at");
@@ -0,0 +1,9 @@
+-- Keyset pages walk (created_at, id) from the newest record.
+CREATE INDEX "book_created_at_id_idx" ON "book" ("created_at" DESC, "id" DESC);
+CREATE INDEX "users_created_at_id_idx" ON "users" ("created_at" DESC, "id" DESC);
+CREATE INDEX "author_created_at_id_idx" ON "author" ("created_at" DESC, "id" DESC);
+CREATE INDEX "customers_created_at_id_idx" ON "customers" ("created_at" DESC, "id" DESC);
+CREATE INDEX "courier_created_at_id_idx" ON "courier" ("created_at" DESC, "id" DESC);
+CREATE INDEX "products_created_at_id_idx" ON "products" ("created_at" DESC, "id" DESC);
+CREATE INDEX "orders_created_at_id_idx" ON "orders" ("created_at" DESC, "id" DESC);
+CREATE INDEX "delivery_zones_created_at_id_idx" ON "delivery_zones" ("created_at" DESC, "id" DESC);
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS "book_created_at_id_idx";
+DROP INDEX IF EXISTS "users_created_at_id_idx";
+DROP INDEX IF EXISTS "author_created_at_id_idx";
+DROP INDEX IF EXISTS "customers_created_at_id_idx";
+DROP INDEX IF EXISTS "courier_created_at_id_idx";
+DROP INDEX IF EXISTS "products_created_at_id_idx";
+DROP INDEX IF EXISTS "orders_created_at_id_idx";
+DROP INDEX IF EXISTS "delivery_zones_created_at_id_idx";
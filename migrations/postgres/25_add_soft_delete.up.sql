@@ -0,0 +1,77 @@
+-- Deleting these records only marks them, so the orders that reference them
+-- keep their history.
+ALTER TABLE "categories" ADD COLUMN "deleted_at" TIMESTAMP;
+ALTER TABLE "products" ADD COLUMN "deleted_at" TIMESTAMP;
+ALTER TABLE "customers" ADD COLUMN "deleted_at" TIMESTAMP;
+ALTER TABLE "courier" ADD COLUMN "deleted_at" TIMESTAMP;
+ALTER TABLE "users" ADD COLUMN "deleted_at" TIMESTAMP;
+
+-- Soft deleted rows still satisfy foreign keys, so new references to them are
+-- rejected here with the error of the foreign key named by the third
+-- argument. The first argument is the referencing column and the second the
+-- referenced table. References already in place are left alone on update,
+-- unless the referencing row itself is being restored.
+CREATE FUNCTION "reject_deleted_reference"() RETURNS TRIGGER AS $$
+DECLARE
+    "new_row" JSONB := to_jsonb(NEW);
+    "old_row" JSONB;
+    "reference" TEXT := "new_row" ->> TG_ARGV[0];
+    "deleted" BOOLEAN;
+BEGIN
+    IF "reference" IS NULL THEN
+        RETURN NEW;
+    END IF;
+
+    IF TG_OP = 'UPDATE' THEN
+        "old_row" := to_jsonb(OLD);
+
+        IF "reference" IS NOT DISTINCT FROM "old_row" ->> TG_ARGV[0]
+            AND NOT ("old_row" ->> 'deleted_at' IS NOT NULL AND "new_row" ->> 'deleted_at' IS NULL) THEN
+            RETURN NEW;
+        END IF;
+    END IF;
+
+    EXECUTE format('SELECT "deleted_at" IS NOT NULL FROM %I WHERE "id" = $1', TG_ARGV[1])
+        INTO "deleted"
+        USING "reference"::UUID;
+
+    IF "deleted" THEN
+        RAISE EXCEPTION 'referenced record is deleted'
+            USING ERRCODE = 'foreign_key_violation', CONSTRAINT = TG_ARGV[2];
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "products_category_id_not_deleted"
+    BEFORE INSERT OR UPDATE OF "category_id", "deleted_at" ON "products"
+    FOR EACH ROW EXECUTE FUNCTION "reject_deleted_reference"('category_id', 'categories', 'products_category_id_fkey');
+
+CREATE TRIGGER "orders_user_id_not_deleted"
+    BEFORE INSERT OR UPDATE OF "user_id" ON "orders"
+    FOR EACH ROW EXECUTE FUNCTION "reject_deleted_reference"('user_id', 'users', 'orders_user_id_fkey');
+
+CREATE TRIGGER "orders_customer_id_not_deleted"
+    BEFORE INSERT OR UPDATE OF "customer_id" ON "orders"
+    FOR EACH ROW EXECUTE FUNCTION "reject_deleted_reference"('customer_id', 'customers', 'orders_customer_id_fkey');
+
+CREATE TRIGGER "orders_courier_id_not_deleted"
+    BEFORE INSERT OR UPDATE OF "courier_id" ON "orders"
+    FOR EACH ROW EXECUTE FUNCTION "reject_deleted_reference"('courier_id', 'courier', 'orders_courier_id_fkey');
+
+CREATE TRIGGER "carts_customer_id_not_deleted"
+    BEFORE INSERT OR UPDATE OF "customer_id" ON "carts"
+    FOR EACH ROW EXECUTE FUNCTION "reject_deleted_reference"('customer_id', 'customers', 'carts_customer_id_fkey');
+
+CREATE TRIGGER "customer_addresses_customer_id_not_deleted"
+    BEFORE INSERT OR UPDATE OF "customer_id" ON "customer_addresses"
+    FOR EACH ROW EXECUTE FUNCTION "reject_deleted_reference"('customer_id', 'customers', 'customer_addresses_customer_id_fkey');
+
+CREATE TRIGGER "users_courier_id_not_deleted"
+    BEFORE INSERT OR UPDATE OF "courier_id" ON "users"
+    FOR EACH ROW EXECUTE FUNCTION "reject_deleted_reference"('courier_id', 'courier', 'users_courier_id_fkey');
+
+CREATE TRIGGER "users_customer_id_not_deleted"
+    BEFORE INSERT OR UPDATE OF "customer_id" ON "users"
+    FOR EACH ROW EXECUTE FUNCTION "reject_deleted_reference"('customer_id', 'customers', 'users_customer_id_fkey');
@@ -0,0 +1,17 @@
+DROP TRIGGER IF EXISTS "users_customer_id_not_deleted" ON "users";
+DROP TRIGGER IF EXISTS "users_courier_id_not_deleted" ON "users";
+DROP TRIGGER IF EXISTS "customer_addresses_customer_id_not_deleted" ON "customer_addresses";
+DROP TRIGGER IF EXISTS "carts_customer_id_not_deleted" ON "carts";
+DROP TRIGGER IF EXISTS "orders_courier_id_not_deleted" ON "orders";
+DROP TRIGGER IF EXISTS "orders_customer_id_not_deleted" ON "orders";
+DROP TRIGGER IF EXISTS "orders_user_id_not_deleted" ON "orders";
+DROP TRIGGER IF EXISTS "products_category_id_not_deleted" ON "products";
+
+DROP FUNCTION IF EXISTS "reject_deleted_reference"();
+
+-- Soft deleted rows come back as live ones.
+ALTER TABLE "users" DROP COLUMN IF EXISTS "deleted_at";
+ALTER TABLE "courier" DROP COLUMN IF EXISTS "deleted_at";
+ALTER TABLE "customers" DROP COLUMN IF EXISTS "deleted_at";
+ALTER TABLE "products" DROP COLUMN IF EXISTS "deleted_at";
+ALTER TABLE "categories" DROP COLUMN IF EXISTS "deleted_at";
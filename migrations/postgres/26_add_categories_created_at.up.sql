@@ -0,0 +1,5 @@
+-- Categories are paged with a cursor like the other lists. Existing rows are
+-- dated to the migration, and their ids break the ties.
+ALTER TABLE "categories" ADD COLUMN "created_at" TIMESTAMP NOT NULL DEFAULT now();
+
+CREATE INDEX "categories_created_at_id_idx" ON "categories" ("created_at" DESC, "id" DESC);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS "categories_created_at_id_idx";
+
+ALTER TABLE "categories" DROP COLUMN IF EXISTS "created_at";
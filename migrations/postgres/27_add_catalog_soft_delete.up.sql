@@ -0,0 +1,3 @@
+-- Books and authors are catalog records too, so deleting them only marks them.
+ALTER TABLE "book" ADD COLUMN "deleted_at" TIMESTAMP;
+ALTER TABLE "author" ADD COLUMN "deleted_at" TIMESTAMP;
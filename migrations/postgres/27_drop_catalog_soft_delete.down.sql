@@ -0,0 +1,3 @@
+-- Soft deleted rows come back as live ones.
+ALTER TABLE "author" DROP COLUMN IF EXISTS "deleted_at";
+ALTER TABLE "book" DROP COLUMN IF EXISTS "deleted_at";
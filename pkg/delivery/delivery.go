@@ -0,0 +1,109 @@
+package delivery
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"app/pkg/geo"
+)
+
+// ErrOutOfRange is returned when a destination is farther from the store than
+// the last pricing tier reaches.
+var ErrOutOfRange = errors.New("delivery address is out of range")
+
+// Tier charges Fee for deliveries up to MaxDistance kilometers.
+type Tier struct {
+	MaxDistance float64
+	Fee         float64
+}
+
+// Quote is the delivery fee for a destination.
+type Quote struct {
+	Distance float64 `json:"distance"`
+	Fee      float64 `json:"fee"`
+}
+
+// Calculator prices deliveries from a store location using distance tiers.
+type Calculator struct {
+	store geo.Point
+	tiers []Tier
+}
+
+// NewCalculator returns a Calculator for store. Tiers are sorted by distance,
+// so they may be given in any order.
+func NewCalculator(store geo.Point, tiers []Tier) *Calculator {
+
+	sorted := make([]Tier, len(tiers))
+	copy(sorted, tiers)
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].MaxDistance < sorted[j].MaxDistance
+	})
+
+	return &Calculator{
+		store: store,
+		tiers: sorted,
+	}
+}
+
+// Quote returns the haversine distance from the store to destination and the
+// fee of the first tier covering it. Distances are rounded to meters.
+func (c *Calculator) Quote(destination geo.Point) (*Quote, error) {
+
+	distance := math.Round(geo.Distance(c.store, destination)*1000) / 1000
+
+	for _, tier := range c.tiers {
+		if distance <= tier.MaxDistance {
+			return &Quote{Distance: distance, Fee: tier.Fee}, nil
+		}
+	}
+
+	return nil, ErrOutOfRange
+}
+
+// ParseTiers parses tiers written as comma separated "max_km:fee" pairs,
+// e.g. "3:5000,7:10000,15:15000".
+func ParseTiers(s string) ([]Tier, error) {
+
+	var tiers []Tier
+
+	for _, part := range strings.Split(s, ",") {
+
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		pair := strings.SplitN(part, ":", 2)
+		if len(pair) != 2 {
+			return nil, fmt.Errorf("invalid delivery tier %q", part)
+		}
+
+		distance, err := strconv.ParseFloat(strings.TrimSpace(pair[0]), 64)
+		if err != nil || distance <= 0 {
+			return nil, fmt.Errorf("invalid delivery tier distance %q", pair[0])
+		}
+
+		fee, err := strconv.ParseFloat(strings.TrimSpace(pair[1]), 64)
+		if err != nil || fee < 0 {
+			return nil, fmt.Errorf("invalid delivery tier fee %q", pair[1])
+		}
+
+		tiers = append(tiers, Tier{MaxDistance: distance, Fee: fee})
+	}
+
+	if len(tiers) <= 0 {
+		return nil, errors.New("no delivery tiers configured")
+	}
+
+	return tiers, nil
+}
+
+// Store returns the location deliveries are priced from.
+func (c *Calculator) Store() geo.Point {
+	return c.store
+}
@@ -0,0 +1,230 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// OrderStatusChanged is published on the order topic when its status changes.
+	OrderStatusChanged = "order.status"
+	// OrderCourierAssigned is published on the order topic when a courier is (re)assigned.
+	OrderCourierAssigned = "order.courier"
+	// CourierLocationUpdated is published on the courier topic for every reported position.
+	CourierLocationUpdated = "courier.location"
+)
+
+// Event is a single notification delivered to subscribers of a topic.
+type Event struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+	Time time.Time   `json:"time"`
+}
+
+// Publisher is implemented by anything events can be published to.
+type Publisher interface {
+	Publish(topic string, eventType string, data interface{})
+}
+
+// OrderTopic is the topic carrying the events of a single order.
+func OrderTopic(orderId string) string {
+	return "order:" + orderId
+}
+
+// CourierTopic is the topic carrying the events of a single courier.
+func CourierTopic(courierId string) string {
+	return "courier:" + courierId
+}
+
+// Bus is an in-process publish/subscribe hub. Publishing never blocks: a
+// subscriber that falls behind by more than its buffer is closed, so that a
+// slow client cannot stall the publishers; it is expected to reconnect and
+// fetch a fresh snapshot.
+type Bus struct {
+	mu          sync.RWMutex
+	buffer      int
+	subscribers map[string]map[*Subscription]struct{}
+}
+
+// NewBus creates a bus whose subscriptions buffer up to buffer events.
+func NewBus(buffer int) *Bus {
+
+	if buffer <= 0 {
+		buffer = 1
+	}
+
+	return &Bus{
+		buffer:      buffer,
+		subscribers: make(map[string]map[*Subscription]struct{}),
+	}
+}
+
+// Subscribe returns a subscription receiving the events of the given topics.
+// The subscription must be closed when no longer needed.
+func (b *Bus) Subscribe(topics ...string) *Subscription {
+
+	ch := make(chan Event, b.buffer)
+
+	sub := &Subscription{
+		C:      ch,
+		ch:     ch,
+		bus:    b,
+		topics: make(map[string]struct{}),
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, topic := range topics {
+		b.add(sub, topic)
+	}
+
+	return sub
+}
+
+// Publish delivers an event to every subscriber of topic.
+func (b *Bus) Publish(topic string, eventType string, data interface{}) {
+
+	event := Event{
+		Type: eventType,
+		Data: data,
+		Time: time.Now(),
+	}
+
+	var slow []*Subscription
+
+	b.mu.RLock()
+	for sub := range b.subscribers[topic] {
+		select {
+		case sub.ch <- event:
+		default:
+			slow = append(slow, sub)
+		}
+	}
+	b.mu.RUnlock()
+
+	for _, sub := range slow {
+		sub.Close()
+	}
+}
+
+// Subscribers returns the number of subscriptions to topic.
+func (b *Bus) Subscribers(topic string) int {
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return len(b.subscribers[topic])
+}
+
+// add and remove must be called with b.mu held for writing.
+func (b *Bus) add(sub *Subscription, topic string) {
+
+	if sub.closed {
+		return
+	}
+
+	if b.subscribers[topic] == nil {
+		b.subscribers[topic] = make(map[*Subscription]struct{})
+	}
+
+	b.subscribers[topic][sub] = struct{}{}
+	sub.topics[topic] = struct{}{}
+}
+
+func (b *Bus) remove(sub *Subscription, topic string) {
+
+	delete(b.subscribers[topic], sub)
+	if len(b.subscribers[topic]) == 0 {
+		delete(b.subscribers, topic)
+	}
+
+	delete(sub.topics, topic)
+}
+
+// Subscription receives events on C until it is closed. C is closed when the
+// subscription is closed, either by its owner or by the bus.
+type Subscription struct {
+	C <-chan Event
+
+	ch     chan Event
+	bus    *Bus
+	topics map[string]struct{}
+	closed bool
+}
+
+// Subscribe adds topics to the subscription.
+func (s *Subscription) Subscribe(topics ...string) {
+
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+
+	for _, topic := range topics {
+		s.bus.add(s, topic)
+	}
+}
+
+// Unsubscribe removes topics from the subscription.
+func (s *Subscription) Unsubscribe(topics ...string) {
+
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+
+	for _, topic := range topics {
+		s.bus.remove(s, topic)
+	}
+}
+
+// Close removes the subscription from every topic and closes C. It is safe to
+// call more than once.
+func (s *Subscription) Close() {
+
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+
+	if s.closed {
+		return
+	}
+
+	for topic := range s.topics {
+		s.bus.remove(s, topic)
+	}
+
+	s.closed = true
+	close(s.ch)
+}
+
+// Buffer is a Publisher that holds events until they are flushed, so that
+// the events of a transaction are only delivered once it commits.
+type Buffer struct {
+	mu     sync.Mutex
+	events []bufferedEvent
+}
+
+type bufferedEvent struct {
+	topic     string
+	eventType string
+	data      interface{}
+}
+
+// Publish holds an event until the next Flush.
+func (b *Buffer) Publish(topic string, eventType string, data interface{}) {
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.events = append(b.events, bufferedEvent{topic: topic, eventType: eventType, data: data})
+}
+
+// Flush publishes the held events to publisher in order and empties b.
+func (b *Buffer) Flush(publisher Publisher) {
+
+	b.mu.Lock()
+	held := b.events
+	b.events = nil
+	b.mu.Unlock()
+
+	for _, event := range held {
+		publisher.Publish(event.topic, event.eventType, event.data)
+	}
+}
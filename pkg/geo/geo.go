@@ -0,0 +1,31 @@
+package geo
+
+import "math"
+
+// EarthRadius is the mean radius of the Earth in kilometers.
+const EarthRadius = 6371.0
+
+// Point is a WGS84 coordinate in degrees.
+type Point struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// Distance returns the great-circle distance between a and b in kilometers
+// using the haversine formula.
+func Distance(a, b Point) float64 {
+
+	lat1 := toRadians(a.Latitude)
+	lat2 := toRadians(b.Latitude)
+	dLat := toRadians(b.Latitude - a.Latitude)
+	dLng := toRadians(b.Longitude - a.Longitude)
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+
+	return 2 * EarthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+func toRadians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
@@ -0,0 +1,162 @@
+package geo
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Polygon is a list of linear rings. The first ring is the outer boundary and
+// any following rings are holes. Rings are closed: their first and last
+// points are equal.
+type Polygon [][]Point
+
+// PolygonFromGeoJSON builds a Polygon from the coordinates of a GeoJSON
+// Polygon geometry, whose positions are [longitude, latitude] pairs.
+func PolygonFromGeoJSON(coordinates [][][]float64) (Polygon, error) {
+
+	if len(coordinates) <= 0 {
+		return nil, errors.New("polygon must have an outer ring")
+	}
+
+	polygon := make(Polygon, 0, len(coordinates))
+
+	for i, positions := range coordinates {
+
+		if len(positions) < 4 {
+			return nil, fmt.Errorf("ring %d must have at least 4 positions", i)
+		}
+
+		ring := make([]Point, 0, len(positions))
+
+		for _, position := range positions {
+
+			if len(position) < 2 {
+				return nil, fmt.Errorf("ring %d has a position without longitude and latitude", i)
+			}
+
+			point := Point{Latitude: position[1], Longitude: position[0]}
+			if point.Latitude < -90 || point.Latitude > 90 || point.Longitude < -180 || point.Longitude > 180 {
+				return nil, fmt.Errorf("ring %d has an invalid position %v", i, position)
+			}
+
+			ring = append(ring, point)
+		}
+
+		if ring[0] != ring[len(ring)-1] {
+			return nil, fmt.Errorf("ring %d is not closed", i)
+		}
+
+		polygon = append(polygon, ring)
+	}
+
+	return polygon, nil
+}
+
+// Contains reports whether p lies inside the outer ring of the polygon and
+// outside all of its holes. Points on an edge count as inside the ring.
+func (polygon Polygon) Contains(p Point) bool {
+
+	if len(polygon) <= 0 || !ringContains(polygon[0], p) {
+		return false
+	}
+
+	for _, hole := range polygon[1:] {
+		if ringContains(hole, p) && !onRing(hole, p) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Distance returns the distance in kilometers from p to the nearest edge of
+// the polygon, or 0 if p is inside it.
+func (polygon Polygon) Distance(p Point) float64 {
+
+	if polygon.Contains(p) {
+		return 0
+	}
+
+	nearest := math.Inf(1)
+
+	for _, ring := range polygon {
+		for i := 1; i < len(ring); i++ {
+			nearest = math.Min(nearest, segmentDistance(p, ring[i-1], ring[i]))
+		}
+	}
+
+	return nearest
+}
+
+// ringContains implements the even-odd ray casting test, treating points on
+// an edge as inside.
+func ringContains(ring []Point, p Point) bool {
+
+	if onRing(ring, p) {
+		return true
+	}
+
+	inside := false
+
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+
+		a, b := ring[i], ring[j]
+
+		if (a.Latitude > p.Latitude) != (b.Latitude > p.Latitude) {
+			crossing := (b.Longitude-a.Longitude)*(p.Latitude-a.Latitude)/(b.Latitude-a.Latitude) + a.Longitude
+			if p.Longitude < crossing {
+				inside = !inside
+			}
+		}
+	}
+
+	return inside
+}
+
+func onRing(ring []Point, p Point) bool {
+
+	const epsilon = 1e-12
+
+	for i := 1; i < len(ring); i++ {
+
+		a, b := ring[i-1], ring[i]
+
+		cross := (b.Longitude-a.Longitude)*(p.Latitude-a.Latitude) - (b.Latitude-a.Latitude)*(p.Longitude-a.Longitude)
+		if math.Abs(cross) > epsilon {
+			continue
+		}
+
+		if p.Longitude >= math.Min(a.Longitude, b.Longitude) && p.Longitude <= math.Max(a.Longitude, b.Longitude) &&
+			p.Latitude >= math.Min(a.Latitude, b.Latitude) && p.Latitude <= math.Max(a.Latitude, b.Latitude) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// segmentDistance returns the distance in kilometers from p to the segment
+// ab. The segment is projected onto a plane tangent at p, which is accurate
+// for the city-sized distances delivery zones cover.
+func segmentDistance(p, a, b Point) float64 {
+
+	scale := math.Cos(toRadians(p.Latitude))
+
+	ax, ay := (a.Longitude-p.Longitude)*scale, a.Latitude-p.Latitude
+	bx, by := (b.Longitude-p.Longitude)*scale, b.Latitude-p.Latitude
+
+	dx, dy := bx-ax, by-ay
+
+	t := 0.0
+	if length := dx*dx + dy*dy; length > 0 {
+		t = math.Max(0, math.Min(1, -(ax*dx+ay*dy)/length))
+	}
+
+	nearest := Point{
+		Latitude:  p.Latitude + ay + t*dy,
+		Longitude: p.Longitude + (ax+t*dx)/scale,
+	}
+
+	return Distance(p, nearest)
+}
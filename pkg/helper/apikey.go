@@ -0,0 +1,35 @@
+package helper
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+const (
+	// APIKeyPrefix starts every generated API key so leaked keys are easy to
+	// recognise.
+	APIKeyPrefix = "sck_"
+	// APIKeyDisplayLength is how many leading characters of a key are kept in
+	// clear to identify it.
+	APIKeyDisplayLength = len(APIKeyPrefix) + 8
+)
+
+// GenerateAPIKey returns a new random API key.
+func GenerateAPIKey() (string, error) {
+
+	buffer := make([]byte, 24)
+	_, err := rand.Read(buffer)
+	if err != nil {
+		return "", err
+	}
+
+	return APIKeyPrefix + hex.EncodeToString(buffer), nil
+}
+
+// HashAPIKey returns the hex encoded SHA-256 hash of key. API keys are long
+// and random, so unlike passwords they need no salt or slow hash.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -63,3 +63,8 @@ func IsValidPrice(price string) bool {
 	r := regexp.MustCompile(`^\d+$`)
 	return r.MatchString(price)
 }
+
+// IsValidCoordinate ...
+func IsValidCoordinate(latitude, longitude float64) bool {
+	return latitude >= -90 && latitude <= 90 && longitude >= -180 && longitude <= 180
+}
@@ -0,0 +1,73 @@
+// Package sms delivers text messages through a pluggable Sender.
+package sms
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"app/pkg/logger"
+)
+
+const (
+	DriverLog  = "log"
+	DriverFile = "file"
+)
+
+// Sender delivers a text message to a phone number.
+type Sender interface {
+	Send(ctx context.Context, phone, message string) error
+}
+
+// New returns the Sender for driver. The file driver appends messages to
+// path; the log driver writes them to log.
+func New(driver, path string, log logger.LoggerI) (Sender, error) {
+
+	switch driver {
+	case DriverLog:
+		return &LogSender{log: log}, nil
+	case DriverFile:
+		return &FileSender{path: path}, nil
+	}
+
+	return nil, fmt.Errorf("unknown sms driver %q", driver)
+}
+
+// LogSender writes messages to the service log instead of sending them. It
+// is meant for local runs.
+type LogSender struct {
+	log logger.LoggerI
+}
+
+func (s *LogSender) Send(ctx context.Context, phone, message string) error {
+	s.log.Info("sms", logger.String("phone", phone), logger.String("message", message))
+	return nil
+}
+
+// FileSender appends messages to a file, one per line, so local runs and
+// tests can read them back.
+type FileSender struct {
+	mu   sync.Mutex
+	path string
+}
+
+func (s *FileSender) Send(ctx context.Context, phone, message string) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(file, "%s\t%s\t%s\n", time.Now().Format(time.RFC3339), phone, message)
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"app/api/models"
+	"app/pkg/delivery"
+	"app/pkg/geo"
+	"fmt"
+	"math"
+	"time"
+)
+
+// QuoteDelivery prices delivery to point. When active delivery zones exist
+// the point must fall inside one that is open at now; its fee, if set,
+// replaces the distance based fee. Without zones only the distance tiers
+// apply. The matched zone is returned so callers can check its minimum order.
+func QuoteDelivery(calculator *delivery.Calculator, zones []*models.DeliveryZone, point geo.Point, now time.Time) (*models.DeliveryQuote, *models.DeliveryZone, error) {
+
+	distanceQuote, err := calculator.Quote(point)
+
+	if len(zones) <= 0 {
+		if err != nil {
+			return nil, nil, err
+		}
+		return &models.DeliveryQuote{Distance: distanceQuote.Distance, Fee: distanceQuote.Fee}, nil, nil
+	}
+
+	var (
+		zone    *models.DeliveryZone
+		nearest = &OutsideDeliveryZoneError{}
+	)
+
+	for i, candidate := range zones {
+
+		polygon, perr := geo.PolygonFromGeoJSON(candidate.Area.Coordinates)
+		if perr != nil {
+			return nil, nil, fmt.Errorf("delivery zone %s: %w", candidate.Id, perr)
+		}
+
+		distance := polygon.Distance(point)
+		if distance == 0 {
+			zone = candidate
+			break
+		}
+
+		if i == 0 || distance < nearest.Distance {
+			nearest.NearestZone = candidate.Name
+			nearest.Distance = distance
+		}
+	}
+
+	if zone == nil {
+		return nil, nil, nearest
+	}
+
+	if !DeliveryZoneOpen(zone, now) {
+		return nil, nil, &DeliveryZoneClosedError{Zone: zone.Name, OpensAt: zone.Opens_at, ClosesAt: zone.Closes_at}
+	}
+
+	quote := &models.DeliveryQuote{
+		Distance:  math.Round(geo.Distance(calculator.Store(), point)*1000) / 1000,
+		Zone_id:   zone.Id,
+		Zone_name: zone.Name,
+		Min_order: zone.Min_order,
+	}
+
+	switch {
+	case zone.Delivery_fee != nil:
+		quote.Fee = *zone.Delivery_fee
+	case err != nil:
+		return nil, nil, err
+	default:
+		quote.Distance = distanceQuote.Distance
+		quote.Fee = distanceQuote.Fee
+	}
+
+	return quote, zone, nil
+}
+
+// DeliveryZoneOpen reports whether zone takes orders at now. Zones without
+// hours are always open, and hours may wrap past midnight.
+func DeliveryZoneOpen(zone *models.DeliveryZone, now time.Time) bool {
+
+	if zone.Opens_at == "" || zone.Closes_at == "" {
+		return true
+	}
+
+	clock := now.Format("15:04")
+
+	if zone.Opens_at <= zone.Closes_at {
+		return clock >= zone.Opens_at && clock < zone.Closes_at
+	}
+
+	return clock >= zone.Opens_at || clock < zone.Closes_at
+}
@@ -0,0 +1,284 @@
+package storage
+
+import (
+	"app/api/models"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+)
+
+// StatusTransitionError is returned when an order is moved to a status that
+// is not reachable from its current one.
+type StatusTransitionError struct {
+	From string
+	To   string
+}
+
+func (e *StatusTransitionError) Error() string {
+	return fmt.Sprintf("order status cannot change from %q to %q", e.From, e.To)
+}
+
+// InsufficientStockError is returned when an order asks for more of some
+// products than is left in stock. Products lists every offending product.
+type InsufficientStockError struct {
+	Products []*models.StockShortage `json:"products"`
+}
+
+func (e *InsufficientStockError) Error() string {
+	return fmt.Sprintf("insufficient stock for %d product(s)", len(e.Products))
+}
+
+// ErrOrderItemsLocked is returned when the items of an order are changed after
+// it has been picked up, delivered or closed.
+var ErrOrderItemsLocked = errors.New("order items can no longer be changed")
+
+// InsufficientBalanceError is returned when a debit would take the balance of
+// a user below zero.
+type InsufficientBalanceError struct {
+	UserId   string  `json:"user_id"`
+	Balance  float64 `json:"balance"`
+	Required float64 `json:"required"`
+}
+
+func (e *InsufficientBalanceError) Error() string {
+	return fmt.Sprintf("insufficient balance: %.2f available, %.2f required", e.Balance, e.Required)
+}
+
+// ErrNoCourierAvailable is returned by automatic assignment when no courier
+// with a known location has room for another order.
+var ErrNoCourierAvailable = errors.New("no courier is available for this order")
+
+// ErrCourierUnavailable is returned when an order is assigned by hand to a
+// courier who is not taking orders.
+var ErrCourierUnavailable = errors.New("courier is not available for new orders")
+
+// ErrCourierAssignmentLocked is returned when the courier of an order is
+// reassigned after the order has been picked up or closed.
+var ErrCourierAssignmentLocked = errors.New("courier can no longer be reassigned for this order")
+
+// UnavailableProductsError is returned by checkout when the cart references
+// products that no longer exist or cannot be sold.
+type UnavailableProductsError struct {
+	ProductIds []string `json:"product_ids"`
+}
+
+func (e *UnavailableProductsError) Error() string {
+	return fmt.Sprintf("%d product(s) in the cart are no longer available", len(e.ProductIds))
+}
+
+// ErrCartEmpty is returned when checking out a cart without items.
+var ErrCartEmpty = errors.New("cart is empty")
+
+// OutsideDeliveryZoneError is returned when an order address lies outside
+// every active delivery zone. NearestZone names the closest one.
+type OutsideDeliveryZoneError struct {
+	NearestZone string  `json:"nearest_zone"`
+	Distance    float64 `json:"distance"`
+}
+
+func (e *OutsideDeliveryZoneError) Error() string {
+	return fmt.Sprintf("address is outside the delivery area, nearest zone is %q (%.2f km away)", e.NearestZone, e.Distance)
+}
+
+// DeliveryZoneClosedError is returned when an order is placed in a delivery
+// zone outside its active hours.
+type DeliveryZoneClosedError struct {
+	Zone     string `json:"zone"`
+	OpensAt  string `json:"opens_at"`
+	ClosesAt string `json:"closes_at"`
+}
+
+func (e *DeliveryZoneClosedError) Error() string {
+	return fmt.Sprintf("delivery zone %q only takes orders between %s and %s", e.Zone, e.OpensAt, e.ClosesAt)
+}
+
+// BelowMinimumOrderError is returned when the items of an order cost less
+// than the minimum order of its delivery zone.
+type BelowMinimumOrderError struct {
+	Zone     string  `json:"zone"`
+	MinOrder float64 `json:"min_order"`
+	Subtotal float64 `json:"subtotal"`
+}
+
+func (e *BelowMinimumOrderError) Error() string {
+	return fmt.Sprintf("delivery zone %q requires a minimum order of %.2f, got %.2f", e.Zone, e.MinOrder, e.Subtotal)
+}
+
+// ErrAddressNotFound is returned when an order references an address that
+// does not belong to its customer.
+var ErrAddressNotFound = errors.New("address not found for this customer")
+
+// ErrLoginTaken is returned when a user is created with a login that another
+// user already has.
+var ErrLoginTaken = errors.New("login is already taken")
+
+// OtpTooSoonError is returned when a one-time code is requested for a phone
+// that was sent one moments ago.
+type OtpTooSoonError struct {
+	RetryAfter int `json:"retry_after"`
+}
+
+func (e *OtpTooSoonError) Error() string {
+	return fmt.Sprintf("a code was sent recently, retry in %d seconds", e.RetryAfter)
+}
+
+// ErrOtpNotFound is returned when a phone has no unused, unexpired code.
+var ErrOtpNotFound = errors.New("no active code for this phone, request a new one")
+
+// ErrOtpAttemptsExceeded is returned when a code was guessed wrong too many
+// times.
+var ErrOtpAttemptsExceeded = errors.New("too many wrong attempts, request a new code")
+
+// InvalidOtpError is returned for a wrong code that may still be retried.
+type InvalidOtpError struct {
+	AttemptsLeft int `json:"attempts_left"`
+}
+
+func (e *InvalidOtpError) Error() string {
+	return fmt.Sprintf("invalid code, %d attempt(s) left", e.AttemptsLeft)
+}
+
+// UnknownPermissionError is returned when an API key is given scopes that are
+// not permission codes.
+type UnknownPermissionError struct {
+	Permissions []string `json:"permissions"`
+}
+
+func (e *UnknownPermissionError) Error() string {
+	return fmt.Sprintf("unknown permission(s): %s", strings.Join(e.Permissions, ", "))
+}
+
+// ErrIdempotencyKeyReused is returned when an idempotency key is sent again
+// with a different request.
+var ErrIdempotencyKeyReused = errors.New("idempotency key was already used with a different request")
+
+// ErrIdempotencyKeyInProgress is returned when a request with the same
+// idempotency key is still being processed.
+var ErrIdempotencyKeyInProgress = errors.New("a request with this idempotency key is still in progress")
+
+// ErrorKind classifies storage failures the caller can act on.
+type ErrorKind string
+
+const (
+	// KindNotFound means the requested record does not exist.
+	KindNotFound ErrorKind = "not_found"
+	// KindConflict means the write clashes with an existing record, such as
+	// a duplicate unique value.
+	KindConflict ErrorKind = "conflict"
+	// KindForeignKey means the write references a record that does not exist,
+	// or deletes one that is still referenced.
+	KindForeignKey ErrorKind = "foreign_key"
+	// KindValidation means the database rejected a value, such as a NULL in a
+	// required column or a failed CHECK.
+	KindValidation ErrorKind = "validation"
+)
+
+// Error is a classified storage failure. Message is safe to show to clients;
+// Err keeps the underlying driver error for logs.
+type Error struct {
+	Kind    ErrorKind
+	Message string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// PostgreSQL error codes translated by Translate.
+const (
+	sqlStateUniqueViolation           = "23505"
+	sqlStateExclusionViolation        = "23P01"
+	sqlStateForeignKeyViolation       = "23503"
+	sqlStateNotNullViolation          = "23502"
+	sqlStateCheckViolation            = "23514"
+	sqlStateInvalidTextRepresentation = "22P02"
+	sqlStateStringDataRightTruncation = "22001"
+	sqlStateNumericValueOutOfRange    = "22003"
+	sqlStateInvalidDatetimeFormat     = "22007"
+	sqlStateDatetimeFieldOverflow     = "22008"
+	sqlStateSerializationFailure      = "40001"
+	sqlStateDeadlockDetected          = "40P01"
+)
+
+// ErrNotFound is the generic error for a missing record.
+var ErrNotFound = &Error{Kind: KindNotFound, Message: "record not found", Err: pgx.ErrNoRows}
+
+// ForeignKeyViolation is the error Translate returns for a violation of the
+// foreign key constraint, for references checked outside of the database.
+func ForeignKeyViolation(constraint string) *Error {
+	return &Error{Kind: KindForeignKey, Message: "referenced record is missing or still in use, constraint " + constraint}
+}
+
+// IsKind reports whether err is, or translates to, a storage error of kind.
+func IsKind(err error, kind ErrorKind) bool {
+
+	var storageErr *Error
+	return errors.As(Translate(err), &storageErr) && storageErr.Kind == kind
+}
+
+// IsSerializationFailure reports whether err aborted a transaction that can
+// succeed when run again.
+func IsSerializationFailure(err error) bool {
+
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && (pgErr.Code == sqlStateSerializationFailure || pgErr.Code == sqlStateDeadlockDetected)
+}
+
+// Translate classifies err into an *Error when it is a missing row or a
+// PostgreSQL constraint or data error, and returns it unchanged otherwise.
+func Translate(err error) error {
+
+	var storageErr *Error
+	if err == nil || errors.As(err, &storageErr) {
+		return err
+	}
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &Error{Kind: KindNotFound, Message: "record not found", Err: err}
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+
+	switch pgErr.Code {
+	case sqlStateUniqueViolation, sqlStateExclusionViolation:
+		return &Error{Kind: KindConflict, Message: constraintMessage("duplicate value violates", pgErr), Err: err}
+
+	case sqlStateForeignKeyViolation:
+		return &Error{Kind: KindForeignKey, Message: constraintMessage("referenced record is missing or still in use,", pgErr), Err: err}
+
+	case sqlStateNotNullViolation:
+		return &Error{Kind: KindValidation, Message: fmt.Sprintf("%s is required", pgErr.ColumnName), Err: err}
+
+	case sqlStateCheckViolation:
+		return &Error{Kind: KindValidation, Message: constraintMessage("value violates", pgErr), Err: err}
+
+	case sqlStateInvalidTextRepresentation, sqlStateStringDataRightTruncation,
+		sqlStateNumericValueOutOfRange, sqlStateInvalidDatetimeFormat, sqlStateDatetimeFieldOverflow:
+		return &Error{Kind: KindValidation, Message: "invalid value", Err: err}
+
+	case sqlStateSerializationFailure, sqlStateDeadlockDetected:
+		return &Error{Kind: KindConflict, Message: "concurrent update, retry the request", Err: err}
+	}
+
+	return err
+}
+
+func constraintMessage(prefix string, pgErr *pgconn.PgError) string {
+
+	if len(pgErr.ConstraintName) <= 0 {
+		return strings.TrimSuffix(prefix, ",") + " a constraint"
+	}
+
+	return fmt.Sprintf("%s constraint %s", prefix, pgErr.ConstraintName)
+}
@@ -0,0 +1,229 @@
+package memory
+
+import (
+	"app/api/models"
+	"app/storage"
+	"context"
+	"sort"
+	"time"
+)
+
+type apiKeyRepo struct {
+	store *Store
+}
+
+// apiKey is a row of the api_keys table with its sorted api_key_scopes.
+type apiKey struct {
+	models.ApiKey
+	keyHash    string
+	expiresAt  time.Time
+	lastUsedAt time.Time
+	revokedAt  time.Time
+	createdAt  time.Time
+}
+
+func (k apiKey) model() *models.ApiKey {
+
+	resp := k.ApiKey
+	resp.Scopes = append([]string{}, k.Scopes...)
+	resp.Expires_at = formatTime(k.expiresAt)
+	resp.Last_used_at = formatTime(k.lastUsedAt)
+	resp.Revoked_at = formatTime(k.revokedAt)
+	resp.CreatedAt = formatTime(k.createdAt)
+
+	return &resp
+}
+
+func (r *apiKeyRepo) CreateApiKey(ctx context.Context, req *models.CreateApiKey) (string, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	var (
+		unknown []string
+		scopes  []string
+		seen    = make(map[string]bool)
+	)
+
+	for _, scope := range req.Scopes {
+
+		if !isPermission(scope) {
+			unknown = append(unknown, scope)
+			continue
+		}
+
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+
+	if len(unknown) > 0 {
+		return "", &storage.UnknownPermissionError{Permissions: unknown}
+	}
+
+	sort.Strings(scopes)
+
+	if len(req.Created_by) > 0 {
+		_, ok := t.users[req.Created_by]
+		if err := reference(req.Created_by, ok, "api_keys_created_by_fkey"); err != nil {
+			return "", err
+		}
+	}
+
+	for _, k := range t.apiKeys {
+		if k.keyHash == req.Key_hash {
+			return "", uniqueViolation("api_keys_key_hash_key")
+		}
+	}
+
+	id := newId()
+
+	k := apiKey{
+		ApiKey: models.ApiKey{
+			Id:         id,
+			Name:       req.Name,
+			Prefix:     req.Prefix,
+			Scopes:     scopes,
+			Created_by: req.Created_by,
+		},
+		keyHash:   req.Key_hash,
+		createdAt: now(),
+	}
+
+	if len(req.Expires_at) > 0 {
+
+		expiresAt, err := parseTime(req.Expires_at)
+		if err != nil {
+			return "", err
+		}
+
+		k.expiresAt = expiresAt
+	}
+
+	t.apiKeys[id] = k
+
+	return id, nil
+}
+
+func (r *apiKeyRepo) GetByIdApiKey(ctx context.Context, req *models.ApiKeyPrimaryKey) (*models.ApiKey, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	k, ok := t.apiKeys[req.Id]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+
+	return k.model(), nil
+}
+
+func (r *apiKeyRepo) GetListApiKey(ctx context.Context, req *models.GetListApiKeyRequest) (*models.GetListApiKeyResponse, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	var (
+		rows   []apiKey
+		offset = req.Offset
+		limit  = 10
+	)
+
+	for _, k := range t.apiKeys {
+		rows = append(rows, k)
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		if !rows[i].createdAt.Equal(rows[j].createdAt) {
+			return rows[i].createdAt.After(rows[j].createdAt)
+		}
+		return rows[i].Id < rows[j].Id
+	})
+
+	if req.Limit > 0 {
+		limit = req.Limit
+	}
+
+	if offset < 0 {
+		offset = 0
+	}
+
+	resp := &models.GetListApiKeyResponse{}
+
+	if offset < len(rows) {
+
+		page := rows[offset:]
+		if len(page) > limit {
+			page = page[:limit]
+		}
+
+		for _, k := range page {
+			resp.Api_keys = append(resp.Api_keys, k.model())
+		}
+
+		resp.Count = len(rows)
+	}
+
+	return resp, nil
+}
+
+// RevokeApiKey revokes a key for good. Revoking a key twice affects no rows.
+func (r *apiKeyRepo) RevokeApiKey(ctx context.Context, req *models.ApiKeyPrimaryKey) (int64, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	k, ok := t.apiKeys[req.Id]
+	if !ok || !k.revokedAt.IsZero() {
+		return 0, nil
+	}
+
+	k.revokedAt = now()
+	t.apiKeys[req.Id] = k
+
+	return 1, nil
+}
+
+// AuthenticateApiKey looks a key up by its hash and loads the permissions of
+// its scopes. Usage of a valid key is recorded in last_used_at, at most once a
+// minute.
+func (r *apiKeyRepo) AuthenticateApiKey(ctx context.Context, keyHash string) (*models.ApiKeyAuth, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	for id, k := range t.apiKeys {
+
+		if k.keyHash != keyHash {
+			continue
+		}
+
+		at := now()
+
+		auth := &models.ApiKeyAuth{
+			Id:          k.Id,
+			Name:        k.Name,
+			Expired:     !k.expiresAt.IsZero() && !k.expiresAt.After(at),
+			Revoked:     !k.revokedAt.IsZero(),
+			Permissions: make(map[string]string),
+		}
+
+		if auth.Expired || auth.Revoked {
+			return auth, nil
+		}
+
+		for _, scope := range k.Scopes {
+			auth.Permissions[scope] = models.PermissionScopeAll
+		}
+
+		if k.lastUsedAt.IsZero() || k.lastUsedAt.Before(at.Add(-time.Minute)) {
+			k.lastUsedAt = at
+			t.apiKeys[id] = k
+		}
+
+		return auth, nil
+	}
+
+	return nil, storage.ErrNotFound
+}
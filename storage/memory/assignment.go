@@ -0,0 +1,169 @@
+package memory
+
+import (
+	"app/api/models"
+	"app/pkg/events"
+	"app/pkg/geo"
+	"app/storage"
+	"context"
+	"sort"
+	"time"
+)
+
+type courierCandidate struct {
+	id       string
+	distance float64
+	load     int
+}
+
+// nearestCourier returns the closest courier to the order that is available,
+// has reported a location recently and carries fewer open orders than the
+// configured maximum. Ties on distance go to the courier with the lighter load.
+func (s *Store) nearestCourier(t *tables, o *order) (*courierCandidate, error) {
+
+	var (
+		best   *courierCandidate
+		ids    []string
+		cutoff = time.Now().Add(-time.Duration(s.cfg.CourierLocationTTL) * time.Minute)
+	)
+
+	for id := range t.couriers {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	for _, id := range ids {
+
+		c := t.couriers[id]
+
+		if !c.deletedAt.IsZero() || !c.Is_available || c.Latitude == nil || c.Longitude == nil || c.locationUpdatedAt.Before(cutoff) {
+			continue
+		}
+
+		candidate := courierCandidate{id: id}
+
+		for _, other := range t.orders {
+			if other.Courier_id == id && other.Id != o.Id && models.OrderHoldsReservation(other.Status) {
+				candidate.load++
+			}
+		}
+
+		if candidate.load >= s.cfg.CourierMaxActiveOrders {
+			continue
+		}
+
+		candidate.distance = geo.Distance(o.point(), geo.Point{Latitude: *c.Latitude, Longitude: *c.Longitude})
+
+		if best == nil || candidate.distance < best.distance ||
+			(candidate.distance == best.distance && candidate.load < best.load) {
+			best = &candidate
+		}
+	}
+
+	if best == nil {
+		return nil, storage.ErrNoCourierAvailable
+	}
+
+	return best, nil
+}
+
+// autoAssignCourier assigns the nearest available courier to an order and
+// records the distance to them.
+func (s *Store) autoAssignCourier(t *tables, o *order) (*models.OrderCourierEvent, error) {
+
+	courier, err := s.nearestCourier(t, o)
+	if err != nil {
+		return nil, err
+	}
+
+	distance := courier.distance
+
+	o.Courier_id = courier.id
+	o.Courier_distance = &distance
+	o.Assignment_mode = models.AssignmentModeAuto
+	o.assignedAt = now()
+	o.updatedAt = o.assignedAt
+
+	return &models.OrderCourierEvent{
+		Order_id:         o.Id,
+		Courier_id:       courier.id,
+		Courier_distance: &distance,
+		Assignment_mode:  models.AssignmentModeAuto,
+	}, nil
+}
+
+// assignableCourier returns a courier picked by the client for an order.
+// Missing or deleted couriers violate orders_courier_id_fkey, and couriers
+// who are not available are storage.ErrCourierUnavailable.
+func (t *tables) assignableCourier(courierId string) (courier, error) {
+
+	c, ok := t.liveCourier(courierId)
+	if err := reference(courierId, ok, "orders_courier_id_fkey"); err != nil {
+		return c, err
+	}
+
+	if !c.Is_available {
+		return c, storage.ErrCourierUnavailable
+	}
+
+	return c, nil
+}
+
+// manualAssignCourier records a courier picked by the client. The distance is
+// only known when the courier has reported a location.
+func (t *tables) manualAssignCourier(o *order, courierId string) (*models.OrderCourierEvent, error) {
+
+	c, err := t.assignableCourier(courierId)
+	if err != nil {
+		return nil, err
+	}
+
+	var distance *float64
+
+	if c.Latitude != nil && c.Longitude != nil {
+		d := geo.Distance(o.point(), geo.Point{Latitude: *c.Latitude, Longitude: *c.Longitude})
+		distance = &d
+	}
+
+	o.Courier_id = courierId
+	o.Courier_distance = distance
+	o.Assignment_mode = models.AssignmentModeManual
+	o.assignedAt = now()
+	o.updatedAt = o.assignedAt
+
+	return &models.OrderCourierEvent{
+		Order_id:         o.Id,
+		Courier_id:       courierId,
+		Courier_distance: distance,
+		Assignment_mode:  models.AssignmentModeManual,
+	}, nil
+}
+
+func (r *orderRepo) AssignCourier(ctx context.Context, req *models.OrderPrimaryKey) (int64, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	o, ok := t.orders[req.Id]
+	if !ok {
+		return 0, nil
+	}
+
+	switch o.Status {
+	case models.OrderStatusNew, models.OrderStatusAccepted, models.OrderStatusAssigned:
+	default:
+		return 0, storage.ErrCourierAssignmentLocked
+	}
+
+	assignment, err := r.store.autoAssignCourier(t, &o)
+	if err != nil {
+		return 0, err
+	}
+
+	t.orders[req.Id] = o
+
+	r.store.publisher.Publish(events.OrderTopic(req.Id), events.OrderCourierAssigned, assignment)
+
+	return 1, nil
+}
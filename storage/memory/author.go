@@ -0,0 +1,157 @@
+package memory
+
+import (
+	"app/api/models"
+	"app/storage"
+	"context"
+	"time"
+)
+
+type authorRepo struct {
+	store *Store
+}
+
+// author is a row of the author table.
+type author struct {
+	models.Author
+	createdAt time.Time
+	deletedAt time.Time
+}
+
+func (a author) rowId() string           { return a.Id }
+func (a author) rowCreatedAt() time.Time { return a.createdAt }
+
+func (a author) column(name string) interface{} {
+
+	switch name {
+	case "name":
+		return a.Name
+	case "created_at":
+		return a.createdAt
+	}
+
+	return a.Id
+}
+
+func (a author) model() *models.Author {
+
+	resp := a.Author
+	resp.CreatedAt = formatTime(a.createdAt)
+	resp.DeletedAt = formatTime(a.deletedAt)
+
+	return &resp
+}
+
+func (r *authorRepo) CreateAuthor(ctx context.Context, req *models.CreateAuthor) (string, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	id := newId()
+
+	t.authors[id] = author{
+		Author:    models.Author{Id: id, Name: req.Name},
+		createdAt: now(),
+	}
+
+	return id, nil
+}
+
+func (r *authorRepo) AuthorGetById(ctx context.Context, req *models.AuthorPrimaryKey) (*models.Author, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	a, ok := t.authors[req.Id]
+	if !ok || (!a.deletedAt.IsZero() && !req.Include_deleted) {
+		return nil, storage.ErrNotFound
+	}
+
+	return a.model(), nil
+}
+
+func (r *authorRepo) GetListAuthor(ctx context.Context, req *models.GetListAuthorRequest) (*models.GetListAuthorResponse, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	var rows []author
+
+	for _, a := range t.authors {
+		if (a.deletedAt.IsZero() || req.Include_deleted) && matches(req.Search, a.Name) {
+			rows = append(rows, a)
+		}
+	}
+
+	page, err := list(rows, listQuery{
+		sort:        req.Sort,
+		allowed:     models.AuthorSortFields,
+		defaultSort: "name",
+		cursor:      req.Cursor,
+		offset:      req.Offset,
+		limit:       req.Limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &models.GetListAuthorResponse{
+		Count:       page.count,
+		Sort:        page.sort,
+		Next_cursor: page.nextCursor,
+	}
+
+	for _, a := range page.rows {
+		resp.Authors = append(resp.Authors, a.model())
+	}
+
+	return resp, nil
+}
+
+func (r *authorRepo) UpdateAuthor(ctx context.Context, req *models.UpdateAuthor) (int64, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	a, ok := t.liveAuthor(req.Id)
+	if !ok {
+		return 0, nil
+	}
+
+	a.Name = req.Name
+	t.authors[req.Id] = a
+
+	return 1, nil
+}
+
+func (r *authorRepo) DeleteAuthor(ctx context.Context, req *models.AuthorPrimaryKey) error {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	a, ok := t.liveAuthor(req.Id)
+	if !ok {
+		return storage.ErrNotFound
+	}
+
+	a.deletedAt = now()
+	t.authors[req.Id] = a
+
+	return nil
+}
+
+func (r *authorRepo) RestoreAuthor(ctx context.Context, req *models.AuthorPrimaryKey) error {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	a, ok := t.authors[req.Id]
+	if !ok {
+		return nil
+	}
+
+	a.deletedAt = time.Time{}
+	t.authors[req.Id] = a
+
+	return nil
+}
@@ -0,0 +1,245 @@
+package memory
+
+import (
+	"app/api/models"
+	"app/storage"
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// balanceTransaction is a row of the balance_transactions table.
+type balanceTransaction struct {
+	models.BalanceTransaction
+	createdAt time.Time
+}
+
+func (b balanceTransaction) model() *models.BalanceTransaction {
+
+	resp := b.BalanceTransaction
+	resp.CreatedAt = formatTime(b.createdAt)
+
+	return &resp
+}
+
+// addBalanceTransaction moves the balance of a user by req.Amount and records
+// the movement in the ledger together with the resulting balance. A movement
+// that would make the balance negative fails with
+// storage.InsufficientBalanceError.
+func (t *tables) addBalanceTransaction(req *models.CreateBalanceTransaction) (string, error) {
+
+	u, ok := t.users[req.User_id]
+	if !ok {
+		return "", storage.ErrNotFound
+	}
+
+	switch req.Type {
+	case models.BalanceTransactionTopUp, models.BalanceTransactionOrderDebit,
+		models.BalanceTransactionRefund, models.BalanceTransactionAdjustment:
+	default:
+		return "", checkViolation("balance_transactions_type_check")
+	}
+
+	if len(req.Order_id) > 0 {
+		_, ok := t.orders[req.Order_id]
+		if err := reference(req.Order_id, ok, "balance_transactions_order_id_fkey"); err != nil {
+			return "", err
+		}
+	}
+
+	if u.Balance+req.Amount < 0 {
+		return "", &storage.InsufficientBalanceError{
+			UserId:   req.User_id,
+			Balance:  u.Balance,
+			Required: -req.Amount,
+		}
+	}
+
+	var (
+		id = newId()
+		at = now()
+	)
+
+	u.Balance += req.Amount
+	u.updatedAt = at
+	t.users[req.User_id] = u
+
+	t.balanceTransactions[id] = balanceTransaction{
+		BalanceTransaction: models.BalanceTransaction{
+			Id:            id,
+			User_id:       req.User_id,
+			Type:          req.Type,
+			Amount:        req.Amount,
+			Balance_after: u.Balance,
+			Order_id:      req.Order_id,
+			Description:   req.Description,
+		},
+		createdAt: at,
+	}
+
+	return id, nil
+}
+
+// settleOrderPayment brings the wallet charges of an order in line with its
+// current state: the payer is charged the order price while the order is open,
+// everything is refunded once it is cancelled or returned, and any user who
+// paid for the order but is no longer its payer gets their money back.
+func (t *tables) settleOrderPayment(orderId string) error {
+
+	var (
+		o       = t.orders[orderId]
+		price   = o.Price
+		charged = make(map[string]float64)
+		users   []string
+	)
+
+	for _, b := range t.balanceTransactions {
+		if b.Order_id == orderId && (b.Type == models.BalanceTransactionOrderDebit || b.Type == models.BalanceTransactionRefund) {
+			if _, ok := charged[b.User_id]; !ok {
+				users = append(users, b.User_id)
+			}
+			charged[b.User_id] -= b.Amount
+		}
+	}
+
+	sort.Strings(users)
+
+	if o.Status == models.OrderStatusCancelled || o.Status == models.OrderStatusReturned {
+		price = 0
+	}
+
+	for _, userId := range users {
+		if userId != o.User_id && charged[userId] > 0 {
+			err := t.settleDifference(orderId, userId, -charged[userId])
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return t.settleDifference(orderId, o.User_id, price-charged[o.User_id])
+}
+
+// refundOrderPayment gives back everything users paid for an order, as when it
+// is cancelled, so that no charge is left once the order is deleted.
+func (t *tables) refundOrderPayment(orderId string) error {
+
+	charged := make(map[string]float64)
+
+	for _, b := range t.balanceTransactions {
+		if b.Order_id == orderId && (b.Type == models.BalanceTransactionOrderDebit || b.Type == models.BalanceTransactionRefund) {
+			charged[b.User_id] -= b.Amount
+		}
+	}
+
+	for userId, amount := range charged {
+		err := t.settleDifference(orderId, userId, -amount)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// settleDifference debits (diff > 0) or refunds (diff < 0) a user for an order.
+func (t *tables) settleDifference(orderId, userId string, diff float64) error {
+
+	var req = models.CreateBalanceTransaction{
+		User_id:  userId,
+		Order_id: orderId,
+	}
+
+	diff = math.Round(diff*100) / 100
+
+	switch {
+	case diff > 0:
+		req.Type = models.BalanceTransactionOrderDebit
+		req.Amount = -diff
+		req.Description = fmt.Sprintf("payment for order %s", orderId)
+	case diff < 0:
+		req.Type = models.BalanceTransactionRefund
+		req.Amount = -diff
+		req.Description = fmt.Sprintf("refund for order %s", orderId)
+	default:
+		return nil
+	}
+
+	_, err := t.addBalanceTransaction(&req)
+
+	return err
+}
+
+func (r *userRepo) CreateBalanceTransaction(ctx context.Context, req *models.CreateBalanceTransaction) (string, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	return t.addBalanceTransaction(req)
+}
+
+func (r *userRepo) GetByIdBalanceTransaction(ctx context.Context, req *models.BalanceTransactionPrimaryKey) (*models.BalanceTransaction, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	b, ok := t.balanceTransactions[req.Id]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+
+	return b.model(), nil
+}
+
+func (r *userRepo) GetListBalanceTransactions(ctx context.Context, req *models.GetListBalanceTransactionRequest) (*models.GetListBalanceTransactionResponse, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	var (
+		rows   []balanceTransaction
+		offset = req.Offset
+		limit  = 10
+	)
+
+	for _, b := range t.balanceTransactions {
+		if b.User_id == req.User_id {
+			rows = append(rows, b)
+		}
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		if !rows[i].createdAt.Equal(rows[j].createdAt) {
+			return rows[i].createdAt.After(rows[j].createdAt)
+		}
+		return rows[i].Id > rows[j].Id
+	})
+
+	if req.Limit > 0 {
+		limit = req.Limit
+	}
+
+	resp := &models.GetListBalanceTransactionResponse{}
+
+	if offset < 0 {
+		offset = 0
+	}
+
+	if offset < len(rows) {
+
+		page := rows[offset:]
+		if len(page) > limit {
+			page = page[:limit]
+		}
+
+		for _, b := range page {
+			resp.Transactions = append(resp.Transactions, b.model())
+		}
+
+		resp.Count = len(rows)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,191 @@
+package memory
+
+import (
+	"app/api/models"
+	"app/storage"
+	"context"
+	"time"
+)
+
+type bookRepo struct {
+	store *Store
+}
+
+// book is a row of the book table.
+type book struct {
+	models.Book
+	createdAt time.Time
+	updatedAt time.Time
+	deletedAt time.Time
+}
+
+func (b book) rowId() string           { return b.Id }
+func (b book) rowCreatedAt() time.Time { return b.createdAt }
+
+func (b book) column(name string) interface{} {
+
+	switch name {
+	case "name":
+		return b.Name
+	case "price":
+		return b.Price
+	case "count":
+		return b.Count
+	case "sell_price":
+		return b.Sell_price
+	case "profit":
+		return b.Profit
+	case "created_at":
+		return b.createdAt
+	case "updated_at":
+		return nullTime(b.updatedAt)
+	}
+
+	return b.Id
+}
+
+func (b book) model() *models.Book {
+
+	resp := b.Book
+	resp.CreatedAt = formatTime(b.createdAt)
+	resp.UpdatedAt = formatTime(b.updatedAt)
+	resp.DeletedAt = formatTime(b.deletedAt)
+
+	return &resp
+}
+
+func (r *bookRepo) Create(ctx context.Context, req *models.CreateBook) (string, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	var (
+		id = newId()
+		at = now()
+	)
+
+	t.books[id] = book{
+		Book: models.Book{
+			Id:            id,
+			Name:          req.Name,
+			Price:         req.Price,
+			Count:         req.Count,
+			Came_price:    req.Came_price,
+			Profit_status: req.Profit_status,
+			Profit:        req.Profit,
+			Sell_price:    req.Sell_price,
+		},
+		createdAt: at,
+		updatedAt: at,
+	}
+
+	return id, nil
+}
+
+func (r *bookRepo) GetByID(ctx context.Context, req *models.BookPrimaryKey) (*models.Book, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	b, ok := t.books[req.Id]
+	if !ok || (!b.deletedAt.IsZero() && !req.Include_deleted) {
+		return nil, storage.ErrNotFound
+	}
+
+	return b.model(), nil
+}
+
+func (r *bookRepo) GetList(ctx context.Context, req *models.GetListBookRequest) (*models.GetListBookResponse, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	var rows []book
+
+	for _, b := range t.books {
+		if (b.deletedAt.IsZero() || req.Include_deleted) && matches(req.Search, b.Name) && inRange(b.Price, req.Min_price, req.Max_price) {
+			rows = append(rows, b)
+		}
+	}
+
+	page, err := list(rows, listQuery{
+		sort:         req.Sort,
+		allowed:      models.BookSortFields,
+		defaultSort:  "name",
+		cursor:       req.Cursor,
+		offset:       req.Offset,
+		limit:        req.Limit,
+		defaultLimit: 10,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &models.GetListBookResponse{
+		Count:       page.count,
+		Sort:        page.sort,
+		Next_cursor: page.nextCursor,
+	}
+
+	for _, b := range page.rows {
+		resp.Books = append(resp.Books, b.model())
+	}
+
+	return resp, nil
+}
+
+func (r *bookRepo) Update(ctx context.Context, req *models.UpdateBook) (int64, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	b, ok := t.liveBook(req.Id)
+	if !ok {
+		return 0, nil
+	}
+
+	b.Name = req.Name
+	b.Price = req.Price
+	b.Count = req.Count
+	b.Came_price = req.Came_price
+	b.Profit_status = req.Profit_status
+	b.Profit = req.Profit
+	b.Sell_price = req.Sell_price
+	b.updatedAt = now()
+
+	t.books[req.Id] = b
+
+	return 1, nil
+}
+
+func (r *bookRepo) Delete(ctx context.Context, req *models.BookPrimaryKey) error {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	b, ok := t.liveBook(req.Id)
+	if !ok {
+		return storage.ErrNotFound
+	}
+
+	b.deletedAt = now()
+	t.books[req.Id] = b
+
+	return nil
+}
+
+func (r *bookRepo) Restore(ctx context.Context, req *models.BookPrimaryKey) error {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	b, ok := t.books[req.Id]
+	if !ok {
+		return nil
+	}
+
+	b.deletedAt = time.Time{}
+	t.books[req.Id] = b
+
+	return nil
+}
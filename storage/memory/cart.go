@@ -0,0 +1,262 @@
+package memory
+
+import (
+	"app/api/models"
+	"app/pkg/events"
+	"app/storage"
+	"context"
+	"time"
+)
+
+type cartRepo struct {
+	store *Store
+}
+
+// cart is a row of the carts table together with its cart_items rows, in the
+// order they were added.
+type cart struct {
+	id         string
+	customerId string
+	items      []cartItem
+	createdAt  time.Time
+	updatedAt  time.Time
+}
+
+type cartItem struct {
+	productId string
+	quantity  int
+}
+
+// CreateCart returns the cart of the customer, creating it on first use.
+func (r *cartRepo) CreateCart(ctx context.Context, req *models.CreateCart) (string, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	for _, c := range t.carts {
+		if c.customerId == req.Customer_id {
+			return c.id, nil
+		}
+	}
+
+	_, ok := t.liveCustomer(req.Customer_id)
+	if err := reference(req.Customer_id, ok, "carts_customer_id_fkey"); err != nil {
+		return "", err
+	}
+
+	var (
+		id = newId()
+		at = now()
+	)
+
+	t.carts[id] = cart{id: id, customerId: req.Customer_id, createdAt: at, updatedAt: at}
+
+	return id, nil
+}
+
+func (r *cartRepo) GetByIdCart(ctx context.Context, req *models.CartPrimaryKey) (*models.Cart, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	c, ok := t.carts[req.Id]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+
+	resp := &models.Cart{
+		Id:          c.id,
+		Customer_id: c.customerId,
+		CreatedAt:   formatTime(c.createdAt),
+		UpdatedAt:   formatTime(c.updatedAt),
+	}
+
+	for _, i := range c.items {
+
+		item := &models.CartItem{Product_id: i.productId, Quantity: i.quantity}
+
+		// Products that were removed from the catalog are reported separately
+		// and do not count towards the total.
+		p, ok := t.liveProduct(i.productId)
+		if !ok {
+			resp.Unavailable_items = append(resp.Unavailable_items, item)
+			continue
+		}
+
+		item.Name = p.Name
+		item.Price = p.price
+		item.Total_price = item.Price * float64(item.Quantity)
+		resp.Total_price += item.Total_price
+
+		resp.Items = append(resp.Items, item)
+	}
+
+	return resp, nil
+}
+
+// AddCartItem puts a product into the cart, adding to the quantity already
+// there. It affects no rows when the product does not exist.
+func (r *cartRepo) AddCartItem(ctx context.Context, req *models.AddCartItem) (int64, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	if _, ok := t.liveProduct(req.Product_id); !ok {
+		return 0, nil
+	}
+
+	c, ok := t.carts[req.Cart_id]
+	if err := reference(req.Cart_id, ok, "cart_items_cart_id_fkey"); err != nil {
+		return 0, err
+	}
+
+	items := append([]cartItem(nil), c.items...)
+	found := false
+
+	for i := range items {
+		if items[i].productId == req.Product_id {
+			items[i].quantity += req.Quantity
+			found = true
+		}
+	}
+
+	if !found {
+		items = append(items, cartItem{productId: req.Product_id, quantity: req.Quantity})
+	}
+
+	for _, item := range items {
+		if item.quantity <= 0 {
+			return 0, checkViolation("cart_items_quantity_check")
+		}
+	}
+
+	c.items = items
+	c.updatedAt = now()
+	t.carts[req.Cart_id] = c
+
+	return 1, nil
+}
+
+func (r *cartRepo) UpdateCartItem(ctx context.Context, req *models.UpdateCartItem) (int64, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	c, ok := t.carts[req.Cart_id]
+	if !ok {
+		return 0, nil
+	}
+
+	items := append([]cartItem(nil), c.items...)
+
+	for i := range items {
+
+		if items[i].productId != req.Product_id {
+			continue
+		}
+
+		if req.Quantity <= 0 {
+			return 0, checkViolation("cart_items_quantity_check")
+		}
+
+		items[i].quantity = req.Quantity
+
+		c.items = items
+		c.updatedAt = now()
+		t.carts[req.Cart_id] = c
+
+		return 1, nil
+	}
+
+	return 0, nil
+}
+
+func (r *cartRepo) DeleteCartItem(ctx context.Context, req *models.CartItemPrimaryKey) error {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	c, ok := t.carts[req.Cart_id]
+	if !ok {
+		return nil
+	}
+
+	var items []cartItem
+
+	for _, item := range c.items {
+		if item.productId != req.Product_id {
+			items = append(items, item)
+		}
+	}
+
+	c.items = items
+	c.updatedAt = now()
+	t.carts[req.Cart_id] = c
+
+	return nil
+}
+
+// Checkout turns the cart into an order and empties it at once. Items whose
+// product is gone make the whole checkout fail with
+// storage.UnavailableProductsError so the customer can fix the cart first.
+func (r *cartRepo) Checkout(ctx context.Context, req *models.CheckoutCart) (string, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	c, ok := t.carts[req.Cart_id]
+	if !ok {
+		return "", storage.ErrNotFound
+	}
+
+	var (
+		items       []*models.CreateOrderItem
+		unavailable []string
+	)
+
+	for _, item := range c.items {
+
+		if _, ok := t.liveProduct(item.productId); !ok {
+			unavailable = append(unavailable, item.productId)
+			continue
+		}
+
+		items = append(items, &models.CreateOrderItem{Product_id: item.productId, Quantity: item.quantity})
+	}
+
+	if len(unavailable) > 0 {
+		return "", &storage.UnavailableProductsError{ProductIds: unavailable}
+	}
+
+	if len(items) <= 0 {
+		return "", storage.ErrCartEmpty
+	}
+
+	tx := t.clone()
+
+	id, assignment, err := r.store.createOrder(tx, &models.CreateOrder{
+		Name:         req.Name,
+		Phone_number: req.Phone_number,
+		Address_id:   req.Address_id,
+		Latitude:     req.Latitude,
+		Longtitude:   req.Longtitude,
+		User_id:      req.User_id,
+		Customer_id:  c.customerId,
+		Courier_id:   req.Courier_id,
+		Items:        items,
+		Api_key_id:   req.Api_key_id,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	c.items = nil
+	c.updatedAt = now()
+	tx.carts[req.Cart_id] = c
+
+	*t = *tx
+
+	r.store.publisher.Publish(events.OrderTopic(id), events.OrderCourierAssigned, assignment)
+
+	return id, nil
+}
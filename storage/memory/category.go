@@ -0,0 +1,162 @@
+package memory
+
+import (
+	"app/api/models"
+	"app/storage"
+	"context"
+	"time"
+)
+
+type categoryRepo struct {
+	store *Store
+}
+
+// category is a row of the categories table.
+type category struct {
+	models.Category
+	createdAt time.Time
+	deletedAt time.Time
+}
+
+func (c category) rowId() string           { return c.Id }
+func (c category) rowCreatedAt() time.Time { return c.createdAt }
+
+func (c category) column(name string) interface{} {
+
+	switch name {
+	case "name":
+		return c.Name
+	case "created_at":
+		return c.createdAt
+	}
+
+	return c.Id
+}
+
+func (c category) model() *models.Category {
+
+	resp := c.Category
+	resp.CreatedAt = formatTime(c.createdAt)
+	resp.DeletedAt = formatTime(c.deletedAt)
+
+	return &resp
+}
+
+func (r *categoryRepo) CreateCategory(ctx context.Context, req *models.CreateCategory) (string, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	id := newId()
+
+	t.categories[id] = category{Category: models.Category{Id: id, Name: req.Name}, createdAt: now()}
+
+	return id, nil
+}
+
+func (r *categoryRepo) GetByIdCategory(ctx context.Context, req *models.CategoryPrimaryKey) (*models.Category, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	c, ok := t.categories[req.Id]
+	if !ok || (!c.deletedAt.IsZero() && !req.Include_deleted) {
+		return nil, storage.ErrNotFound
+	}
+
+	return c.model(), nil
+}
+
+func (r *categoryRepo) GetListCategory(ctx context.Context, req *models.GetListCatogoryRequest) (*models.GetListCategoryResponse, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	var rows []category
+
+	for _, c := range t.categories {
+		if (c.deletedAt.IsZero() || req.Include_deleted) && matches(req.Search, c.Name) {
+			rows = append(rows, c)
+		}
+	}
+
+	page, err := list(rows, listQuery{
+		sort:        req.Sort,
+		allowed:     models.CategorySortFields,
+		defaultSort: "name",
+		offset:      req.Offset,
+		limit:       req.Limit,
+		cursor:      req.Cursor,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &models.GetListCategoryResponse{
+		Count:       page.count,
+		Sort:        page.sort,
+		Next_cursor: page.nextCursor,
+	}
+
+	for _, c := range page.rows {
+		resp.Categories = append(resp.Categories, c.model())
+	}
+
+	return resp, nil
+}
+
+func (r *categoryRepo) UpdateCategory(ctx context.Context, req *models.UpdateCategory) (int64, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	c, ok := t.liveCategory(req.Id)
+	if !ok {
+		return 0, nil
+	}
+
+	c.Name = req.Name
+	t.categories[req.Id] = c
+
+	return 1, nil
+}
+
+// DeleteCategory fails while products that are not deleted reference the
+// category.
+func (r *categoryRepo) DeleteCategory(ctx context.Context, req *models.CategoryPrimaryKey) error {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	c, ok := t.liveCategory(req.Id)
+	if !ok {
+		return storage.ErrNotFound
+	}
+
+	for _, p := range t.products {
+		if p.Category_id == req.Id && p.deletedAt.IsZero() {
+			return foreignKeyViolation("products_category_id_fkey")
+		}
+	}
+
+	c.deletedAt = now()
+	t.categories[req.Id] = c
+
+	return nil
+}
+
+func (r *categoryRepo) RestoreCategory(ctx context.Context, req *models.CategoryPrimaryKey) error {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	c, ok := t.categories[req.Id]
+	if !ok {
+		return nil
+	}
+
+	c.deletedAt = time.Time{}
+	t.categories[req.Id] = c
+
+	return nil
+}
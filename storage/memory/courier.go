@@ -0,0 +1,215 @@
+package memory
+
+import (
+	"app/api/models"
+	"app/storage"
+	"context"
+	"time"
+)
+
+type courierRepo struct {
+	store *Store
+}
+
+// courier is a row of the courier table.
+type courier struct {
+	models.Courier
+	locationUpdatedAt time.Time
+	createdAt         time.Time
+	updatedAt         time.Time
+	deletedAt         time.Time
+}
+
+func (c courier) rowId() string           { return c.Id }
+func (c courier) rowCreatedAt() time.Time { return c.createdAt }
+
+func (c courier) column(name string) interface{} {
+
+	switch name {
+	case "name":
+		return c.Name
+	case "phone_number":
+		return c.Phone_number
+	case "created_at":
+		return c.createdAt
+	case "updated_at":
+		return nullTime(c.updatedAt)
+	}
+
+	return c.Id
+}
+
+func (c courier) model() *models.Courier {
+
+	resp := c.Courier
+	resp.Location_updated_at = formatTime(c.locationUpdatedAt)
+	resp.CreatedAt = formatTime(c.createdAt)
+	resp.UpdatedAt = formatTime(c.updatedAt)
+	resp.DeletedAt = formatTime(c.deletedAt)
+
+	return &resp
+}
+
+func (r *courierRepo) CreateCourier(ctx context.Context, req *models.CreateCourier) (string, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	var (
+		id = newId()
+		at = now()
+	)
+
+	c := courier{
+		Courier: models.Courier{
+			Id:           id,
+			Name:         req.Name,
+			Phone_number: req.Phone_number,
+			Latitude:     req.Latitude,
+			Longitude:    req.Longitude,
+			Is_available: true,
+		},
+		createdAt: at,
+		updatedAt: at,
+	}
+
+	if req.Latitude != nil {
+		c.locationUpdatedAt = at
+	}
+
+	if req.Is_available != nil {
+		c.Is_available = *req.Is_available
+	}
+
+	t.couriers[id] = c
+
+	return id, nil
+}
+
+func (r *courierRepo) GetByIDCourier(ctx context.Context, req *models.CourierPrimaryKey) (*models.Courier, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	c, ok := t.couriers[req.Id]
+	if !ok || (!c.deletedAt.IsZero() && !req.Include_deleted) {
+		return nil, storage.ErrNotFound
+	}
+
+	return c.model(), nil
+}
+
+func (r *courierRepo) GetListCourier(ctx context.Context, req *models.GetListCourierRequest) (*models.GetListCourierResponse, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	var rows []courier
+
+	for _, c := range t.couriers {
+
+		if !c.deletedAt.IsZero() && !req.Include_deleted {
+			continue
+		}
+
+		if !matches(req.Search, c.Name, c.Phone_number) {
+			continue
+		}
+
+		if req.Is_available != nil && c.Is_available != *req.Is_available {
+			continue
+		}
+
+		rows = append(rows, c)
+	}
+
+	page, err := list(rows, listQuery{
+		sort:        req.Sort,
+		allowed:     models.CourierSortFields,
+		defaultSort: "name",
+		cursor:      req.Cursor,
+		offset:      req.Offset,
+		limit:       req.Limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &models.GetListCourierResponse{
+		Count:       page.count,
+		Sort:        page.sort,
+		Next_cursor: page.nextCursor,
+	}
+
+	for _, c := range page.rows {
+		resp.Couriers = append(resp.Couriers, c.model())
+	}
+
+	return resp, nil
+}
+
+func (r *courierRepo) UpdateCourier(ctx context.Context, req *models.UpdateCourier) (int64, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	c, ok := t.liveCourier(req.Id)
+	if !ok {
+		return 0, nil
+	}
+
+	c.Name = req.Name
+	c.Phone_number = req.Phone_number
+	c.updatedAt = now()
+
+	if req.Latitude != nil {
+		c.Latitude = req.Latitude
+		c.locationUpdatedAt = c.updatedAt
+	}
+
+	if req.Longitude != nil {
+		c.Longitude = req.Longitude
+	}
+
+	if req.Is_available != nil {
+		c.Is_available = *req.Is_available
+	}
+
+	t.couriers[req.Id] = c
+
+	return 1, nil
+}
+
+// DeleteCourier soft deletes the courier, who then gets no new orders and
+// can no longer report a location. Orders already assigned keep them.
+func (r *courierRepo) DeleteCourier(ctx context.Context, req *models.CourierPrimaryKey) error {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	c, ok := t.liveCourier(req.Id)
+	if !ok {
+		return storage.ErrNotFound
+	}
+
+	c.deletedAt = now()
+	t.couriers[req.Id] = c
+
+	return nil
+}
+
+func (r *courierRepo) RestoreCourier(ctx context.Context, req *models.CourierPrimaryKey) error {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	c, ok := t.couriers[req.Id]
+	if !ok {
+		return nil
+	}
+
+	c.deletedAt = time.Time{}
+	t.couriers[req.Id] = c
+
+	return nil
+}
@@ -0,0 +1,160 @@
+package memory
+
+import (
+	"app/api/models"
+	"app/pkg/events"
+	"context"
+	"sort"
+	"time"
+)
+
+// courierLocation is a row of the courier_locations table. id orders points
+// recorded at the same time, like the BIGSERIAL column.
+type courierLocation struct {
+	models.CourierLocation
+	id         int
+	recordedAt time.Time
+}
+
+func (l courierLocation) model() *models.CourierLocation {
+
+	resp := l.CourierLocation
+	resp.Recorded_at = formatTime(l.recordedAt)
+
+	return &resp
+}
+
+func (r *courierRepo) CreateCourierLocation(ctx context.Context, req *models.CreateCourierLocation) (int64, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	c, ok := t.liveCourier(req.Courier_id)
+	if !ok {
+		return 0, nil
+	}
+
+	recordedAt := now()
+
+	if len(req.Recorded_at) > 0 {
+
+		parsed, err := parseTime(req.Recorded_at)
+		if err != nil {
+			return 0, err
+		}
+
+		recordedAt = parsed
+	}
+
+	t.courierLocations = append(t.courierLocations, courierLocation{
+		CourierLocation: models.CourierLocation{
+			Courier_id: req.Courier_id,
+			Latitude:   req.Latitude,
+			Longitude:  req.Longitude,
+		},
+		id:         len(t.courierLocations) + 1,
+		recordedAt: recordedAt,
+	})
+
+	// Points can arrive out of order from a phone that was offline for a
+	// while, so the last known location only moves forward in time.
+	if c.locationUpdatedAt.IsZero() || !c.locationUpdatedAt.After(recordedAt) {
+		latitude, longitude := req.Latitude, req.Longitude
+		c.Latitude, c.Longitude = &latitude, &longitude
+		c.locationUpdatedAt = recordedAt
+		t.couriers[req.Courier_id] = c
+	}
+
+	location := &models.CourierLocation{
+		Courier_id:  req.Courier_id,
+		Latitude:    req.Latitude,
+		Longitude:   req.Longitude,
+		Recorded_at: req.Recorded_at,
+	}
+
+	if len(location.Recorded_at) <= 0 {
+		location.Recorded_at = time.Now().Format(time.RFC3339)
+	}
+
+	r.store.publisher.Publish(events.CourierTopic(req.Courier_id), events.CourierLocationUpdated, location)
+
+	return 1, nil
+}
+
+// GetCourierLocation returns the latest reported point of a courier, or nil
+// when the courier has not reported any location yet.
+func (r *courierRepo) GetCourierLocation(ctx context.Context, req *models.CourierPrimaryKey) (*models.CourierLocation, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	var latest *courierLocation
+
+	for i, l := range t.courierLocations {
+
+		if l.Courier_id != req.Id {
+			continue
+		}
+
+		if latest == nil || l.recordedAt.After(latest.recordedAt) ||
+			(l.recordedAt.Equal(latest.recordedAt) && l.id > latest.id) {
+			latest = &t.courierLocations[i]
+		}
+	}
+
+	if latest == nil {
+		return nil, nil
+	}
+
+	return latest.model(), nil
+}
+
+func (r *courierRepo) GetCourierTrack(ctx context.Context, req *models.GetCourierTrackRequest) (*models.GetCourierTrackResponse, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	from, err := parseTime(req.From)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := parseTime(req.To)
+	if err != nil {
+		return nil, err
+	}
+
+	var points []courierLocation
+
+	for _, l := range t.courierLocations {
+		if l.Courier_id == req.Courier_id && !l.recordedAt.Before(from) && !l.recordedAt.After(to) {
+			points = append(points, l)
+		}
+	}
+
+	sort.SliceStable(points, func(i, j int) bool {
+		if !points[i].recordedAt.Equal(points[j].recordedAt) {
+			return points[i].recordedAt.Before(points[j].recordedAt)
+		}
+		return points[i].id < points[j].id
+	})
+
+	limit := 1000
+	if req.Limit > 0 {
+		limit = req.Limit
+	}
+
+	if len(points) > limit {
+		points = points[:limit]
+	}
+
+	resp := &models.GetCourierTrackResponse{}
+
+	for _, l := range points {
+		resp.Points = append(resp.Points, l.model())
+	}
+
+	resp.Count = len(resp.Points)
+
+	return resp, nil
+}
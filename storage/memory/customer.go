@@ -0,0 +1,184 @@
+package memory
+
+import (
+	"app/api/models"
+	"app/storage"
+	"context"
+	"time"
+)
+
+type customerRepo struct {
+	store *Store
+}
+
+// customer is a row of the customers table.
+type customer struct {
+	models.Customer
+	phoneVerifiedAt time.Time
+	createdAt       time.Time
+	updatedAt       time.Time
+	deletedAt       time.Time
+}
+
+func (c customer) rowId() string           { return c.Id }
+func (c customer) rowCreatedAt() time.Time { return c.createdAt }
+
+func (c customer) column(name string) interface{} {
+
+	switch name {
+	case "name":
+		return c.Name
+	case "phone":
+		return c.Phone
+	case "created_at":
+		return c.createdAt
+	case "updated_at":
+		return nullTime(c.updatedAt)
+	}
+
+	return c.Id
+}
+
+func (c customer) model() *models.Customer {
+
+	resp := c.Customer
+	resp.Phone_verified_at = formatTime(c.phoneVerifiedAt)
+	resp.CreatedAt = formatTime(c.createdAt)
+	resp.UpdatedAt = formatTime(c.updatedAt)
+	resp.DeletedAt = formatTime(c.deletedAt)
+
+	return &resp
+}
+
+func (t *tables) insertCustomer(name, phone string) string {
+
+	var (
+		id = newId()
+		at = now()
+	)
+
+	t.customers[id] = customer{
+		Customer:  models.Customer{Id: id, Name: name, Phone: phone},
+		createdAt: at,
+		updatedAt: at,
+	}
+
+	return id
+}
+
+func (r *customerRepo) CreateCustomer(ctx context.Context, req *models.CreateCustomer) (string, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	return t.insertCustomer(req.Name, req.Phone), nil
+}
+
+func (r *customerRepo) GetByIdCustomer(ctx context.Context, req *models.CustomerPrimaryKey) (*models.Customer, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	c, ok := t.customers[req.Id]
+	if !ok || (!c.deletedAt.IsZero() && !req.Include_deleted) {
+		return nil, storage.ErrNotFound
+	}
+
+	return c.model(), nil
+}
+
+func (r *customerRepo) GetListCustomer(ctx context.Context, req *models.GetListCustomerRequest) (*models.GetListCustomerResponse, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	var rows []customer
+
+	for _, c := range t.customers {
+		if (c.deletedAt.IsZero() || req.Include_deleted) && matches(req.Search, c.Name, c.Phone) {
+			rows = append(rows, c)
+		}
+	}
+
+	page, err := list(rows, listQuery{
+		sort:        req.Sort,
+		allowed:     models.CustomerSortFields,
+		defaultSort: "name",
+		cursor:      req.Cursor,
+		offset:      req.Offset,
+		limit:       req.Limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &models.GetListCustomerResponse{
+		Count:       page.count,
+		Sort:        page.sort,
+		Next_cursor: page.nextCursor,
+	}
+
+	for _, c := range page.rows {
+		resp.Customers = append(resp.Customers, c.model())
+	}
+
+	return resp, nil
+}
+
+// UpdateCustomer clears the phone verification when the phone changes.
+func (r *customerRepo) UpdateCustomer(ctx context.Context, req *models.UpdateCustomer) (int64, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	c, ok := t.liveCustomer(req.Id)
+	if !ok {
+		return 0, nil
+	}
+
+	if c.Phone != req.Phone {
+		c.phoneVerifiedAt = time.Time{}
+	}
+
+	c.Name = req.Name
+	c.Phone = req.Phone
+	c.updatedAt = now()
+
+	t.customers[req.Id] = c
+
+	return 1, nil
+}
+
+// DeleteCustomer soft deletes the customer, keeping their orders, carts and
+// addresses for when they are restored.
+func (r *customerRepo) DeleteCustomer(ctx context.Context, req *models.CustomerPrimaryKey) error {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	c, ok := t.liveCustomer(req.Id)
+	if !ok {
+		return storage.ErrNotFound
+	}
+
+	c.deletedAt = now()
+	t.customers[req.Id] = c
+
+	return nil
+}
+
+func (r *customerRepo) RestoreCustomer(ctx context.Context, req *models.CustomerPrimaryKey) error {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	c, ok := t.customers[req.Id]
+	if !ok {
+		return nil
+	}
+
+	c.deletedAt = time.Time{}
+	t.customers[req.Id] = c
+
+	return nil
+}
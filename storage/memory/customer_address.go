@@ -0,0 +1,257 @@
+package memory
+
+import (
+	"app/api/models"
+	"app/storage"
+	"context"
+	"sort"
+	"time"
+)
+
+type customerAddressRepo struct {
+	store *Store
+}
+
+// customerAddress is a row of the customer_addresses table.
+type customerAddress struct {
+	models.CustomerAddress
+	createdAt time.Time
+	updatedAt time.Time
+}
+
+func (a customerAddress) model() *models.CustomerAddress {
+
+	resp := a.CustomerAddress
+	resp.CreatedAt = formatTime(a.createdAt)
+	resp.UpdatedAt = formatTime(a.updatedAt)
+
+	return &resp
+}
+
+// addresses returns the addresses of a customer, oldest first.
+func (t *tables) addresses(customerId string) []customerAddress {
+
+	var addresses []customerAddress
+
+	for _, a := range t.customerAddresses {
+		if a.Customer_id == customerId {
+			addresses = append(addresses, a)
+		}
+	}
+
+	sort.Slice(addresses, func(i, j int) bool {
+		if !addresses[i].createdAt.Equal(addresses[j].createdAt) {
+			return addresses[i].createdAt.Before(addresses[j].createdAt)
+		}
+		return addresses[i].Id < addresses[j].Id
+	})
+
+	return addresses
+}
+
+func (t *tables) clearDefaultAddress(customerId string, at time.Time) {
+
+	for _, a := range t.addresses(customerId) {
+		if a.Is_default {
+			a.Is_default = false
+			a.updatedAt = at
+			t.customerAddresses[a.Id] = a
+		}
+	}
+}
+
+// deleteCustomerAddress removes an address. Orders delivered to it keep their
+// snapshot of the address but lose the reference to it.
+func (t *tables) deleteCustomerAddress(id string) {
+
+	for orderId, o := range t.orders {
+		if o.Address_id == id {
+			o.Address_id = ""
+			t.orders[orderId] = o
+		}
+	}
+
+	delete(t.customerAddresses, id)
+}
+
+// CreateCustomerAddress stores a new address. The first address of a customer
+// becomes their default, and a new default replaces the previous one.
+func (r *customerAddressRepo) CreateCustomerAddress(ctx context.Context, req *models.CreateCustomerAddress) (string, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	_, ok := t.liveCustomer(req.Customer_id)
+	if err := reference(req.Customer_id, ok, "customer_addresses_customer_id_fkey"); err != nil {
+		return "", err
+	}
+
+	var (
+		id         = newId()
+		at         = now()
+		hasDefault bool
+	)
+
+	for _, a := range t.addresses(req.Customer_id) {
+		hasDefault = hasDefault || a.Is_default
+	}
+
+	isDefault := req.Is_default || !hasDefault
+
+	if isDefault {
+		t.clearDefaultAddress(req.Customer_id, at)
+	}
+
+	t.customerAddresses[id] = customerAddress{
+		CustomerAddress: models.CustomerAddress{
+			Id:          id,
+			Customer_id: req.Customer_id,
+			Label:       req.Label,
+			Street:      req.Street,
+			Apartment:   req.Apartment,
+			Latitude:    req.Latitude,
+			Longitude:   req.Longitude,
+			Is_default:  isDefault,
+		},
+		createdAt: at,
+		updatedAt: at,
+	}
+
+	return id, nil
+}
+
+func (r *customerAddressRepo) GetByIdCustomerAddress(ctx context.Context, req *models.CustomerAddressPrimaryKey) (*models.CustomerAddress, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	a, ok := t.customerAddresses[req.Id]
+	if !ok || a.Customer_id != req.Customer_id {
+		return nil, storage.ErrNotFound
+	}
+
+	return a.model(), nil
+}
+
+// GetListCustomerAddress returns every address of a customer, default first.
+func (r *customerAddressRepo) GetListCustomerAddress(ctx context.Context, req *models.GetListCustomerAddressRequest) (*models.GetListCustomerAddressResponse, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	addresses := t.addresses(req.Customer_id)
+
+	sort.SliceStable(addresses, func(i, j int) bool {
+		return addresses[i].Is_default && !addresses[j].Is_default
+	})
+
+	resp := &models.GetListCustomerAddressResponse{}
+
+	for _, a := range addresses {
+		resp.Addresses = append(resp.Addresses, a.model())
+	}
+
+	resp.Count = len(resp.Addresses)
+
+	return resp, nil
+}
+
+func (r *customerAddressRepo) UpdateCustomerAddress(ctx context.Context, req *models.UpdateCustomerAddress) (int64, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	a, ok := t.customerAddresses[req.Id]
+	if !ok || a.Customer_id != req.Customer_id {
+		return 0, nil
+	}
+
+	at := now()
+
+	if req.Is_default {
+		t.clearDefaultAddress(req.Customer_id, at)
+	}
+
+	a.Label = req.Label
+	a.Street = req.Street
+	a.Apartment = req.Apartment
+	a.Latitude = req.Latitude
+	a.Longitude = req.Longitude
+	a.Is_default = a.Is_default || req.Is_default
+	a.updatedAt = at
+
+	t.customerAddresses[req.Id] = a
+
+	return 1, nil
+}
+
+// DeleteCustomerAddress removes an address. If it was the default, the oldest
+// remaining address of the customer becomes the default.
+func (r *customerAddressRepo) DeleteCustomerAddress(ctx context.Context, req *models.CustomerAddressPrimaryKey) error {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	a, ok := t.customerAddresses[req.Id]
+	if !ok || a.Customer_id != req.Customer_id {
+		return nil
+	}
+
+	t.deleteCustomerAddress(req.Id)
+
+	remaining := t.addresses(req.Customer_id)
+
+	for _, a := range remaining {
+		if a.Is_default {
+			return nil
+		}
+	}
+
+	if len(remaining) > 0 {
+		oldest := remaining[0]
+		oldest.Is_default = true
+		oldest.updatedAt = now()
+		t.customerAddresses[oldest.Id] = oldest
+	}
+
+	return nil
+}
+
+// orderAddress is the snapshot of a customer address stored on an order.
+type orderAddress struct {
+	Id        string
+	Street    string
+	Apartment string
+}
+
+// resolveOrderAddress loads the address an order references and copies its
+// coordinates into latitude and longtitude. When the order has no phone number
+// the customer's phone is used. Orders without an address keep their raw
+// coordinates and get an empty snapshot.
+func (t *tables) resolveOrderAddress(addressId, customerId string, latitude, longtitude *float64, phone *string) (*orderAddress, error) {
+
+	address := &orderAddress{}
+
+	if len(addressId) <= 0 {
+		return address, nil
+	}
+
+	a, ok := t.customerAddresses[addressId]
+	if !ok || a.Customer_id != customerId {
+		return nil, storage.ErrAddressNotFound
+	}
+
+	c, ok := t.customers[customerId]
+	if !ok {
+		return nil, storage.ErrAddressNotFound
+	}
+
+	address.Id, address.Street, address.Apartment = a.Id, a.Street, a.Apartment
+	*latitude, *longtitude = a.Latitude, a.Longitude
+
+	if len(*phone) <= 0 {
+		*phone = c.Phone
+	}
+
+	return address, nil
+}
@@ -0,0 +1,264 @@
+package memory
+
+import (
+	"app/api/models"
+	"app/pkg/geo"
+	"app/storage"
+	"context"
+	"errors"
+	"sort"
+	"time"
+)
+
+type deliveryZoneRepo struct {
+	store *Store
+}
+
+// deliveryZone is a row of the delivery_zones table.
+type deliveryZone struct {
+	models.DeliveryZone
+	createdAt time.Time
+	updatedAt time.Time
+}
+
+func (z deliveryZone) rowId() string           { return z.Id }
+func (z deliveryZone) rowCreatedAt() time.Time { return z.createdAt }
+
+func (z deliveryZone) column(name string) interface{} {
+
+	switch name {
+	case "name":
+		return z.Name
+	case "delivery_fee":
+		return nullFloat(z.Delivery_fee)
+	case "min_order":
+		return z.Min_order
+	case "created_at":
+		return z.createdAt
+	case "updated_at":
+		return nullTime(z.updatedAt)
+	}
+
+	return z.Id
+}
+
+func (z deliveryZone) model() *models.DeliveryZone {
+
+	resp := z.DeliveryZone
+	resp.CreatedAt = formatTime(z.createdAt)
+	resp.UpdatedAt = formatTime(z.updatedAt)
+
+	return &resp
+}
+
+// set validates and stores the editable columns of a zone.
+func (z *deliveryZone) set(name string, area models.GeoJSONPolygon, fee *float64, minOrder float64, opensAt, closesAt string) error {
+
+	var err error
+
+	if fee != nil && *fee < 0 {
+		return checkViolation("delivery_zones_delivery_fee_check")
+	}
+
+	if minOrder < 0 {
+		return checkViolation("delivery_zones_min_order_check")
+	}
+
+	z.Opens_at, err = parseClock(opensAt)
+	if err != nil {
+		return err
+	}
+
+	z.Closes_at, err = parseClock(closesAt)
+	if err != nil {
+		return err
+	}
+
+	if fee != nil {
+		f := *fee
+		fee = &f
+	}
+
+	z.Name = name
+	z.Area = area
+	z.Delivery_fee = fee
+	z.Min_order = minOrder
+
+	return nil
+}
+
+// parseClock parses a TIME column formatted as HH24:MI, "" for NULL.
+func parseClock(value string) (string, error) {
+
+	if len(value) <= 0 {
+		return "", nil
+	}
+
+	for _, layout := range []string{"15:04", "15:04:05"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.Format("15:04"), nil
+		}
+	}
+
+	return "", invalidValue(errors.New("invalid input syntax for type time: " + value))
+}
+
+func (r *deliveryZoneRepo) CreateDeliveryZone(ctx context.Context, req *models.CreateDeliveryZone) (string, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	var (
+		id = newId()
+		at = now()
+	)
+
+	z := deliveryZone{
+		DeliveryZone: models.DeliveryZone{Id: id, Is_active: true},
+		createdAt:    at,
+		updatedAt:    at,
+	}
+
+	err := z.set(req.Name, req.Area, req.Delivery_fee, req.Min_order, req.Opens_at, req.Closes_at)
+	if err != nil {
+		return "", err
+	}
+
+	if req.Is_active != nil {
+		z.Is_active = *req.Is_active
+	}
+
+	t.deliveryZones[id] = z
+
+	return id, nil
+}
+
+func (r *deliveryZoneRepo) GetByIdDeliveryZone(ctx context.Context, req *models.DeliveryZonePrimaryKey) (*models.DeliveryZone, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	z, ok := t.deliveryZones[req.Id]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+
+	return z.model(), nil
+}
+
+func (r *deliveryZoneRepo) GetListDeliveryZone(ctx context.Context, req *models.GetListDeliveryZoneRequest) (*models.GetListDeliveryZoneResponse, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	var rows []deliveryZone
+
+	for _, z := range t.deliveryZones {
+
+		if !matches(req.Search, z.Name) {
+			continue
+		}
+
+		if req.Is_active != nil && z.Is_active != *req.Is_active {
+			continue
+		}
+
+		rows = append(rows, z)
+	}
+
+	page, err := list(rows, listQuery{
+		sort:         req.Sort,
+		allowed:      models.DeliveryZoneSortFields,
+		defaultSort:  "name",
+		cursor:       req.Cursor,
+		offset:       req.Offset,
+		limit:        req.Limit,
+		defaultLimit: 10,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &models.GetListDeliveryZoneResponse{
+		Count:       page.count,
+		Sort:        page.sort,
+		Next_cursor: page.nextCursor,
+	}
+
+	for _, z := range page.rows {
+		resp.Zones = append(resp.Zones, z.model())
+	}
+
+	return resp, nil
+}
+
+func (r *deliveryZoneRepo) UpdateDeliveryZone(ctx context.Context, req *models.UpdateDeliveryZone) (int64, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	z, ok := t.deliveryZones[req.Id]
+	if !ok {
+		return 0, nil
+	}
+
+	err := z.set(req.Name, req.Area, req.Delivery_fee, req.Min_order, req.Opens_at, req.Closes_at)
+	if err != nil {
+		return 0, err
+	}
+
+	if req.Is_active != nil {
+		z.Is_active = *req.Is_active
+	}
+
+	z.updatedAt = now()
+	t.deliveryZones[req.Id] = z
+
+	return 1, nil
+}
+
+// DeleteDeliveryZone deletes a zone. Orders placed in it keep their fee but
+// lose the reference to the zone.
+func (r *deliveryZoneRepo) DeleteDeliveryZone(ctx context.Context, req *models.DeliveryZonePrimaryKey) error {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	for id, o := range t.orders {
+		if o.Delivery_zone_id == req.Id {
+			o.Delivery_zone_id = ""
+			t.orders[id] = o
+		}
+	}
+
+	delete(t.deliveryZones, req.Id)
+
+	return nil
+}
+
+func (r *deliveryZoneRepo) QuoteDelivery(ctx context.Context, point geo.Point) (*models.DeliveryQuote, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	quote, _, err := r.store.quoteDelivery(t, point)
+
+	return quote, err
+}
+
+// quoteDelivery prices delivery to point against the active delivery zones,
+// as described by storage.QuoteDelivery.
+func (s *Store) quoteDelivery(t *tables, point geo.Point) (*models.DeliveryQuote, *models.DeliveryZone, error) {
+
+	var zones []*models.DeliveryZone
+
+	for _, z := range t.deliveryZones {
+		if z.Is_active {
+			zones = append(zones, z.model())
+		}
+	}
+
+	sort.Slice(zones, func(i, j int) bool { return zones[i].Name < zones[j].Name })
+
+	return storage.QuoteDelivery(s.delivery, zones, point, time.Now())
+}
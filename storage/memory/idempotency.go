@@ -0,0 +1,107 @@
+package memory
+
+import (
+	"app/api/models"
+	"app/storage"
+	"context"
+	"time"
+)
+
+type idempotencyRepo struct {
+	store *Store
+}
+
+// idempotencyOwnerKey is the primary key of the idempotency_keys table.
+type idempotencyOwnerKey struct {
+	owner string
+	key   string
+}
+
+// idempotencyRecord is a row of the idempotency_keys table. response is nil
+// while the request is still being processed.
+type idempotencyRecord struct {
+	requestHash string
+	response    *models.IdempotentResponse
+	expiresAt   time.Time
+}
+
+// BeginIdempotentRequest reserves req.Key for its owner. It returns nil when
+// the request is new and must be processed, or the stored response when the
+// key was already used for the same request. Expired keys of every owner are
+// purged first.
+func (r *idempotencyRepo) BeginIdempotentRequest(ctx context.Context, req *models.IdempotencyKey) (*models.IdempotentResponse, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	var (
+		key = idempotencyOwnerKey{owner: req.Owner, key: req.Key}
+		at  = now()
+	)
+
+	for k, record := range t.idempotencyKeys {
+		if !record.expiresAt.After(at) {
+			delete(t.idempotencyKeys, k)
+		}
+	}
+
+	record, ok := t.idempotencyKeys[key]
+	if !ok {
+		t.idempotencyKeys[key] = idempotencyRecord{
+			requestHash: req.Request_hash,
+			expiresAt:   at.Add(time.Duration(r.store.cfg.IdempotencyTTL) * time.Hour),
+		}
+		return nil, nil
+	}
+
+	if record.requestHash != req.Request_hash {
+		return nil, storage.ErrIdempotencyKeyReused
+	}
+
+	if record.response == nil {
+		return nil, storage.ErrIdempotencyKeyInProgress
+	}
+
+	resp := *record.response
+
+	return &resp, nil
+}
+
+// FinishIdempotentRequest stores the response of a reserved request so that
+// retries replay it.
+func (r *idempotencyRepo) FinishIdempotentRequest(ctx context.Context, req *models.IdempotencyKey, resp *models.IdempotentResponse) error {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	key := idempotencyOwnerKey{owner: req.Owner, key: req.Key}
+
+	record, ok := t.idempotencyKeys[key]
+	if !ok {
+		return nil
+	}
+
+	stored := *resp
+	stored.Body = append([]byte(nil), resp.Body...)
+	record.response = &stored
+
+	t.idempotencyKeys[key] = record
+
+	return nil
+}
+
+// ReleaseIdempotentRequest drops the reservation of a request that failed, so
+// it can be retried with the same key.
+func (r *idempotencyRepo) ReleaseIdempotentRequest(ctx context.Context, req *models.IdempotencyKey) error {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	key := idempotencyOwnerKey{owner: req.Owner, key: req.Key}
+
+	if record, ok := t.idempotencyKeys[key]; ok && record.response == nil {
+		delete(t.idempotencyKeys, key)
+	}
+
+	return nil
+}
@@ -0,0 +1,223 @@
+package memory
+
+import (
+	"app/api/models"
+	"app/storage"
+	"sort"
+	"strings"
+	"time"
+)
+
+// row is a record of a table that can be listed.
+type row interface {
+	rowId() string
+	rowCreatedAt() time.Time
+	// column returns the value of a sortable column, nil for NULL.
+	column(name string) interface{}
+}
+
+// listQuery is how a list is ordered and paged, as in the PostgreSQL
+// repositories.
+type listQuery struct {
+	sort        []models.SortField
+	allowed     []string
+	defaultSort string
+	cursor      *models.Cursor
+	offset      int
+	limit       int
+	// defaultLimit applies when limit is not positive.
+	defaultLimit int
+}
+
+// listResult is a page of rows. count is the number of rows matching the
+// filters, and 0 when the page is empty, as with COUNT(*) OVER().
+type listResult[T row] struct {
+	rows       []T
+	count      int
+	sort       string
+	nextCursor string
+}
+
+// list orders and pages rows that already passed the filters of a list. Rows
+// are ordered by the requested fields with id breaking ties, or newest first
+// with a cursor, which then continues after the last row of the page.
+func list[T row](rows []T, q listQuery) (*listResult[T], error) {
+
+	var (
+		result = &listResult[T]{}
+		fields []models.SortField
+		offset = q.offset
+		limit  = q.defaultLimit
+	)
+
+	_, applied, err := storage.OrderBy(q.sort, q.allowed, q.defaultSort)
+	if err != nil {
+		return nil, err
+	}
+
+	fields = q.sort
+	if len(fields) <= 0 {
+		fields, _ = models.ParseSort(q.defaultSort, q.allowed)
+	}
+	fields = append(fields[:len(fields):len(fields)], models.SortField{Field: "id"})
+
+	if q.limit > 0 {
+		limit = q.limit
+	}
+
+	if q.cursor != nil {
+
+		applied, offset, limit = storage.KeysetOrder, 0, q.limit+1
+		fields = []models.SortField{{Field: "created_at", Desc: true}, {Field: "id", Desc: true}}
+
+		if !q.cursor.IsZero() {
+			rows = afterCursor(rows, q.cursor)
+		}
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		for _, field := range fields {
+			if c := compare(rows[i].column(field.Field), rows[j].column(field.Field)); c != 0 {
+				return (c < 0) != field.Desc
+			}
+		}
+		return false
+	})
+
+	result.sort = applied
+
+	if offset < len(rows) {
+		result.rows = rows[offset:]
+		if len(result.rows) > limit {
+			result.rows = result.rows[:limit]
+		}
+	}
+
+	if len(result.rows) > 0 {
+		result.count = len(rows)
+	}
+
+	if q.cursor != nil && len(result.rows) > q.limit {
+		result.rows = result.rows[:q.limit]
+
+		last := result.rows[q.limit-1]
+		result.nextCursor = (&models.Cursor{Created_at: last.rowCreatedAt(), Id: last.rowId()}).String()
+	}
+
+	return result, nil
+}
+
+// afterCursor keeps the rows where (created_at, id) < (cursor.Created_at, cursor.Id).
+func afterCursor[T row](rows []T, cursor *models.Cursor) []T {
+
+	var kept []T
+
+	for _, r := range rows {
+		createdAt := r.rowCreatedAt()
+		if createdAt.Before(cursor.Created_at) || (createdAt.Equal(cursor.Created_at) && r.rowId() < cursor.Id) {
+			kept = append(kept, r)
+		}
+	}
+
+	return kept
+}
+
+// compare orders two column values. NULL sorts after every value, as it does
+// in PostgreSQL.
+func compare(a, b interface{}) int {
+
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	}
+
+	switch a := a.(type) {
+	case string:
+		return strings.Compare(a, b.(string))
+	case float64:
+		return compareFloat(a, b.(float64))
+	case int:
+		return compareFloat(float64(a), float64(b.(int)))
+	case bool:
+		return compareFloat(boolToFloat(a), boolToFloat(b.(bool)))
+	case time.Time:
+		switch {
+		case a.Before(b.(time.Time)):
+			return -1
+		case a.After(b.(time.Time)):
+			return 1
+		}
+	}
+
+	return 0
+}
+
+func compareFloat(a, b float64) int {
+
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+
+	return 0
+}
+
+func boolToFloat(b bool) float64 {
+
+	if b {
+		return 1
+	}
+
+	return 0
+}
+
+// nullTime is t as a column value, nil when t is the zero time.
+func nullTime(t time.Time) interface{} {
+
+	if t.IsZero() {
+		return nil
+	}
+
+	return t
+}
+
+// nullFloat is f as a column value, nil when f is nil.
+func nullFloat(f *float64) interface{} {
+
+	if f == nil {
+		return nil
+	}
+
+	return *f
+}
+
+// matches reports whether any of values contains search, case-insensitively,
+// like Filter.Search. An empty search matches everything.
+func matches(search string, values ...string) bool {
+
+	if len(search) <= 0 {
+		return true
+	}
+
+	search = strings.ToLower(search)
+
+	for _, value := range values {
+		if strings.Contains(strings.ToLower(value), search) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// inRange reports whether value lies between from and to, both inclusive. A
+// zero bound is left open, like Filter.Range.
+func inRange(value, from, to float64) bool {
+	return (from == 0 || value >= from) && (to == 0 || value <= to)
+}
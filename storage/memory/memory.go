@@ -0,0 +1,324 @@
+// Package memory implements storage.StorageI in process memory. It follows
+// the semantics of the PostgreSQL storage, including search, paging, foreign
+// keys and not-found errors, so the API can run without a database for local
+// demos and tests. Nothing is persisted.
+package memory
+
+import (
+	"app/api/models"
+	"app/config"
+	"app/pkg/delivery"
+	"app/pkg/events"
+	"app/pkg/geo"
+	"app/pkg/helper"
+	"app/storage"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Store keeps every table in maps guarded by one mutex, so each repository
+// call is atomic.
+type Store struct {
+	mu   sync.Mutex
+	data *tables
+	cfg  *config.Config
+	// delivery prices deliveries from the configured store location.
+	delivery *delivery.Calculator
+	events   *events.Bus
+	// publisher is events, or a buffer flushed when the transaction commits.
+	publisher events.Publisher
+}
+
+// tables holds the rows of every table. Rows are stored by value and the
+// slices they hold are never modified in place, so copying the maps is enough
+// to snapshot the database for a transaction.
+type tables struct {
+	books               map[string]book
+	users               map[string]user
+	balanceTransactions map[string]balanceTransaction
+	authors             map[string]author
+	customers           map[string]customer
+	couriers            map[string]courier
+	courierLocations    []courierLocation
+	products            map[string]product
+	categories          map[string]category
+	orders              map[string]order
+	statusHistory       []statusHistory
+	carts               map[string]cart
+	deliveryZones       map[string]deliveryZone
+	customerAddresses   map[string]customerAddress
+	otps                map[string]otp
+	apiKeys             map[string]apiKey
+	idempotencyKeys     map[idempotencyOwnerKey]idempotencyRecord
+}
+
+// NewStore returns an empty store with the roles and permissions of the
+// migrations. When cfg sets MemoryAdminLogin, an administrator with that
+// login and MemoryAdminPassword is created so the API can be used at once.
+func NewStore(cfg *config.Config) (storage.StorageI, error) {
+
+	bus := events.NewBus(cfg.EventBufferSize)
+
+	store := &Store{
+		data:      newTables(),
+		cfg:       cfg,
+		delivery:  delivery.NewCalculator(geo.Point{Latitude: cfg.StoreLatitude, Longitude: cfg.StoreLongitude}, cfg.DeliveryTiers),
+		events:    bus,
+		publisher: bus,
+	}
+
+	if len(cfg.MemoryAdminLogin) > 0 {
+
+		hash, err := helper.HashPassword(cfg.MemoryAdminPassword)
+		if err != nil {
+			return nil, err
+		}
+
+		ctx := context.Background()
+
+		id, err := store.User().CreateUser(ctx, &models.CreateUser{
+			Name:          cfg.MemoryAdminLogin,
+			Login:         cfg.MemoryAdminLogin,
+			Password_hash: hash,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = store.Role().AssignUserRole(ctx, &models.AssignUserRole{User_id: id, Role: "admin"})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return store, nil
+}
+
+func newTables() *tables {
+	return &tables{
+		books:               make(map[string]book),
+		users:               make(map[string]user),
+		balanceTransactions: make(map[string]balanceTransaction),
+		authors:             make(map[string]author),
+		customers:           make(map[string]customer),
+		couriers:            make(map[string]courier),
+		products:            make(map[string]product),
+		categories:          make(map[string]category),
+		orders:              make(map[string]order),
+		carts:               make(map[string]cart),
+		deliveryZones:       make(map[string]deliveryZone),
+		customerAddresses:   make(map[string]customerAddress),
+		otps:                make(map[string]otp),
+		apiKeys:             make(map[string]apiKey),
+		idempotencyKeys:     make(map[idempotencyOwnerKey]idempotencyRecord),
+	}
+}
+
+func (t *tables) clone() *tables {
+	return &tables{
+		books:               copyMap(t.books),
+		users:               copyMap(t.users),
+		balanceTransactions: copyMap(t.balanceTransactions),
+		authors:             copyMap(t.authors),
+		customers:           copyMap(t.customers),
+		couriers:            copyMap(t.couriers),
+		courierLocations:    append([]courierLocation(nil), t.courierLocations...),
+		products:            copyMap(t.products),
+		categories:          copyMap(t.categories),
+		orders:              copyMap(t.orders),
+		statusHistory:       append([]statusHistory(nil), t.statusHistory...),
+		carts:               copyMap(t.carts),
+		deliveryZones:       copyMap(t.deliveryZones),
+		customerAddresses:   copyMap(t.customerAddresses),
+		otps:                copyMap(t.otps),
+		apiKeys:             copyMap(t.apiKeys),
+		idempotencyKeys:     copyMap(t.idempotencyKeys),
+	}
+}
+
+func copyMap[K comparable, V any](m map[K]V) map[K]V {
+
+	copied := make(map[K]V, len(m))
+	for k, v := range m {
+		copied[k] = v
+	}
+
+	return copied
+}
+
+// WithTx runs fn with a store working on a snapshot of the data, which
+// replaces the data when fn returns nil and is dropped when it returns an
+// error or panics. The store is locked until fn returns, so fn must only use
+// the store it is given, and transactions never conflict, so fn runs once.
+// Events are published once the outermost transaction commits.
+func (s *Store) WithTx(ctx context.Context, fn func(storage.StorageI) error) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	buffer := &events.Buffer{}
+
+	tx := &Store{
+		data:      s.data.clone(),
+		cfg:       s.cfg,
+		delivery:  s.delivery,
+		events:    s.events,
+		publisher: buffer,
+	}
+
+	err := fn(tx)
+	if err != nil {
+		return err
+	}
+
+	s.data = tx.data
+
+	buffer.Flush(s.publisher)
+
+	return nil
+}
+
+// lock locks the store for a repository call and returns its tables.
+func (s *Store) lock() *tables {
+	s.mu.Lock()
+	return s.data
+}
+
+func (s *Store) unlock() {
+	s.mu.Unlock()
+}
+
+func (s *Store) CloseDB() {}
+
+func (s *Store) Events() *events.Bus {
+	return s.events
+}
+
+func (s *Store) Book() storage.BookRepoI {
+	return &bookRepo{store: s}
+}
+
+func (s *Store) User() storage.UserRepoI {
+	return &userRepo{store: s}
+}
+
+func (s *Store) Author() storage.AuthorRepoI {
+	return &authorRepo{store: s}
+}
+
+func (s *Store) Customer() storage.CustomerRepoI {
+	return &customerRepo{store: s}
+}
+
+func (s *Store) Courier() storage.CourierRepoI {
+	return &courierRepo{store: s}
+}
+
+func (s *Store) Product() storage.ProductRepoI {
+	return &productRepo{store: s}
+}
+
+func (s *Store) Category() storage.CategoryRepoI {
+	return &categoryRepo{store: s}
+}
+
+func (s *Store) Order() storage.OrderRepoI {
+	return &orderRepo{store: s}
+}
+
+func (s *Store) Cart() storage.CartRepoI {
+	return &cartRepo{store: s}
+}
+
+func (s *Store) DeliveryZone() storage.DeliveryZoneRepoI {
+	return &deliveryZoneRepo{store: s}
+}
+
+func (s *Store) CustomerAddress() storage.CustomerAddressRepoI {
+	return &customerAddressRepo{store: s}
+}
+
+func (s *Store) Role() storage.RoleRepoI {
+	return &roleRepo{store: s}
+}
+
+func (s *Store) Otp() storage.OtpRepoI {
+	return &otpRepo{store: s}
+}
+
+func (s *Store) ApiKey() storage.ApiKeyRepoI {
+	return &apiKeyRepo{store: s}
+}
+
+func (s *Store) Idempotency() storage.IdempotencyRepoI {
+	return &idempotencyRepo{store: s}
+}
+
+func newId() string {
+	return uuid.New().String()
+}
+
+// now returns the current time at the microsecond precision of PostgreSQL
+// timestamps.
+func now() time.Time {
+	return time.Now().Truncate(time.Microsecond)
+}
+
+// formatTime formats t like TO_CHAR(t, 'YYYY-MM-DD HH24-MI-SS'). The zero
+// time is NULL and formats as "".
+func formatTime(t time.Time) string {
+
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format("2006-01-02 15-04-05")
+}
+
+// parseTime parses an RFC3339 timestamp into local time, as a cast to
+// TIMESTAMPTZ stored in a TIMESTAMP column does.
+func parseTime(value string) (time.Time, error) {
+
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, invalidValue(err)
+	}
+
+	return t.Local(), nil
+}
+
+func invalidValue(err error) error {
+	return &storage.Error{Kind: storage.KindValidation, Message: "invalid value", Err: err}
+}
+
+// foreignKeyViolation is the error of a write that references a missing row
+// or deletes a referenced one. constraint is named as in PostgreSQL.
+func foreignKeyViolation(constraint string) error {
+	return storage.ForeignKeyViolation(constraint)
+}
+
+func checkViolation(constraint string) error {
+	return &storage.Error{Kind: storage.KindValidation, Message: "value violates constraint " + constraint}
+}
+
+func uniqueViolation(constraint string) error {
+	return &storage.Error{Kind: storage.KindConflict, Message: "duplicate value violates constraint " + constraint}
+}
+
+// reference checks a foreign key column: id must be a UUID of a row in
+// table, for which exists reports.
+func reference(id string, exists bool, constraint string) error {
+
+	if _, err := uuid.Parse(id); err != nil {
+		return invalidValue(err)
+	}
+
+	if !exists {
+		return foreignKeyViolation(constraint)
+	}
+
+	return nil
+}
@@ -0,0 +1,162 @@
+package memory
+
+import (
+	"app/api/models"
+	"app/config"
+	"app/storage"
+	"context"
+	"errors"
+	"testing"
+)
+
+func newTestStore(t *testing.T) storage.StorageI {
+
+	t.Helper()
+
+	store, err := NewStore(&config.Config{EventBufferSize: 1, TxMaxAttempts: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return store
+}
+
+func categoryCount(t *testing.T, store storage.StorageI) int {
+
+	t.Helper()
+
+	resp, err := store.Category().GetListCategory(context.Background(), &models.GetListCatogoryRequest{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return resp.Count
+}
+
+func createCategory(tx storage.StorageI, name string) error {
+	_, err := tx.Category().CreateCategory(context.Background(), &models.CreateCategory{Name: name})
+	return err
+}
+
+func TestWithTxCommit(t *testing.T) {
+
+	store := newTestStore(t)
+
+	err := store.WithTx(context.Background(), func(tx storage.StorageI) error {
+
+		err := createCategory(tx, "Bakery")
+		if err != nil {
+			return err
+		}
+
+		// The transaction sees its own writes before it commits.
+		if count := categoryCount(t, tx); count != 1 {
+			t.Fatalf("transaction sees %d categories, want 1", count)
+		}
+
+		return createCategory(tx, "Dairy")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if count := categoryCount(t, store); count != 2 {
+		t.Fatalf("%d categories after commit, want 2", count)
+	}
+}
+
+func TestWithTxRollbackOnError(t *testing.T) {
+
+	store := newTestStore(t)
+	failure := errors.New("failed")
+
+	err := store.WithTx(context.Background(), func(tx storage.StorageI) error {
+
+		err := createCategory(tx, "Bakery")
+		if err != nil {
+			return err
+		}
+
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("got error %v, want %v", err, failure)
+	}
+
+	if count := categoryCount(t, store); count != 0 {
+		t.Fatalf("%d categories after rollback, want 0", count)
+	}
+
+	// A failing nested transaction only rolls back its own writes.
+	err = store.WithTx(context.Background(), func(tx storage.StorageI) error {
+
+		err := createCategory(tx, "Bakery")
+		if err != nil {
+			return err
+		}
+
+		err = tx.WithTx(context.Background(), func(nested storage.StorageI) error {
+			createCategory(nested, "Dairy")
+			return failure
+		})
+		if !errors.Is(err, failure) {
+			t.Fatalf("nested transaction returned %v, want %v", err, failure)
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if count := categoryCount(t, store); count != 1 {
+		t.Fatalf("%d categories after a nested rollback, want 1", count)
+	}
+}
+
+func TestWithTxRollbackOnPanic(t *testing.T) {
+
+	store := newTestStore(t)
+
+	func() {
+		defer func() {
+			if p := recover(); p != "boom" {
+				t.Fatalf("recovered %v, want the panic of fn", p)
+			}
+		}()
+
+		store.WithTx(context.Background(), func(tx storage.StorageI) error {
+			createCategory(tx, "Bakery")
+			panic("boom")
+		})
+	}()
+
+	// The store is unlocked and unchanged.
+	if count := categoryCount(t, store); count != 0 {
+		t.Fatalf("%d categories after a panic, want 0", count)
+	}
+}
+
+func TestIdempotencyKeysPurged(t *testing.T) {
+
+	store, err := NewStore(&config.Config{EventBufferSize: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Keys expire as soon as they are reserved without a TTL.
+	for _, key := range []string{"first", "second"} {
+		_, err = store.Idempotency().BeginIdempotentRequest(context.Background(), &models.IdempotencyKey{
+			Owner:        "user:" + key,
+			Key:          key,
+			Request_hash: key,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if keys := len(store.(*Store).data.idempotencyKeys); keys != 1 {
+		t.Fatalf("%d idempotency keys kept, want the last one", keys)
+	}
+}
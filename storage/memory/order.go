@@ -0,0 +1,721 @@
+package memory
+
+import (
+	"app/api/models"
+	"app/pkg/events"
+	"app/pkg/geo"
+	"app/storage"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+type orderRepo struct {
+	store *Store
+}
+
+// order is a row of the orders table together with its order_items rows.
+type order struct {
+	models.Order
+	items      []models.OrderItem
+	assignedAt time.Time
+	createdAt  time.Time
+	updatedAt  time.Time
+}
+
+func (o order) rowId() string           { return o.Id }
+func (o order) rowCreatedAt() time.Time { return o.createdAt }
+
+func (o order) column(name string) interface{} {
+
+	switch name {
+	case "name":
+		return o.Name
+	case "price":
+		return o.Price
+	case "delivery_fee":
+		return o.Delivery_fee
+	case "status":
+		return o.Status
+	case "created_at":
+		return o.createdAt
+	case "updated_at":
+		return nullTime(o.updatedAt)
+	}
+
+	return o.Id
+}
+
+func (o order) model() *models.Order {
+
+	resp := o.Order
+	resp.Assigned_at = formatTime(o.assignedAt)
+	resp.CreatedAt = formatTime(o.createdAt)
+	resp.UpdatedAt = formatTime(o.updatedAt)
+	resp.Items = nil
+
+	for i := range o.items {
+		item := o.items[i]
+		resp.Items = append(resp.Items, &item)
+	}
+
+	return &resp
+}
+
+func (o order) point() geo.Point {
+	return geo.Point{Latitude: o.Latitude, Longitude: o.Longtitude}
+}
+
+// statusHistory is a row of the order_status_history table.
+type statusHistory struct {
+	models.OrderStatusHistory
+	createdAt time.Time
+}
+
+func (r *orderRepo) CreateOrder(ctx context.Context, req *models.CreateOrder) (string, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	// A failed order must leave no trace, as the PostgreSQL transaction does.
+	tx := t.clone()
+
+	id, assignment, err := r.store.createOrder(tx, req)
+	if err != nil {
+		return "", err
+	}
+
+	*t = *tx
+
+	r.store.publisher.Publish(events.OrderTopic(id), events.OrderCourierAssigned, assignment)
+
+	return id, nil
+}
+
+// createOrder inserts an order with its items: it assigns a courier, reserves
+// stock, prices the items and delivery and charges the payer. The courier
+// assignment is returned so the caller can publish it.
+func (s *Store) createOrder(t *tables, req *models.CreateOrder) (string, *models.OrderCourierEvent, error) {
+
+	if len(req.Items) <= 0 {
+		return "", nil, errors.New("order must contain at least one item")
+	}
+
+	address, err := t.resolveOrderAddress(req.Address_id, req.Customer_id, &req.Latitude, &req.Longtitude, &req.Phone_number)
+	if err != nil {
+		return "", nil, err
+	}
+
+	err = t.checkOrderReferences(req.User_id, req.Customer_id)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var (
+		id = newId()
+		at = now()
+	)
+
+	o := order{
+		Order: models.Order{
+			Id:                id,
+			Name:              req.Name,
+			Phone_number:      req.Phone_number,
+			Address_id:        address.Id,
+			Address_street:    address.Street,
+			Address_apartment: address.Apartment,
+			Latitude:          req.Latitude,
+			Longtitude:        req.Longtitude,
+			User_id:           req.User_id,
+			Customer_id:       req.Customer_id,
+			Status:            models.OrderStatusNew,
+		},
+		createdAt: at,
+		updatedAt: at,
+	}
+
+	var assignment *models.OrderCourierEvent
+
+	if len(req.Courier_id) > 0 {
+		assignment, err = t.manualAssignCourier(&o, req.Courier_id)
+	} else {
+		assignment, err = s.autoAssignCourier(t, &o)
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	err = t.insertOrderItems(&o, req.Items)
+	if err != nil {
+		return "", nil, err
+	}
+
+	err = s.applyDeliveryFee(t, &o)
+	if err != nil {
+		return "", nil, err
+	}
+
+	t.orders[id] = o
+
+	err = t.settleOrderPayment(id)
+	if err != nil {
+		return "", nil, err
+	}
+
+	err = t.insertStatusHistory(&models.OrderStatusHistory{
+		Order_id:   id,
+		To_status:  models.OrderStatusNew,
+		Changed_by: req.User_id,
+		Api_key_id: req.Api_key_id,
+		Reason:     "order created",
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	return id, assignment, nil
+}
+
+// checkOrderReferences checks the user and customer foreign keys of an order.
+func (t *tables) checkOrderReferences(userId, customerId string) error {
+
+	_, ok := t.liveUser(userId)
+	if err := reference(userId, ok, "orders_user_id_fkey"); err != nil {
+		return err
+	}
+
+	_, ok = t.liveCustomer(customerId)
+
+	return reference(customerId, ok, "orders_customer_id_fkey")
+}
+
+// insertOrderItems replaces the line items of an order, reserves their stock
+// and snapshots the current unit prices of the products on them.
+func (t *tables) insertOrderItems(o *order, items []*models.CreateOrderItem) error {
+
+	for _, item := range items {
+		if item.Quantity <= 0 {
+			return checkViolation("order_items_quantity_check")
+		}
+	}
+
+	prices, err := t.reserveStock(items)
+	if err != nil {
+		return err
+	}
+
+	o.items = nil
+
+	for _, item := range items {
+
+		unitPrice := prices[item.Product_id]
+
+		o.items = append(o.items, models.OrderItem{
+			Id:          newId(),
+			Order_id:    o.Id,
+			Product_id:  item.Product_id,
+			Quantity:    item.Quantity,
+			Price:       unitPrice,
+			Total_price: unitPrice * float64(item.Quantity),
+		})
+	}
+
+	o.refreshPrice()
+
+	return nil
+}
+
+// subtotal is the total of the items of the order.
+func (o *order) subtotal() float64 {
+
+	var subtotal float64
+
+	for _, item := range o.items {
+		subtotal += item.Total_price
+	}
+
+	return subtotal
+}
+
+// refreshPrice sets the order price to the total of its items plus its
+// delivery fee.
+func (o *order) refreshPrice() {
+	o.Price = o.Delivery_fee + o.subtotal()
+}
+
+// applyDeliveryFee quotes delivery to the coordinates of the order and stores
+// the fee, distance and delivery zone as a separate line of the order price.
+func (s *Store) applyDeliveryFee(t *tables, o *order) error {
+
+	quote, zone, err := s.quoteDelivery(t, o.point())
+	if err != nil {
+		return err
+	}
+
+	if subtotal := o.subtotal(); zone != nil && subtotal < zone.Min_order {
+		return &storage.BelowMinimumOrderError{Zone: zone.Name, MinOrder: zone.Min_order, Subtotal: subtotal}
+	}
+
+	distance := quote.Distance
+
+	o.Delivery_fee = quote.Fee
+	o.Delivery_distance = &distance
+	o.Delivery_zone_id = quote.Zone_id
+	o.refreshPrice()
+
+	return nil
+}
+
+func (t *tables) insertStatusHistory(req *models.OrderStatusHistory) error {
+
+	if len(req.Changed_by) > 0 {
+		_, ok := t.users[req.Changed_by]
+		if err := reference(req.Changed_by, ok, "order_status_history_changed_by_fkey"); err != nil {
+			return err
+		}
+	}
+
+	if len(req.Api_key_id) > 0 {
+		_, ok := t.apiKeys[req.Api_key_id]
+		if err := reference(req.Api_key_id, ok, "order_status_history_api_key_id_fkey"); err != nil {
+			return err
+		}
+	}
+
+	h := *req
+	h.Id = newId()
+
+	t.statusHistory = append(t.statusHistory, statusHistory{OrderStatusHistory: h, createdAt: now()})
+
+	return nil
+}
+
+func (r *orderRepo) GetByIdOrder(ctx context.Context, req *models.OrderPrimaryKey) (*models.Order, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	o, ok := t.orders[req.Id]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+
+	return o.model(), nil
+}
+
+func (r *orderRepo) GetListOrders(ctx context.Context, req *models.GetListOrderRequest) (*models.GetListOrderResponse, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	var (
+		rows     []order
+		from, to time.Time
+		err      error
+	)
+
+	// Dates are whole days: created_to includes every order of that day.
+	if len(req.Created_from) > 0 {
+		from, err = parseDate(req.Created_from)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(req.Created_to) > 0 {
+		to, err = parseDate(req.Created_to)
+		if err != nil {
+			return nil, err
+		}
+		to = to.AddDate(0, 0, 1)
+	}
+
+	for _, o := range t.orders {
+
+		if !matches(req.Search, o.Name, o.Phone_number) {
+			continue
+		}
+
+		if (len(req.Customer_id) > 0 && o.Customer_id != req.Customer_id) ||
+			(len(req.Courier_id) > 0 && o.Courier_id != req.Courier_id) ||
+			(len(req.Status) > 0 && o.Status != req.Status) {
+			continue
+		}
+
+		if !inRange(o.Price, req.Min_price, req.Max_price) {
+			continue
+		}
+
+		if (!from.IsZero() && o.createdAt.Before(from)) || (!to.IsZero() && !o.createdAt.Before(to)) {
+			continue
+		}
+
+		rows = append(rows, o)
+	}
+
+	page, err := list(rows, listQuery{
+		sort:         req.Sort,
+		allowed:      models.OrderSortFields,
+		defaultSort:  "-created_at",
+		cursor:       req.Cursor,
+		offset:       req.Offset,
+		limit:        req.Limit,
+		defaultLimit: 10,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &models.GetListOrderResponse{
+		Sort:        page.sort,
+		Next_cursor: page.nextCursor,
+	}
+
+	for _, o := range page.rows {
+		resp.Orders = append(resp.Orders, o.model())
+	}
+
+	resp.Count = len(resp.Orders)
+
+	return resp, nil
+}
+
+// parseDate parses a YYYY-MM-DD date as the start of that day in local time.
+func parseDate(value string) (time.Time, error) {
+
+	d, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, invalidValue(err)
+	}
+
+	return d, nil
+}
+
+func (r *orderRepo) UpdateOrder(ctx context.Context, req *models.UpdateOrder) (int64, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	o, ok := t.orders[req.Id]
+	if !ok {
+		return 0, nil
+	}
+
+	tx := t.clone()
+
+	address, err := tx.resolveOrderAddress(req.Address_id, req.Customer_id, &req.Latitude, &req.Longtitude, &req.Phone_number)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.checkOrderReferences(req.User_id, req.Customer_id)
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		courierId = o.Courier_id
+		location  = o.point()
+	)
+
+	if req.Courier_id != o.Courier_id {
+
+		if len(req.Courier_id) > 0 {
+			_, err = tx.assignableCourier(req.Courier_id)
+			if err != nil {
+				return 0, err
+			}
+		}
+
+		o.Courier_id = req.Courier_id
+		o.Courier_distance = nil
+		o.Assignment_mode = models.AssignmentModeManual
+		o.assignedAt = now()
+	}
+
+	o.Name = req.Name
+	o.Phone_number = req.Phone_number
+	o.Latitude = req.Latitude
+	o.Longtitude = req.Longtitude
+	o.User_id = req.User_id
+	o.Customer_id = req.Customer_id
+	o.Address_id = address.Id
+	o.Address_street = address.Street
+	o.Address_apartment = address.Apartment
+	o.updatedAt = now()
+
+	if len(req.Items) > 0 {
+
+		if !models.OrderHoldsReservation(o.Status) || o.Status == models.OrderStatusPickedUp {
+			return 0, storage.ErrOrderItemsLocked
+		}
+
+		tx.releaseStock(o)
+
+		err = tx.insertOrderItems(&o, req.Items)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if len(req.Items) > 0 || location != o.point() {
+		err = r.store.applyDeliveryFee(tx, &o)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	tx.orders[req.Id] = o
+
+	err = tx.settleOrderPayment(req.Id)
+	if err != nil {
+		return 0, err
+	}
+
+	*t = *tx
+
+	if courierId != req.Courier_id {
+		r.store.publisher.Publish(events.OrderTopic(req.Id), events.OrderCourierAssigned, &models.OrderCourierEvent{
+			Order_id:        req.Id,
+			Courier_id:      req.Courier_id,
+			Assignment_mode: models.AssignmentModeManual,
+		})
+	}
+
+	return 1, nil
+}
+
+func (r *orderRepo) UpdateOrderStatus(ctx context.Context, req *models.UpdateOrderStatus) (int64, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	o, ok := t.orders[req.Id]
+	if !ok {
+		return 0, nil
+	}
+
+	current := o.Status
+
+	if !models.CanTransitionOrderStatus(current, req.Status) {
+		return 0, &storage.StatusTransitionError{From: current, To: req.Status}
+	}
+
+	tx := t.clone()
+
+	tx.applyStatusStock(o, current, req.Status)
+
+	o.Status = req.Status
+	o.updatedAt = now()
+	tx.orders[req.Id] = o
+
+	err := tx.settleOrderPayment(req.Id)
+	if err != nil {
+		return 0, err
+	}
+
+	history := &models.OrderStatusHistory{
+		Order_id:    req.Id,
+		From_status: current,
+		To_status:   req.Status,
+		Changed_by:  req.Changed_by,
+		Api_key_id:  req.Api_key_id,
+		Reason:      req.Reason,
+	}
+
+	err = tx.insertStatusHistory(history)
+	if err != nil {
+		return 0, err
+	}
+
+	*t = *tx
+
+	r.store.publisher.Publish(events.OrderTopic(req.Id), events.OrderStatusChanged, history)
+
+	return 1, nil
+}
+
+func (r *orderRepo) GetOrderStatusHistory(ctx context.Context, req *models.OrderPrimaryKey) (*models.GetOrderStatusHistoryResponse, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	resp := &models.GetOrderStatusHistoryResponse{}
+
+	for _, h := range t.statusHistory {
+		if h.Order_id == req.Id {
+			history := h.OrderStatusHistory
+			history.CreatedAt = formatTime(h.createdAt)
+			resp.History = append(resp.History, &history)
+		}
+	}
+
+	resp.Count = len(resp.History)
+
+	return resp, nil
+}
+
+// PatchOrder sets the given columns of an order. Only the columns the
+// handler lets through and the memory store knows are accepted.
+func (r *orderRepo) PatchOrder(ctx context.Context, req *models.PatchRequest) (int64, error) {
+
+	if len(req.Fields) <= 0 {
+		return 0, errors.New("no fields to update")
+	}
+
+	if err := models.CheckPatchFields(req.Fields, models.OrderPatchFields); err != nil {
+		return 0, &storage.Error{Kind: storage.KindValidation, Message: err.Error()}
+	}
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	o, ok := t.orders[req.ID]
+	if !ok {
+		return 0, nil
+	}
+
+	tx := t.clone()
+
+	keys := make([]string, 0, len(req.Fields))
+	for key := range req.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+
+		var err error
+
+		value := req.Fields[key]
+
+		switch key {
+		case "name":
+			o.Name, err = patchString(value)
+		case "phone_number":
+			o.Phone_number, err = patchString(value)
+		case "latitude":
+			o.Latitude, err = patchFloat(value)
+		case "longtitude":
+			o.Longtitude, err = patchFloat(value)
+		case "user_id":
+			o.User_id, err = patchString(value)
+			if err == nil {
+				_, ok := tx.liveUser(o.User_id)
+				err = reference(o.User_id, ok, "orders_user_id_fkey")
+			}
+		case "customer_id":
+			o.Customer_id, err = patchString(value)
+			if err == nil {
+				_, ok := tx.liveCustomer(o.Customer_id)
+				err = reference(o.Customer_id, ok, "orders_customer_id_fkey")
+			}
+		}
+
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	o.updatedAt = now()
+
+	_, latitude := req.Fields["latitude"]
+	_, longtitude := req.Fields["longtitude"]
+	if latitude || longtitude {
+		err := r.store.applyDeliveryFee(tx, &o)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	tx.orders[req.ID] = o
+
+	err := tx.settleOrderPayment(req.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	*t = *tx
+
+	return 1, nil
+}
+
+// patchString converts a patched JSON value to a text column.
+func patchString(value interface{}) (string, error) {
+
+	switch value := value.(type) {
+	case string:
+		return value, nil
+	case nil:
+		return "", checkViolation("not null")
+	}
+
+	return fmt.Sprint(value), nil
+}
+
+// patchFloat converts a patched JSON value to a numeric column.
+func patchFloat(value interface{}) (float64, error) {
+
+	switch value := value.(type) {
+	case float64:
+		return value, nil
+	case string:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return 0, invalidValue(err)
+		}
+		return f, nil
+	}
+
+	return 0, invalidValue(fmt.Errorf("cannot use %v as a number", value))
+}
+
+// DeleteOrder deletes an order with its items and status history. An open
+// order gives back the stock reserved for it and refunds its payment.
+func (r *orderRepo) DeleteOrder(ctx context.Context, req *models.OrderPrimaryKey) error {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	o, ok := t.orders[req.Id]
+	if !ok {
+		return nil
+	}
+
+	tx := t.clone()
+
+	if models.OrderHoldsReservation(o.Status) {
+		tx.releaseStock(o)
+
+		err := tx.refundOrderPayment(req.Id)
+		if err != nil {
+			return err
+		}
+	}
+
+	var history []statusHistory
+
+	for _, h := range tx.statusHistory {
+		if h.Order_id != req.Id {
+			history = append(history, h)
+		}
+	}
+
+	tx.statusHistory = history
+
+	for id, b := range tx.balanceTransactions {
+		if b.Order_id == req.Id {
+			b.Order_id = ""
+			tx.balanceTransactions[id] = b
+		}
+	}
+
+	delete(tx.orders, req.Id)
+
+	*t = *tx
+
+	return nil
+}
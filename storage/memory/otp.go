@@ -0,0 +1,174 @@
+package memory
+
+import (
+	"app/api/models"
+	"app/pkg/helper"
+	"app/storage"
+	"context"
+	"math"
+	"sort"
+	"time"
+)
+
+type otpRepo struct {
+	store *Store
+}
+
+// otp is a row of the customer_otps table.
+type otp struct {
+	id         string
+	phone      string
+	codeHash   string
+	attempts   int
+	expiresAt  time.Time
+	consumedAt time.Time
+	createdAt  time.Time
+}
+
+// latestOtp returns the most recent code sent to phone that passes keep.
+func (t *tables) latestOtp(phone string, keep func(otp) bool) (otp, bool) {
+
+	var (
+		latest otp
+		found  bool
+	)
+
+	for _, o := range t.otps {
+		if o.phone == phone && keep(o) && (!found || o.createdAt.After(latest.createdAt)) {
+			latest, found = o, true
+		}
+	}
+
+	return latest, found
+}
+
+// CreateCustomerOtp stores a hashed one-time code for a phone, rate limited by
+// the resend interval.
+func (r *otpRepo) CreateCustomerOtp(ctx context.Context, req *models.CreateCustomerOtp) (string, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	cfg := r.store.cfg
+	at := now()
+
+	last, ok := t.latestOtp(req.Phone, func(otp) bool { return true })
+	if elapsed := at.Sub(last.createdAt).Seconds(); ok && elapsed < float64(cfg.OtpResendInterval) {
+		return "", &storage.OtpTooSoonError{RetryAfter: int(math.Ceil(float64(cfg.OtpResendInterval) - elapsed))}
+	}
+
+	id := newId()
+
+	t.otps[id] = otp{
+		id:        id,
+		phone:     req.Phone,
+		codeHash:  req.Code_hash,
+		expiresAt: at.Add(time.Duration(cfg.OtpTTL) * time.Minute),
+		createdAt: at,
+	}
+
+	return id, nil
+}
+
+// VerifyCustomerOtp checks code against the latest active code of phone. On
+// success the code is consumed, the phone of the matching customer is marked
+// verified and the customer's user account is returned, creating the
+// customer and the account on first login.
+func (r *otpRepo) VerifyCustomerOtp(ctx context.Context, req *models.VerifyOtp) (*models.OtpLogin, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	cfg := r.store.cfg
+	at := now()
+
+	code, ok := t.latestOtp(req.Phone, func(o otp) bool { return o.consumedAt.IsZero() && o.expiresAt.After(at) })
+	if !ok {
+		return nil, storage.ErrOtpNotFound
+	}
+
+	if code.attempts >= cfg.OtpMaxAttempts {
+		return nil, storage.ErrOtpAttemptsExceeded
+	}
+
+	if !helper.CheckPassword(code.codeHash, req.Code) {
+
+		code.attempts++
+		t.otps[code.id] = code
+
+		return nil, &storage.InvalidOtpError{AttemptsLeft: cfg.OtpMaxAttempts - code.attempts}
+	}
+
+	for id, o := range t.otps {
+		if o.phone == req.Phone && o.consumedAt.IsZero() {
+			o.consumedAt = at
+			t.otps[id] = o
+		}
+	}
+
+	login := &models.OtpLogin{}
+
+	var candidates []customer
+
+	for _, c := range t.customers {
+		if c.Phone == req.Phone && c.deletedAt.IsZero() {
+			candidates = append(candidates, c)
+		}
+	}
+
+	// Verified customers come first, then the oldest.
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].phoneVerifiedAt.IsZero() != candidates[j].phoneVerifiedAt.IsZero() {
+			return !candidates[i].phoneVerifiedAt.IsZero()
+		}
+		return candidates[i].createdAt.Before(candidates[j].createdAt)
+	})
+
+	if len(candidates) > 0 {
+		login.Customer_id = candidates[0].Id
+	} else {
+		login.Customer_id = t.insertCustomer("", req.Phone)
+	}
+
+	c := t.customers[login.Customer_id]
+	if c.phoneVerifiedAt.IsZero() {
+		c.phoneVerifiedAt = at
+	}
+	c.updatedAt = at
+	t.customers[login.Customer_id] = c
+
+	var oldest *user
+
+	// Staff linked to the customer do not sign in by phone, so only customer
+	// users are picked or created.
+	for _, u := range t.users {
+		if u.Customer_id == login.Customer_id && u.Role == "customer" && u.deletedAt.IsZero() && (oldest == nil || u.createdAt.Before(oldest.createdAt)) {
+			u := u
+			oldest = &u
+		}
+	}
+
+	if oldest != nil {
+		login.User_id = oldest.Id
+		return login, nil
+	}
+
+	name := c.Name
+	if len(name) <= 0 {
+		name = c.Phone
+	}
+
+	id, err := t.insertUser(name, "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	u := t.users[id]
+	u.Role = "customer"
+	u.Customer_id = login.Customer_id
+	t.users[id] = u
+
+	login.User_id = id
+
+	return login, nil
+}
@@ -0,0 +1,242 @@
+package memory
+
+import (
+	"app/api/models"
+	"app/storage"
+	"context"
+	"strconv"
+	"time"
+)
+
+type productRepo struct {
+	store *Store
+}
+
+// product is a row of the products table.
+type product struct {
+	models.Product
+	price     float64
+	createdAt time.Time
+	updatedAt time.Time
+	deletedAt time.Time
+}
+
+func (p product) rowId() string           { return p.Id }
+func (p product) rowCreatedAt() time.Time { return p.createdAt }
+
+func (p product) column(name string) interface{} {
+
+	switch name {
+	case "name":
+		return p.Name
+	case "price":
+		return p.price
+	case "stock":
+		return p.Stock
+	case "created_at":
+		return p.createdAt
+	case "updated_at":
+		return nullTime(p.updatedAt)
+	}
+
+	return p.Id
+}
+
+func (p product) model() *models.Product {
+
+	resp := p.Product
+	resp.Price = strconv.FormatFloat(p.price, 'f', -1, 64)
+	resp.CreatedAt = formatTime(p.createdAt)
+	resp.UpdatedAt = formatTime(p.updatedAt)
+	resp.DeletedAt = formatTime(p.deletedAt)
+
+	return &resp
+}
+
+// check enforces the CHECK constraints of the products table.
+func (p product) check() error {
+
+	switch {
+	case p.Stock < 0:
+		return checkViolation("products_stock_check")
+	case p.Reserved < 0:
+		return checkViolation("products_reserved_check")
+	case p.Reserved > p.Stock:
+		return checkViolation("products_reserved_within_stock")
+	}
+
+	return nil
+}
+
+func (r *productRepo) CreateProduct(ctx context.Context, req *models.CreateProduct) (string, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	_, ok := t.liveCategory(req.Category_id)
+	if err := reference(req.Category_id, ok, "products_category_id_fkey"); err != nil {
+		return "", err
+	}
+
+	var (
+		id = newId()
+		at = now()
+	)
+
+	p := product{
+		Product: models.Product{
+			Id:          id,
+			Name:        req.Name,
+			Category_id: req.Category_id,
+			Stock:       req.Stock,
+		},
+		price:     req.Price,
+		createdAt: at,
+		updatedAt: at,
+	}
+
+	if err := p.check(); err != nil {
+		return "", err
+	}
+
+	t.products[id] = p
+
+	return id, nil
+}
+
+func (r *productRepo) GetByIdProduct(ctx context.Context, req *models.ProductPrimaryKey) (*models.Product, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	p, ok := t.products[req.Id]
+	if !ok || (!p.deletedAt.IsZero() && !req.Include_deleted) {
+		return nil, storage.ErrNotFound
+	}
+
+	return p.model(), nil
+}
+
+func (r *productRepo) GetListProduct(ctx context.Context, req *models.GetListProductRequest) (*models.GetListProductResponse, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	var rows []product
+
+	for _, p := range t.products {
+
+		if !p.deletedAt.IsZero() && !req.Include_deleted {
+			continue
+		}
+
+		if !matches(req.Search, p.Name) {
+			continue
+		}
+
+		if len(req.Category_id) > 0 && p.Category_id != req.Category_id {
+			continue
+		}
+
+		if !inRange(p.price, req.Min_price, req.Max_price) {
+			continue
+		}
+
+		rows = append(rows, p)
+	}
+
+	page, err := list(rows, listQuery{
+		sort:        req.Sort,
+		allowed:     models.ProductSortFields,
+		defaultSort: "name",
+		cursor:      req.Cursor,
+		offset:      req.Offset,
+		limit:       req.Limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &models.GetListProductResponse{
+		Sort:        page.sort,
+		Next_cursor: page.nextCursor,
+	}
+
+	for _, p := range page.rows {
+		resp.Products = append(resp.Products, p.model())
+	}
+
+	resp.Count = len(resp.Products)
+
+	return resp, nil
+}
+
+func (r *productRepo) UpdateProduct(ctx context.Context, req *models.UpdateProduct) (int64, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	p, ok := t.liveProduct(req.Id)
+	if !ok {
+		return 0, nil
+	}
+
+	_, ok = t.liveCategory(req.Category_id)
+	if err := reference(req.Category_id, ok, "products_category_id_fkey"); err != nil {
+		return 0, err
+	}
+
+	p.Name = req.Name
+	p.price = req.Price
+	p.Category_id = req.Category_id
+	p.Stock = req.Stock
+	p.updatedAt = now()
+
+	if err := p.check(); err != nil {
+		return 0, err
+	}
+
+	t.products[req.Id] = p
+
+	return 1, nil
+}
+
+// DeleteProduct soft deletes the product. Orders keep their items of it, but
+// it can no longer be ordered and cart items show it as unavailable.
+func (r *productRepo) DeleteProduct(ctx context.Context, req *models.ProductPrimaryKey) error {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	p, ok := t.liveProduct(req.Id)
+	if !ok {
+		return storage.ErrNotFound
+	}
+
+	p.deletedAt = now()
+	t.products[req.Id] = p
+
+	return nil
+}
+
+// RestoreProduct brings back a soft deleted product, unless its category is
+// deleted.
+func (r *productRepo) RestoreProduct(ctx context.Context, req *models.ProductPrimaryKey) error {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	p, ok := t.products[req.Id]
+	if !ok || p.deletedAt.IsZero() {
+		return nil
+	}
+
+	if _, ok := t.liveCategory(p.Category_id); !ok {
+		return foreignKeyViolation("products_category_id_fkey")
+	}
+
+	p.deletedAt = time.Time{}
+	t.products[req.Id] = p
+
+	return nil
+}
@@ -0,0 +1,177 @@
+package memory
+
+import (
+	"app/api/models"
+	"app/storage"
+	"context"
+	"sort"
+)
+
+type roleRepo struct {
+	store *Store
+}
+
+// roleDescriptions and permissions hold the roles and permission codes seeded
+// by the migrations. They cannot be changed through the API.
+var roleDescriptions = map[string]string{
+	"admin":    "Manages the catalog, couriers, users and every order",
+	"courier":  "Sees and progresses the orders assigned to them",
+	"customer": "Places and follows their own orders",
+}
+
+var permissions = []string{
+	"book.read", "book.write",
+	"author.read", "author.write",
+	"user.read", "user.write",
+	"balance.read", "balance.write",
+	"role.read", "role.write",
+	"customer.read", "customer.write",
+	"address.read", "address.write",
+	"courier.read", "courier.write", "courier.location",
+	"product.read", "product.write",
+	"category.read", "category.write",
+	"order.read", "order.create", "order.write", "order.status", "order.assign",
+	"cart.read", "cart.write",
+	"delivery_zone.read", "delivery_zone.write",
+	"api_key.read", "api_key.write",
+}
+
+// rolePermissions maps each role to its permissions and their scopes. The
+// admin role is granted every permission with the "all" scope.
+var rolePermissions = map[string]map[string]string{
+	"admin": {},
+	"courier": {
+		"courier.read":     models.PermissionScopeOwn,
+		"courier.location": models.PermissionScopeOwn,
+		"order.read":       models.PermissionScopeOwn,
+		"order.status":     models.PermissionScopeOwn,
+		"product.read":     models.PermissionScopeAll,
+		"category.read":    models.PermissionScopeAll,
+	},
+	"customer": {
+		"customer.read":      models.PermissionScopeOwn,
+		"customer.write":     models.PermissionScopeOwn,
+		"address.read":       models.PermissionScopeOwn,
+		"address.write":      models.PermissionScopeOwn,
+		"order.read":         models.PermissionScopeOwn,
+		"order.create":       models.PermissionScopeOwn,
+		"cart.read":          models.PermissionScopeOwn,
+		"cart.write":         models.PermissionScopeOwn,
+		"balance.read":       models.PermissionScopeOwn,
+		"product.read":       models.PermissionScopeAll,
+		"category.read":      models.PermissionScopeAll,
+		"book.read":          models.PermissionScopeAll,
+		"author.read":        models.PermissionScopeAll,
+		"delivery_zone.read": models.PermissionScopeAll,
+	},
+}
+
+func init() {
+	for _, permission := range permissions {
+		rolePermissions["admin"][permission] = models.PermissionScopeAll
+	}
+}
+
+// isPermission reports whether code is a known permission code.
+func isPermission(code string) bool {
+
+	for _, permission := range permissions {
+		if permission == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *roleRepo) GetListRoles(ctx context.Context) (*models.GetListRoleResponse, error) {
+
+	resp := &models.GetListRoleResponse{}
+
+	for name, description := range roleDescriptions {
+
+		role := &models.Role{Name: name, Description: description}
+
+		for permission, scope := range rolePermissions[name] {
+			role.Permissions = append(role.Permissions, &models.RolePermission{Permission: permission, Scope: scope})
+		}
+
+		sort.Slice(role.Permissions, func(i, j int) bool {
+			return role.Permissions[i].Permission < role.Permissions[j].Permission
+		})
+
+		resp.Roles = append(resp.Roles, role)
+	}
+
+	sort.Slice(resp.Roles, func(i, j int) bool { return resp.Roles[i].Name < resp.Roles[j].Name })
+
+	resp.Count = len(resp.Roles)
+
+	return resp, nil
+}
+
+// AssignUserRole sets the role of a user and links the user to the courier or
+// customer records their "own" permissions apply to.
+func (r *roleRepo) AssignUserRole(ctx context.Context, req *models.AssignUserRole) (int64, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	u, ok := t.users[req.User_id]
+	if !ok {
+		return 0, nil
+	}
+
+	if _, ok := roleDescriptions[req.Role]; len(req.Role) > 0 && !ok {
+		return 0, foreignKeyViolation("users_role_fkey")
+	}
+
+	if len(req.Courier_id) > 0 {
+		_, ok := t.liveCourier(req.Courier_id)
+		if err := reference(req.Courier_id, ok, "users_courier_id_fkey"); err != nil {
+			return 0, err
+		}
+	}
+
+	if len(req.Customer_id) > 0 {
+		_, ok := t.liveCustomer(req.Customer_id)
+		if err := reference(req.Customer_id, ok, "users_customer_id_fkey"); err != nil {
+			return 0, err
+		}
+	}
+
+	u.Role = req.Role
+	u.Courier_id = req.Courier_id
+	u.Customer_id = req.Customer_id
+	u.updatedAt = now()
+
+	t.users[req.User_id] = u
+
+	return 1, nil
+}
+
+// GetPrincipal loads a user with the permissions granted by their role.
+func (r *roleRepo) GetPrincipal(ctx context.Context, req *models.UserPrimaryKey) (*models.Principal, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	u, ok := t.liveUser(req.Id)
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+
+	principal := &models.Principal{
+		User_id:     u.Id,
+		Role:        u.Role,
+		Courier_id:  u.Courier_id,
+		Customer_id: u.Customer_id,
+		Permissions: make(map[string]string),
+	}
+
+	for permission, scope := range rolePermissions[u.Role] {
+		principal.Permissions[permission] = scope
+	}
+
+	return principal, nil
+}
@@ -0,0 +1,40 @@
+package memory
+
+// Soft deleted rows stay in their tables so orders keep their history. The
+// live lookups below treat them as missing, as the queries filtering on
+// deleted_at IS NULL and the reject_deleted_reference triggers do.
+
+func (t *tables) liveBook(id string) (book, bool) {
+	b, ok := t.books[id]
+	return b, ok && b.deletedAt.IsZero()
+}
+
+func (t *tables) liveAuthor(id string) (author, bool) {
+	a, ok := t.authors[id]
+	return a, ok && a.deletedAt.IsZero()
+}
+
+func (t *tables) liveProduct(id string) (product, bool) {
+	p, ok := t.products[id]
+	return p, ok && p.deletedAt.IsZero()
+}
+
+func (t *tables) liveCategory(id string) (category, bool) {
+	c, ok := t.categories[id]
+	return c, ok && c.deletedAt.IsZero()
+}
+
+func (t *tables) liveCustomer(id string) (customer, bool) {
+	c, ok := t.customers[id]
+	return c, ok && c.deletedAt.IsZero()
+}
+
+func (t *tables) liveCourier(id string) (courier, bool) {
+	c, ok := t.couriers[id]
+	return c, ok && c.deletedAt.IsZero()
+}
+
+func (t *tables) liveUser(id string) (user, bool) {
+	u, ok := t.users[id]
+	return u, ok && u.deletedAt.IsZero()
+}
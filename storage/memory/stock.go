@@ -0,0 +1,103 @@
+package memory
+
+import (
+	"app/api/models"
+	"app/storage"
+	"sort"
+)
+
+// reserveStock checks that enough unreserved stock is left for every product
+// of items and reserves the requested quantities. It returns the current unit
+// price of each product so the caller can snapshot it on the order. A missing
+// or deleted product is reported as a violation of order_items_product_id_fkey.
+func (t *tables) reserveStock(items []*models.CreateOrderItem) (map[string]float64, error) {
+
+	var (
+		ids       []string
+		requested = make(map[string]int)
+		prices    = make(map[string]float64)
+		shortages []*models.StockShortage
+	)
+
+	for _, item := range items {
+		if _, ok := requested[item.Product_id]; !ok {
+			ids = append(ids, item.Product_id)
+		}
+		requested[item.Product_id] += item.Quantity
+	}
+
+	sort.Strings(ids)
+
+	for _, id := range ids {
+
+		p, ok := t.liveProduct(id)
+		if !ok {
+			return nil, foreignKeyViolation("order_items_product_id_fkey")
+		}
+
+		prices[id] = p.price
+
+		if available := p.Stock - p.Reserved; available < requested[id] {
+			shortages = append(shortages, &models.StockShortage{
+				Product_id: id,
+				Requested:  requested[id],
+				Available:  available,
+			})
+		}
+	}
+
+	if len(shortages) > 0 {
+		return nil, &storage.InsufficientStockError{Products: shortages}
+	}
+
+	at := now()
+
+	for _, id := range ids {
+		p := t.products[id]
+		p.Reserved += requested[id]
+		p.updatedAt = at
+		t.products[id] = p
+	}
+
+	return prices, nil
+}
+
+// adjustOrderStock applies adjust to every product of the items of an order
+// with the quantity ordered.
+func (t *tables) adjustOrderStock(o order, adjust func(p *product, quantity int)) {
+
+	at := now()
+
+	for _, item := range o.items {
+
+		p, ok := t.products[item.Product_id]
+		if !ok {
+			continue
+		}
+
+		adjust(&p, item.Quantity)
+		p.updatedAt = at
+		t.products[item.Product_id] = p
+	}
+}
+
+// releaseStock gives back the stock reserved by an order, e.g. when it is cancelled.
+func (t *tables) releaseStock(o order) {
+	t.adjustOrderStock(o, func(p *product, quantity int) { p.Reserved -= quantity })
+}
+
+// applyStatusStock updates product stock for an order moving between statuses.
+func (t *tables) applyStatusStock(o order, from, to string) {
+
+	switch {
+	case to == models.OrderStatusDelivered:
+		t.adjustOrderStock(o, func(p *product, quantity int) {
+			p.Stock -= quantity
+			p.Reserved -= quantity
+		})
+	case from == models.OrderStatusDelivered && to == models.OrderStatusReturned:
+		t.adjustOrderStock(o, func(p *product, quantity int) { p.Stock += quantity })
+	case models.OrderHoldsReservation(from) && !models.OrderHoldsReservation(to):
+		t.releaseStock(o)
+	}
+}
@@ -0,0 +1,237 @@
+package memory
+
+import (
+	"app/api/models"
+	"app/storage"
+	"context"
+	"time"
+)
+
+type userRepo struct {
+	store *Store
+}
+
+// user is a row of the users table.
+type user struct {
+	models.User
+	passwordHash string
+	createdAt    time.Time
+	updatedAt    time.Time
+	deletedAt    time.Time
+}
+
+func (u user) rowId() string           { return u.Id }
+func (u user) rowCreatedAt() time.Time { return u.createdAt }
+
+func (u user) column(name string) interface{} {
+
+	switch name {
+	case "name":
+		return u.Name
+	case "login":
+		if len(u.Login) <= 0 {
+			return nil
+		}
+		return u.Login
+	case "balance":
+		return u.Balance
+	case "created_at":
+		return u.createdAt
+	case "updated_at":
+		return nullTime(u.updatedAt)
+	}
+
+	return u.Id
+}
+
+func (u user) model() *models.User {
+
+	resp := u.User
+	resp.CreatedAt = formatTime(u.createdAt)
+	resp.UpdatedAt = formatTime(u.updatedAt)
+	resp.DeletedAt = formatTime(u.deletedAt)
+
+	return &resp
+}
+
+// insertUser adds a user with a zero balance. A login already taken by
+// another user fails with storage.ErrLoginTaken.
+func (t *tables) insertUser(name, login, passwordHash string) (string, error) {
+
+	if len(login) > 0 {
+		for _, u := range t.users {
+			if u.Login == login {
+				return "", storage.ErrLoginTaken
+			}
+		}
+	}
+
+	var (
+		id = newId()
+		at = now()
+	)
+
+	t.users[id] = user{
+		User:         models.User{Id: id, Name: name, Login: login},
+		passwordHash: passwordHash,
+		createdAt:    at,
+		updatedAt:    at,
+	}
+
+	return id, nil
+}
+
+func (r *userRepo) CreateUser(ctx context.Context, req *models.CreateUser) (string, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	id, err := t.insertUser(req.Name, req.Login, req.Password_hash)
+	if err != nil {
+		return "", err
+	}
+
+	if req.Balance > 0 {
+		_, err = t.addBalanceTransaction(&models.CreateBalanceTransaction{
+			User_id:     id,
+			Type:        models.BalanceTransactionTopUp,
+			Amount:      req.Balance,
+			Description: "initial balance",
+		})
+		if err != nil {
+			delete(t.users, id)
+			return "", err
+		}
+	}
+
+	return id, nil
+}
+
+func (r *userRepo) UserGetByID(ctx context.Context, req *models.UserPrimaryKey) (*models.User, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	u, ok := t.users[req.Id]
+	if !ok || (!u.deletedAt.IsZero() && !req.Include_deleted) {
+		return nil, storage.ErrNotFound
+	}
+
+	return u.model(), nil
+}
+
+// GetUserCredentials returns the id and password hash of the user with the
+// given login. Users without a password cannot log in and are not found.
+func (r *userRepo) GetUserCredentials(ctx context.Context, req *models.LoginRequest) (*models.UserCredentials, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	for _, u := range t.users {
+		if len(u.Login) > 0 && u.Login == req.Login && len(u.passwordHash) > 0 && u.deletedAt.IsZero() {
+			return &models.UserCredentials{Id: u.Id, Password_hash: u.passwordHash}, nil
+		}
+	}
+
+	return nil, storage.ErrNotFound
+}
+
+func (r *userRepo) UserGetList(ctx context.Context, req *models.GetListUserRequest) (*models.GetListUserResponse, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	var rows []user
+
+	for _, u := range t.users {
+
+		if !u.deletedAt.IsZero() && !req.Include_deleted {
+			continue
+		}
+
+		if !matches(req.Search, u.Name, u.Login) {
+			continue
+		}
+
+		if len(req.Role) > 0 && u.Role != req.Role {
+			continue
+		}
+
+		rows = append(rows, u)
+	}
+
+	page, err := list(rows, listQuery{
+		sort:        req.Sort,
+		allowed:     models.UserSortFields,
+		defaultSort: "name",
+		cursor:      req.Cursor,
+		offset:      req.Offset,
+		limit:       req.Limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &models.GetListUserResponse{
+		Count:       page.count,
+		Sort:        page.sort,
+		Next_cursor: page.nextCursor,
+	}
+
+	for _, u := range page.rows {
+		resp.Users = append(resp.Users, u.model())
+	}
+
+	return resp, nil
+}
+
+func (r *userRepo) UpdateUser(ctx context.Context, req *models.UpdateUser) (int64, error) {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	u, ok := t.liveUser(req.Id)
+	if !ok {
+		return 0, nil
+	}
+
+	u.Name = req.Name
+	u.updatedAt = now()
+	t.users[req.Id] = u
+
+	return 1, nil
+}
+
+// DeleteUser soft deletes the user, who can then no longer log in or use
+// their tokens. Their orders and balance are kept.
+func (r *userRepo) DeleteUser(ctx context.Context, req *models.UserPrimaryKey) error {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	u, ok := t.liveUser(req.Id)
+	if !ok {
+		return storage.ErrNotFound
+	}
+
+	u.deletedAt = now()
+	t.users[req.Id] = u
+
+	return nil
+}
+
+func (r *userRepo) RestoreUser(ctx context.Context, req *models.UserPrimaryKey) error {
+
+	t := r.store.lock()
+	defer r.store.unlock()
+
+	u, ok := t.users[req.Id]
+	if !ok {
+		return nil
+	}
+
+	u.deletedAt = time.Time{}
+	t.users[req.Id] = u
+
+	return nil
+}
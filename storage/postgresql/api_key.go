@@ -0,0 +1,244 @@
+package postgresql
+
+import (
+	"app/api/models"
+	"app/pkg/helper"
+	"app/storage"
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+type apiKeyRepo struct {
+	db DB
+}
+
+func NewApiKeyRepo(db DB) *apiKeyRepo {
+	return &apiKeyRepo{
+		db: db,
+	}
+}
+
+func (a *apiKeyRepo) CreateApiKey(ctx context.Context, req *models.CreateApiKey) (string, error) {
+
+	id := uuid.New().String()
+
+	tx, err := a.db.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback(ctx)
+
+	var unknown []string
+
+	err = tx.QueryRow(ctx, `
+		SELECT COALESCE(ARRAY_AGG(scope), '{}')
+		FROM UNNEST($1::VARCHAR[]) AS scope
+		WHERE NOT EXISTS (SELECT 1 FROM permissions WHERE code = scope)
+	`, req.Scopes).Scan(&unknown)
+	if err != nil {
+		return "", err
+	}
+
+	if len(unknown) > 0 {
+		return "", &storage.UnknownPermissionError{Permissions: unknown}
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO api_keys(
+			id,
+			name,
+			prefix,
+			key_hash,
+			created_by,
+			expires_at
+		) VALUES ($1, $2, $3, $4, $5, $6::TIMESTAMPTZ)
+	`,
+		id,
+		req.Name,
+		req.Prefix,
+		req.Key_hash,
+		helper.NewNullString(req.Created_by),
+		helper.NewNullString(req.Expires_at),
+	)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = tx.Exec(ctx,
+		"INSERT INTO api_key_scopes(api_key_id, permission) SELECT DISTINCT $1::UUID, UNNEST($2::VARCHAR[])",
+		id, req.Scopes,
+	)
+	if err != nil {
+		return "", err
+	}
+
+	return id, tx.Commit(ctx)
+}
+
+const apiKeyColumns = `
+	k.id,
+	k.name,
+	k.prefix,
+	ARRAY(SELECT permission FROM api_key_scopes WHERE api_key_id = k.id ORDER BY permission),
+	COALESCE(k.created_by::VARCHAR, ''),
+	COALESCE(TO_CHAR(k.expires_at, 'YYYY-MM-DD HH24-MI-SS'), ''),
+	COALESCE(TO_CHAR(k.last_used_at, 'YYYY-MM-DD HH24-MI-SS'), ''),
+	COALESCE(TO_CHAR(k.revoked_at, 'YYYY-MM-DD HH24-MI-SS'), ''),
+	TO_CHAR(k.created_at, 'YYYY-MM-DD HH24-MI-SS')
+`
+
+func scanApiKey(row pgx.Row, key *models.ApiKey) error {
+	return row.Scan(
+		&key.Id,
+		&key.Name,
+		&key.Prefix,
+		&key.Scopes,
+		&key.Created_by,
+		&key.Expires_at,
+		&key.Last_used_at,
+		&key.Revoked_at,
+		&key.CreatedAt,
+	)
+}
+
+func (a *apiKeyRepo) GetByIdApiKey(ctx context.Context, req *models.ApiKeyPrimaryKey) (*models.ApiKey, error) {
+
+	var key models.ApiKey
+
+	err := scanApiKey(a.db.QueryRow(ctx, "SELECT"+apiKeyColumns+"FROM api_keys AS k WHERE k.id = $1", req.Id), &key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+func (a *apiKeyRepo) GetListApiKey(ctx context.Context, req *models.GetListApiKeyRequest) (*models.GetListApiKeyResponse, error) {
+
+	var (
+		resp   = &models.GetListApiKeyResponse{}
+		offset = " OFFSET 0"
+		limit  = " LIMIT 10"
+	)
+
+	if req.Offset > 0 {
+		offset = fmt.Sprintf(" OFFSET %d", req.Offset)
+	}
+
+	if req.Limit > 0 {
+		limit = fmt.Sprintf(" LIMIT %d", req.Limit)
+	}
+
+	query := "SELECT COUNT(*) OVER()," + apiKeyColumns + "FROM api_keys AS k ORDER BY k.created_at DESC, k.id" + offset + limit
+
+	rows, err := a.db.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+
+		var key models.ApiKey
+
+		err = rows.Scan(
+			&resp.Count,
+			&key.Id,
+			&key.Name,
+			&key.Prefix,
+			&key.Scopes,
+			&key.Created_by,
+			&key.Expires_at,
+			&key.Last_used_at,
+			&key.Revoked_at,
+			&key.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		resp.Api_keys = append(resp.Api_keys, &key)
+	}
+
+	return resp, rows.Err()
+}
+
+// RevokeApiKey revokes a key for good. Revoking a key twice affects no rows.
+func (a *apiKeyRepo) RevokeApiKey(ctx context.Context, req *models.ApiKeyPrimaryKey) (int64, error) {
+
+	result, err := a.db.Exec(ctx,
+		"UPDATE api_keys SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL", req.Id,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// AuthenticateApiKey looks a key up by its hash and loads the permissions of
+// its scopes. Usage of a valid key is recorded in last_used_at, at most once a
+// minute so busy integrations do not write on every request.
+func (a *apiKeyRepo) AuthenticateApiKey(ctx context.Context, keyHash string) (*models.ApiKeyAuth, error) {
+
+	auth := &models.ApiKeyAuth{
+		Permissions: make(map[string]string),
+	}
+
+	err := a.db.QueryRow(ctx, `
+		SELECT
+			id,
+			name,
+			COALESCE(expires_at <= now(), FALSE),
+			revoked_at IS NOT NULL
+		FROM api_keys
+		WHERE key_hash = $1
+	`, keyHash).Scan(
+		&auth.Id,
+		&auth.Name,
+		&auth.Expired,
+		&auth.Revoked,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if auth.Expired || auth.Revoked {
+		return auth, nil
+	}
+
+	rows, err := a.db.Query(ctx, "SELECT permission FROM api_key_scopes WHERE api_key_id = $1", auth.Id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+
+		var permission string
+
+		err = rows.Scan(&permission)
+		if err != nil {
+			return nil, err
+		}
+
+		auth.Permissions[permission] = models.PermissionScopeAll
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	_, err = a.db.Exec(ctx, `
+		UPDATE api_keys SET last_used_at = now()
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < now() - INTERVAL '1 minute')
+	`, auth.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return auth, nil
+}
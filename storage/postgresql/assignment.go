@@ -0,0 +1,278 @@
+package postgresql
+
+import (
+	"app/api/models"
+	"app/pkg/events"
+	"app/pkg/geo"
+	"app/storage"
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/jackc/pgx/v4"
+)
+
+type courierCandidate struct {
+	id       string
+	distance float64
+	load     int
+}
+
+// nearestCourier returns the closest courier to point that is available, has
+// reported a location recently and carries fewer open orders than the
+// configured maximum. Ties on distance go to the courier with the lighter load.
+// Only the chosen courier is locked until the end of the transaction: the
+// candidates are tried nearest first, skipping those another assignment holds,
+// and the load is counted again under the lock so that concurrent assignments
+// cannot push a courier over the limit.
+func (o *orderRepo) nearestCourier(ctx context.Context, tx pgx.Tx, orderId string, point geo.Point) (*courierCandidate, error) {
+
+	query := `
+		SELECT
+			c.id,
+			c.latitude,
+			c.longitude,
+			(
+				SELECT COUNT(*)
+				FROM orders o
+				WHERE o.courier_id = c.id
+					AND o.id <> $1
+					AND o.status IN ('new', 'accepted', 'assigned', 'picked_up')
+			)
+		FROM courier c
+		WHERE c.is_available
+			AND c.deleted_at IS NULL
+			AND c.latitude IS NOT NULL
+			AND c.longitude IS NOT NULL
+			AND c.location_updated_at >= now() - make_interval(mins => $2)
+	`
+
+	rows, err := tx.Query(ctx, query, orderId, o.cfg.CourierLocationTTL)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var candidates []courierCandidate
+
+	for rows.Next() {
+
+		var (
+			candidate courierCandidate
+			location  geo.Point
+		)
+
+		err = rows.Scan(&candidate.id, &location.Latitude, &location.Longitude, &candidate.load)
+		if err != nil {
+			return nil, err
+		}
+
+		if candidate.load >= o.cfg.CourierMaxActiveOrders {
+			continue
+		}
+
+		candidate.distance = geo.Distance(point, location)
+		candidates = append(candidates, candidate)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.distance != b.distance {
+			return a.distance < b.distance
+		}
+		if a.load != b.load {
+			return a.load < b.load
+		}
+		return a.id < b.id
+	})
+
+	lockQuery := `
+		SELECT
+			(
+				SELECT COUNT(*)
+				FROM orders o
+				WHERE o.courier_id = c.id
+					AND o.id <> $2
+					AND o.status IN ('new', 'accepted', 'assigned', 'picked_up')
+			)
+		FROM courier c
+		WHERE c.id = $1
+			AND c.is_available
+			AND c.deleted_at IS NULL
+		FOR UPDATE OF c SKIP LOCKED
+	`
+
+	for _, candidate := range candidates {
+
+		err = tx.QueryRow(ctx, lockQuery, candidate.id, orderId).Scan(&candidate.load)
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if candidate.load < o.cfg.CourierMaxActiveOrders {
+			return &candidate, nil
+		}
+	}
+
+	return nil, storage.ErrNoCourierAvailable
+}
+
+// autoAssignCourier assigns the nearest available courier to an order and
+// records the distance to them.
+func (o *orderRepo) autoAssignCourier(ctx context.Context, tx pgx.Tx, orderId string, point geo.Point) (*models.OrderCourierEvent, error) {
+
+	courier, err := o.nearestCourier(ctx, tx, orderId, point)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		UPDATE
+			orders
+		SET
+			courier_id = $1,
+			courier_distance = $2,
+			assignment_mode = $3,
+			assigned_at = now(),
+			updated_at = now()
+		WHERE id = $4
+	`
+
+	_, err = tx.Exec(ctx, query, courier.id, courier.distance, models.AssignmentModeAuto, orderId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.OrderCourierEvent{
+		Order_id:         orderId,
+		Courier_id:       courier.id,
+		Courier_distance: &courier.distance,
+		Assignment_mode:  models.AssignmentModeAuto,
+	}, nil
+}
+
+// lockAssignableCourier locks a courier picked by the client for an order and
+// returns their last known location, nil when they have not reported one.
+// Missing or deleted couriers violate orders_courier_id_fkey, and couriers
+// who are not available are storage.ErrCourierUnavailable.
+func lockAssignableCourier(ctx context.Context, tx pgx.Tx, courierId string) (*geo.Point, error) {
+
+	var (
+		latitude  *float64
+		longitude *float64
+		available bool
+		deleted   bool
+	)
+
+	err := tx.QueryRow(ctx,
+		"SELECT latitude, longitude, is_available, deleted_at IS NOT NULL FROM courier WHERE id = $1 FOR UPDATE", courierId,
+	).Scan(&latitude, &longitude, &available, &deleted)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && deleted) {
+		return nil, storage.ForeignKeyViolation("orders_courier_id_fkey")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !available {
+		return nil, storage.ErrCourierUnavailable
+	}
+
+	if latitude == nil || longitude == nil {
+		return nil, nil
+	}
+
+	return &geo.Point{Latitude: *latitude, Longitude: *longitude}, nil
+}
+
+// manualAssignCourier records a courier picked by the client. The distance is
+// only known when the courier has reported a location.
+func (o *orderRepo) manualAssignCourier(ctx context.Context, tx pgx.Tx, orderId, courierId string, point geo.Point) (*models.OrderCourierEvent, error) {
+
+	var distance *float64
+
+	location, err := lockAssignableCourier(ctx, tx, courierId)
+	if err != nil {
+		return nil, err
+	}
+
+	if location != nil {
+		d := geo.Distance(point, *location)
+		distance = &d
+	}
+
+	query := `
+		UPDATE
+			orders
+		SET
+			courier_id = $1,
+			courier_distance = $2,
+			assignment_mode = $3,
+			assigned_at = now(),
+			updated_at = now()
+		WHERE id = $4
+	`
+
+	_, err = tx.Exec(ctx, query, courierId, distance, models.AssignmentModeManual, orderId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.OrderCourierEvent{
+		Order_id:         orderId,
+		Courier_id:       courierId,
+		Courier_distance: distance,
+		Assignment_mode:  models.AssignmentModeManual,
+	}, nil
+}
+
+func (o *orderRepo) AssignCourier(ctx context.Context, req *models.OrderPrimaryKey) (int64, error) {
+
+	var (
+		status string
+		point  geo.Point
+	)
+
+	tx, err := o.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx,
+		"SELECT status, latitude, longtitude FROM orders WHERE id = $1 FOR UPDATE", req.Id,
+	).Scan(&status, &point.Latitude, &point.Longitude)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	switch status {
+	case models.OrderStatusNew, models.OrderStatusAccepted, models.OrderStatusAssigned:
+	default:
+		return 0, storage.ErrCourierAssignmentLocked
+	}
+
+	assignment, err := o.autoAssignCourier(ctx, tx, req.Id, point)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	o.events.Publish(events.OrderTopic(req.Id), events.OrderCourierAssigned, assignment)
+
+	return 1, nil
+}
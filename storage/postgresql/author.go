@@ -2,19 +2,19 @@ package postgresql
 
 import (
 	"app/api/models"
+	"app/storage"
 	"context"
 	"fmt"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v4/pgxpool"
 )
 
 
 type authorRepo struct{
-	db *pgxpool.Pool
+	db DB
 }
 
-func NewAuthorRepo(db *pgxpool.Pool) *authorRepo {
+func NewAuthorRepo(db DB) *authorRepo {
 	return &authorRepo{
 		db: db,
 	}
@@ -53,15 +53,17 @@ func (a *authorRepo) AuthorGetById(ctx context.Context, req *models.AuthorPrimar
 	query := `SELECT
 				id,
 				name,
-				TO_CHAR(created_at, 'YYYY-MM-DD HH24-MI-SS')
+				TO_CHAR(created_at, 'YYYY-MM-DD HH24-MI-SS'),
+				COALESCE(TO_CHAR(deleted_at, 'YYYY-MM-DD HH24-MI-SS'), '')
 			FROM author
-			WHERE id = $1  	
+			WHERE id = $1 AND ($2 OR deleted_at IS NULL)
 	`
 
-	err := a.db.QueryRow(ctx, query, req.Id).Scan(
+	err := a.db.QueryRow(ctx, query, req.Id, req.Include_deleted).Scan(
 		&resp.Id,
 		&resp.Name,
 		&resp.CreatedAt,
+		&resp.DeletedAt,
 	)
 
 	if err != nil{
@@ -75,7 +77,7 @@ func (a *authorRepo) GetListAuthor(ctx context.Context, req *models.GetListAutho
 
 	var (
 		query 		string
-		filter	= 	" WHERE  TRUE"
+		filter	= 	storage.NewFilter()
 		offset 	= 	" OFFSET 0"
 		limit	=	" LIMIT 0"	
 	)
@@ -85,13 +87,14 @@ func (a *authorRepo) GetListAuthor(ctx context.Context, req *models.GetListAutho
 			COUNT(*) OVER(),
 			id,
 			name,
-			TO_CHAR(created_at, 'YYYY-MM-DD HH24-MI-SS')
+			TO_CHAR(created_at, 'YYYY-MM-DD HH24-MI-SS'),
+			COALESCE(TO_CHAR(deleted_at, 'YYYY-MM-DD HH24-MI-SS'), '')
 		FROM author
 	`
 
-	if len(req.Search) > 0{
-		filter += " AND name ILIKE '%' || '" + req.Search + "' || '%' "
-	}
+	filter.
+		Search(req.Search, "name").
+		SoftDeleted(req.Include_deleted)
 
 	if req.Offset > 0{
 		offset = fmt.Sprintf(" OFFSET %d", req.Offset)
@@ -101,11 +104,21 @@ func (a *authorRepo) GetListAuthor(ctx context.Context, req *models.GetListAutho
 		limit = fmt.Sprintf(" LIMIT %d", req.Limit)
 	}
 
-	query += filter + offset + limit
+	orderBy, sort, err := storage.OrderBy(req.Sort, models.AuthorSortFields, "name")
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Cursor != nil {
+		orderBy, sort = filter.Keyset(req.Cursor), storage.KeysetOrder
+		offset, limit = " OFFSET 0", fmt.Sprintf(" LIMIT %d", req.Limit+1)
+	}
+
+	query += filter.String() + orderBy + offset + limit
 
 	var resp models.GetListAuthorResponse
 
-	rows, err := a.db.Query(ctx, query)
+	rows, err := a.db.Query(ctx, query, filter.Args()...)
 	if err != nil{
 		return nil, err
 	}
@@ -120,12 +133,24 @@ func (a *authorRepo) GetListAuthor(ctx context.Context, req *models.GetListAutho
 			&author.Id,
 			&author.Name,
 			&author.CreatedAt,
+			&author.DeletedAt,
 		)
 
 		resp.Authors = append(resp.Authors, &author)
 
 	}
 
+	if req.Cursor != nil && len(resp.Authors) > req.Limit {
+		resp.Authors = resp.Authors[:req.Limit]
+
+		resp.Next_cursor, err = nextCursor(ctx, a.db, "author", resp.Authors[req.Limit-1].Id)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	resp.Sort = sort
+
 	return &resp, nil
 
 }
@@ -137,7 +162,7 @@ func (a *authorRepo) UpdateAuthor(ctx context.Context, req *models.UpdateAuthor)
 			author
 		SET
 			name = $1
-		WHERE id = $2
+		WHERE id = $2 AND deleted_at IS NULL
 	`
 
 	rows, err := a.db.Exec(ctx, query, 
@@ -153,14 +178,9 @@ func (a *authorRepo) UpdateAuthor(ctx context.Context, req *models.UpdateAuthor)
 }
 
 func (a *authorRepo) DeleteAuthor(ctx context.Context, req *models.AuthorPrimaryKey) error {
+	return softDelete(ctx, a.db, "author", req.Id)
+}
 
-	_, err := a.db.Exec(
-		ctx, "DELETE FROM author WHERE id = $1", req.Id,
-	)
-
-	if err != nil {
-		return err
-	}
-	
-	return nil
+func (a *authorRepo) RestoreAuthor(ctx context.Context, req *models.AuthorPrimaryKey) error {
+	return restore(ctx, a.db, "author", req.Id)
 }
\ No newline at end of file
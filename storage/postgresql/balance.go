@@ -0,0 +1,325 @@
+package postgresql
+
+import (
+	"app/api/models"
+	"app/pkg/helper"
+	"app/storage"
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+// addBalanceTransaction moves the balance of a user by req.Amount and records
+// the movement in the ledger together with the resulting balance, so that the
+// ledger always adds up to users.balance. The user row is locked for the rest
+// of the transaction; a movement that would make the balance negative fails
+// with storage.InsufficientBalanceError.
+func addBalanceTransaction(ctx context.Context, tx pgx.Tx, req *models.CreateBalanceTransaction) (string, error) {
+
+	var (
+		id      = uuid.New().String()
+		balance float64
+	)
+
+	err := tx.QueryRow(ctx, "SELECT balance FROM users WHERE id = $1 FOR UPDATE", req.User_id).Scan(&balance)
+	if err != nil {
+		return "", err
+	}
+
+	if balance+req.Amount < 0 {
+		return "", &storage.InsufficientBalanceError{
+			UserId:   req.User_id,
+			Balance:  balance,
+			Required: -req.Amount,
+		}
+	}
+
+	balance += req.Amount
+
+	_, err = tx.Exec(ctx, "UPDATE users SET balance = $1, updated_at = now() WHERE id = $2", balance, req.User_id)
+	if err != nil {
+		return "", err
+	}
+
+	query := `
+		INSERT INTO balance_transactions(
+			id,
+			user_id,
+			type,
+			amount,
+			balance_after,
+			order_id,
+			description
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	_, err = tx.Exec(ctx, query,
+		id,
+		req.User_id,
+		req.Type,
+		req.Amount,
+		balance,
+		helper.NewNullString(req.Order_id),
+		helper.NewNullString(req.Description),
+	)
+	if err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+// settleOrderPayment brings the wallet charges of an order in line with its
+// current state: the payer is charged the order price while the order is open,
+// everything is refunded once it is cancelled or returned, and any user who
+// paid for the order but is no longer its payer gets their money back.
+func settleOrderPayment(ctx context.Context, tx pgx.Tx, orderId string) error {
+
+	var (
+		payer  string
+		price  float64
+		status string
+	)
+
+	err := tx.QueryRow(ctx,
+		"SELECT user_id, COALESCE(price, 0), status FROM orders WHERE id = $1", orderId,
+	).Scan(&payer, &price, &status)
+	if err != nil {
+		return err
+	}
+
+	charged, err := orderCharges(ctx, tx, orderId)
+	if err != nil {
+		return err
+	}
+
+	if status == models.OrderStatusCancelled || status == models.OrderStatusReturned {
+		price = 0
+	}
+
+	for userId, amount := range charged {
+		if userId != payer && amount > 0 {
+			err = settleDifference(ctx, tx, orderId, userId, -amount)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return settleDifference(ctx, tx, orderId, payer, price-charged[payer])
+}
+
+// refundOrderPayment gives back everything users paid for an order, as when it
+// is cancelled, so that no charge is left once the order is deleted.
+func refundOrderPayment(ctx context.Context, tx pgx.Tx, orderId string) error {
+
+	charged, err := orderCharges(ctx, tx, orderId)
+	if err != nil {
+		return err
+	}
+
+	for userId, amount := range charged {
+		err = settleDifference(ctx, tx, orderId, userId, -amount)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// orderCharges returns what each user has paid for an order, net of refunds.
+func orderCharges(ctx context.Context, tx pgx.Tx, orderId string) (map[string]float64, error) {
+
+	charged := make(map[string]float64)
+
+	query := `
+		SELECT
+			user_id,
+			-SUM(amount)
+		FROM balance_transactions
+		WHERE order_id = $1 AND type IN ('order_debit', 'refund')
+		GROUP BY user_id
+		ORDER BY user_id
+	`
+
+	rows, err := tx.Query(ctx, query, orderId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+
+		var (
+			userId string
+			amount float64
+		)
+
+		err = rows.Scan(&userId, &amount)
+		if err != nil {
+			return nil, err
+		}
+
+		charged[userId] = amount
+	}
+
+	return charged, rows.Err()
+}
+
+// settleDifference debits (diff > 0) or refunds (diff < 0) a user for an order.
+func settleDifference(ctx context.Context, tx pgx.Tx, orderId, userId string, diff float64) error {
+
+	var req = models.CreateBalanceTransaction{
+		User_id:  userId,
+		Order_id: orderId,
+	}
+
+	diff = math.Round(diff*100) / 100
+
+	switch {
+	case diff > 0:
+		req.Type = models.BalanceTransactionOrderDebit
+		req.Amount = -diff
+		req.Description = fmt.Sprintf("payment for order %s", orderId)
+	case diff < 0:
+		req.Type = models.BalanceTransactionRefund
+		req.Amount = -diff
+		req.Description = fmt.Sprintf("refund for order %s", orderId)
+	default:
+		return nil
+	}
+
+	_, err := addBalanceTransaction(ctx, tx, &req)
+
+	return err
+}
+
+func (u *userRepo) CreateBalanceTransaction(ctx context.Context, req *models.CreateBalanceTransaction) (string, error) {
+
+	tx, err := u.db.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback(ctx)
+
+	id, err := addBalanceTransaction(ctx, tx, req)
+	if err != nil {
+		return "", err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+func (u *userRepo) GetByIdBalanceTransaction(ctx context.Context, req *models.BalanceTransactionPrimaryKey) (*models.BalanceTransaction, error) {
+
+	var (
+		query       string
+		transaction models.BalanceTransaction
+	)
+
+	query = `
+		SELECT
+			id,
+			user_id,
+			type,
+			amount,
+			balance_after,
+			COALESCE(order_id::VARCHAR, ''),
+			COALESCE(description, ''),
+			TO_CHAR(created_at, 'YYYY-MM-DD HH24-MI-SS')
+		FROM balance_transactions
+		WHERE id = $1
+	`
+
+	err := u.db.QueryRow(ctx, query, req.Id).Scan(
+		&transaction.Id,
+		&transaction.User_id,
+		&transaction.Type,
+		&transaction.Amount,
+		&transaction.Balance_after,
+		&transaction.Order_id,
+		&transaction.Description,
+		&transaction.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &transaction, nil
+}
+
+func (u *userRepo) GetListBalanceTransactions(ctx context.Context, req *models.GetListBalanceTransactionRequest) (*models.GetListBalanceTransactionResponse, error) {
+
+	var (
+		query  string
+		offset = " OFFSET 0"
+		limit  = " LIMIT 10"
+	)
+
+	query = `
+		SELECT
+			COUNT(*) OVER(),
+			id,
+			user_id,
+			type,
+			amount,
+			balance_after,
+			COALESCE(order_id::VARCHAR, ''),
+			COALESCE(description, ''),
+			TO_CHAR(created_at, 'YYYY-MM-DD HH24-MI-SS')
+		FROM balance_transactions
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+	`
+
+	if req.Offset > 0 {
+		offset = fmt.Sprintf(" OFFSET %d", req.Offset)
+	}
+
+	if req.Limit > 0 {
+		limit = fmt.Sprintf(" LIMIT %d", req.Limit)
+	}
+
+	query += offset + limit
+
+	rows, err := u.db.Query(ctx, query, req.User_id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	resp := &models.GetListBalanceTransactionResponse{}
+
+	for rows.Next() {
+
+		var transaction models.BalanceTransaction
+
+		err = rows.Scan(
+			&resp.Count,
+			&transaction.Id,
+			&transaction.User_id,
+			&transaction.Type,
+			&transaction.Amount,
+			&transaction.Balance_after,
+			&transaction.Order_id,
+			&transaction.Description,
+			&transaction.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		resp.Transactions = append(resp.Transactions, &transaction)
+	}
+
+	return resp, rows.Err()
+}
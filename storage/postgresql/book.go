@@ -5,17 +5,17 @@ import (
 	"fmt"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v4/pgxpool"
 
 	"app/api/models"
 	"app/pkg/helper"
+	"app/storage"
 )
 
 type bookRepo struct {
-	db *pgxpool.Pool
+	db DB
 }
 
-func NewBookRepo(db *pgxpool.Pool) *bookRepo {
+func NewBookRepo(db DB) *bookRepo {
 	return &bookRepo{
 		db: db,
 	}
@@ -79,12 +79,13 @@ func (r *bookRepo) GetByID(ctx context.Context, req *models.BookPrimaryKey) (*mo
 			COALESCE(profit,0),
 			sell_price,
 			TO_CHAR(created_at, 'YYYY-MM-DD HH24-MI-SS'), 
-			TO_CHAR(updated_at, 'YYYY-MM-DD HH24-MI-SS')
+			TO_CHAR(updated_at, 'YYYY-MM-DD HH24-MI-SS'),
+			COALESCE(TO_CHAR(deleted_at, 'YYYY-MM-DD HH24-MI-SS'), '')
 		FROM book
-		WHERE id = $1
+		WHERE id = $1 AND ($2 OR deleted_at IS NULL)
 	`
 
-	err := r.db.QueryRow(ctx, query, req.Id).Scan(
+	err := r.db.QueryRow(ctx, query, req.Id, req.Include_deleted).Scan(
 		&resp.Id,
 		&resp.Name,
 		&resp.Price,
@@ -95,6 +96,7 @@ func (r *bookRepo) GetByID(ctx context.Context, req *models.BookPrimaryKey) (*mo
 		&resp.Sell_price,
 		&resp.CreatedAt,
 		&resp.UpdatedAt,
+		&resp.DeletedAt,
 	)
 
 	if err != nil {
@@ -110,7 +112,7 @@ func (r *bookRepo) GetList(ctx context.Context, req *models.GetListBookRequest)
 
 	var (
 		query  string
-		filter = " WHERE TRUE "
+		filter = storage.NewFilter()
 		offset = " OFFSET 0"
 		limit  = " LIMIT 10"
 	)
@@ -127,13 +129,15 @@ func (r *bookRepo) GetList(ctx context.Context, req *models.GetListBookRequest)
 			COALESCE(profit,0),
 			sell_price,
 			TO_CHAR(created_at, 'YYYY-MM-DD HH24-MI-SS'), 
-			TO_CHAR(updated_at, 'YYYY-MM-DD HH24-MI-SS')
+			TO_CHAR(updated_at, 'YYYY-MM-DD HH24-MI-SS'),
+			COALESCE(TO_CHAR(deleted_at, 'YYYY-MM-DD HH24-MI-SS'), '')
 		FROM book
 	`
 
-	if len(req.Search) > 0 {
-		filter += " AND name ILIKE '%' || '" + req.Search + "' || '%' "
-	}
+	filter.
+		Search(req.Search, "name").
+		Range("price", req.Min_price, req.Max_price).
+		SoftDeleted(req.Include_deleted)
 
 	if req.Offset > 0 {
 		offset = fmt.Sprintf(" OFFSET %d", req.Offset)
@@ -143,9 +147,19 @@ func (r *bookRepo) GetList(ctx context.Context, req *models.GetListBookRequest)
 		limit = fmt.Sprintf(" LIMIT %d", req.Limit)
 	}
 
-	query += filter + offset + limit
+	orderBy, sort, err := storage.OrderBy(req.Sort, models.BookSortFields, "name")
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Cursor != nil {
+		orderBy, sort = filter.Keyset(req.Cursor), storage.KeysetOrder
+		offset, limit = " OFFSET 0", fmt.Sprintf(" LIMIT %d", req.Limit+1)
+	}
+
+	query += filter.String() + orderBy + offset + limit
 
-	rows, err := r.db.Query(ctx, query)
+	rows, err := r.db.Query(ctx, query, filter.Args()...)
 	if err != nil {
 		return nil, err
 	}
@@ -166,6 +180,7 @@ func (r *bookRepo) GetList(ctx context.Context, req *models.GetListBookRequest)
 			&book.Sell_price,
 			&book.CreatedAt,
 			&book.UpdatedAt,
+			&book.DeletedAt,
 		)
 
 		if err != nil {
@@ -175,6 +190,17 @@ func (r *bookRepo) GetList(ctx context.Context, req *models.GetListBookRequest)
 		resp.Books = append(resp.Books, &book)
 	}
 
+	if req.Cursor != nil && len(resp.Books) > req.Limit {
+		resp.Books = resp.Books[:req.Limit]
+
+		resp.Next_cursor, err = nextCursor(ctx, r.db, "book", resp.Books[req.Limit-1].Id)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	resp.Sort = sort
+
 	return resp, nil
 }
 
@@ -197,7 +223,7 @@ func (r *bookRepo) Update(ctx context.Context, req *models.UpdateBook) (int64, e
 			profit	= :profit,
 			sell_price = :sell_price,
 			updated_at = now()
-		WHERE id = :id
+		WHERE id = :id AND deleted_at IS NULL
 	`
 
 	params = map[string]interface{}{
@@ -223,14 +249,9 @@ func (r *bookRepo) Update(ctx context.Context, req *models.UpdateBook) (int64, e
 }
 
 func (r *bookRepo) Delete(ctx context.Context, req *models.BookPrimaryKey) error {
+	return softDelete(ctx, r.db, "book", req.Id)
+}
 
-	_, err := r.db.Exec(
-		ctx, "DELETE FROM book WHERE id = $1", req.Id,
-	)
-
-	if err != nil {
-		return err
-	}
-
-	return nil
+func (r *bookRepo) Restore(ctx context.Context, req *models.BookPrimaryKey) error {
+	return restore(ctx, r.db, "book", req.Id)
 }
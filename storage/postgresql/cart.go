@@ -0,0 +1,312 @@
+package postgresql
+
+import (
+	"app/api/models"
+	"app/pkg/events"
+	"app/storage"
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type cartRepo struct {
+	db    DB
+	order *orderRepo
+}
+
+func NewCartRepo(db DB, order *orderRepo) *cartRepo {
+	return &cartRepo{
+		db:    db,
+		order: order,
+	}
+}
+
+// CreateCart returns the cart of the customer, creating it on first use.
+func (c *cartRepo) CreateCart(ctx context.Context, req *models.CreateCart) (string, error) {
+
+	var id string
+
+	query := `
+		INSERT INTO carts (
+			id,
+			customer_id,
+			updated_at
+		) VALUES ($1, $2, now())
+		ON CONFLICT (customer_id) DO UPDATE SET customer_id = EXCLUDED.customer_id
+		RETURNING id
+	`
+
+	err := c.db.QueryRow(ctx, query, uuid.New().String(), req.Customer_id).Scan(&id)
+	if err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+func (c *cartRepo) GetByIdCart(ctx context.Context, req *models.CartPrimaryKey) (*models.Cart, error) {
+
+	var (
+		query string
+		cart  models.Cart
+	)
+
+	query = `
+		SELECT
+			id,
+			customer_id,
+			TO_CHAR(created_at, 'YYYY-MM-DD HH24-MI-SS'),
+			COALESCE(TO_CHAR(updated_at, 'YYYY-MM-DD HH24-MI-SS'), '')
+		FROM carts
+		WHERE id = $1
+	`
+
+	err := c.db.QueryRow(ctx, query, req.Id).Scan(
+		&cart.Id,
+		&cart.Customer_id,
+		&cart.CreatedAt,
+		&cart.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	query = `
+		SELECT
+			ci.product_id,
+			COALESCE(p.name, ''),
+			ci.quantity,
+			p.price
+		FROM cart_items ci
+		LEFT JOIN products p ON p.id = ci.product_id AND p.deleted_at IS NULL
+		WHERE ci.cart_id = $1
+		ORDER BY ci.created_at, ci.product_id
+	`
+
+	rows, err := c.db.Query(ctx, query, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+
+		var (
+			item  models.CartItem
+			price *float64
+		)
+
+		err = rows.Scan(
+			&item.Product_id,
+			&item.Name,
+			&item.Quantity,
+			&price,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		// Products that were removed from the catalog or have no price are
+		// reported separately and do not count towards the total.
+		if price == nil {
+			cart.Unavailable_items = append(cart.Unavailable_items, &item)
+			continue
+		}
+
+		item.Price = *price
+		item.Total_price = item.Price * float64(item.Quantity)
+		cart.Total_price += item.Total_price
+
+		cart.Items = append(cart.Items, &item)
+	}
+
+	return &cart, rows.Err()
+}
+
+// AddCartItem puts a product into the cart, adding to the quantity already
+// there. It affects no rows when the product does not exist.
+func (c *cartRepo) AddCartItem(ctx context.Context, req *models.AddCartItem) (int64, error) {
+
+	query := `
+		INSERT INTO cart_items (
+			cart_id,
+			product_id,
+			quantity,
+			updated_at
+		)
+		SELECT $1, p.id, $3, now()
+		FROM products p
+		WHERE p.id = $2 AND p.deleted_at IS NULL
+		ON CONFLICT (cart_id, product_id) DO UPDATE SET
+			quantity = cart_items.quantity + EXCLUDED.quantity,
+			updated_at = now()
+	`
+
+	res, err := c.db.Exec(ctx, query, req.Cart_id, req.Product_id, req.Quantity)
+	if err != nil {
+		return 0, err
+	}
+
+	if res.RowsAffected() > 0 {
+		err = c.touch(ctx, req.Cart_id)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return res.RowsAffected(), nil
+}
+
+func (c *cartRepo) UpdateCartItem(ctx context.Context, req *models.UpdateCartItem) (int64, error) {
+
+	query := `
+		UPDATE
+			cart_items
+		SET
+			quantity = $1,
+			updated_at = now()
+		WHERE cart_id = $2 AND product_id = $3
+	`
+
+	res, err := c.db.Exec(ctx, query, req.Quantity, req.Cart_id, req.Product_id)
+	if err != nil {
+		return 0, err
+	}
+
+	if res.RowsAffected() > 0 {
+		err = c.touch(ctx, req.Cart_id)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return res.RowsAffected(), nil
+}
+
+func (c *cartRepo) DeleteCartItem(ctx context.Context, req *models.CartItemPrimaryKey) error {
+
+	_, err := c.db.Exec(ctx,
+		"DELETE FROM cart_items WHERE cart_id = $1 AND product_id = $2", req.Cart_id, req.Product_id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return c.touch(ctx, req.Cart_id)
+}
+
+func (c *cartRepo) touch(ctx context.Context, cartId string) error {
+
+	_, err := c.db.Exec(ctx, "UPDATE carts SET updated_at = now() WHERE id = $1", cartId)
+
+	return err
+}
+
+// Checkout turns the cart into an order and empties it in one transaction.
+// Items whose product is gone make the whole checkout fail with
+// storage.UnavailableProductsError so the customer can fix the cart first.
+func (c *cartRepo) Checkout(ctx context.Context, req *models.CheckoutCart) (string, error) {
+
+	tx, err := c.db.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback(ctx)
+
+	var customerId string
+
+	err = tx.QueryRow(ctx, "SELECT customer_id FROM carts WHERE id = $1 FOR UPDATE", req.Cart_id).Scan(&customerId)
+	if err != nil {
+		return "", err
+	}
+
+	query := `
+		SELECT
+			ci.product_id,
+			ci.quantity,
+			p.id IS NOT NULL AND p.price IS NOT NULL
+		FROM cart_items ci
+		LEFT JOIN products p ON p.id = ci.product_id AND p.deleted_at IS NULL
+		WHERE ci.cart_id = $1
+		ORDER BY ci.created_at, ci.product_id
+	`
+
+	rows, err := tx.Query(ctx, query, req.Cart_id)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	var (
+		items       []*models.CreateOrderItem
+		unavailable []string
+	)
+
+	for rows.Next() {
+
+		var (
+			item      models.CreateOrderItem
+			available bool
+		)
+
+		err = rows.Scan(&item.Product_id, &item.Quantity, &available)
+		if err != nil {
+			return "", err
+		}
+
+		if !available {
+			unavailable = append(unavailable, item.Product_id)
+			continue
+		}
+
+		items = append(items, &item)
+	}
+
+	if err = rows.Err(); err != nil {
+		return "", err
+	}
+	rows.Close()
+
+	if len(unavailable) > 0 {
+		return "", &storage.UnavailableProductsError{ProductIds: unavailable}
+	}
+
+	if len(items) <= 0 {
+		return "", storage.ErrCartEmpty
+	}
+
+	id, assignment, err := c.order.createOrder(ctx, tx, &models.CreateOrder{
+		Name:         req.Name,
+		Phone_number: req.Phone_number,
+		Address_id:   req.Address_id,
+		Latitude:     req.Latitude,
+		Longtitude:   req.Longtitude,
+		User_id:      req.User_id,
+		Customer_id:  customerId,
+		Courier_id:   req.Courier_id,
+		Items:        items,
+		Api_key_id:   req.Api_key_id,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	_, err = tx.Exec(ctx, "DELETE FROM cart_items WHERE cart_id = $1", req.Cart_id)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = tx.Exec(ctx, "UPDATE carts SET updated_at = now() WHERE id = $1", req.Cart_id)
+	if err != nil {
+		return "", err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	c.order.events.Publish(events.OrderTopic(id), events.OrderCourierAssigned, assignment)
+
+	return id, nil
+}
@@ -2,18 +2,18 @@ package postgresql
 
 import (
 	"app/api/models"
+	"app/storage"
 	"context"
 	"fmt"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v4/pgxpool"
 )
 
 type categoryRepo struct{
-	db	*pgxpool.Pool
+	db	DB
 }
 
-func NewCategoryRepoI(db *pgxpool.Pool) *categoryRepo {
+func NewCategoryRepoI(db DB) *categoryRepo {
 	return &categoryRepo{
 		db: db,
 	}
@@ -56,15 +56,19 @@ func (c *categoryRepo) GetByIdCategory(ctx context.Context, req *models.Category
 	query = `
 		SELECT
 			id,
-			name
+			name,
+			TO_CHAR(created_at, 'YYYY-MM-DD HH24-MI-SS'),
+			COALESCE(TO_CHAR(deleted_at, 'YYYY-MM-DD HH24-MI-SS'), '')
 		FROM
 			categories
-		WHERE id = $1
+		WHERE id = $1 AND ($2 OR deleted_at IS NULL)
 	`
 
-	err := c.db.QueryRow(ctx, query, req.Id).Scan(
+	err := c.db.QueryRow(ctx, query, req.Id, req.Include_deleted).Scan(
 		&category.Id,
 		&category.Name,
+		&category.CreatedAt,
+		&category.DeletedAt,
 	)
 	
 	if err != nil{
@@ -79,7 +83,7 @@ func (c *categoryRepo) GetListCategory(ctx context.Context, req *models.GetListC
 
 	var (
 		query		string
-		filter = 	" WHERE TRUE"
+		filter = 	storage.NewFilter()
 		offset = 	" OFFSET 0"
 		limit = 	" LIMIT 0"
 	)
@@ -88,14 +92,16 @@ func (c *categoryRepo) GetListCategory(ctx context.Context, req *models.GetListC
 		SELECT
 			COUNT(*) OVER(),
 			id,
-			name
+			name,
+			TO_CHAR(created_at, 'YYYY-MM-DD HH24-MI-SS'),
+			COALESCE(TO_CHAR(deleted_at, 'YYYY-MM-DD HH24-MI-SS'), '')
 		FROM 
 			categories
 	`
 
-	if len(req.Search) > 0{
-		filter += " AND name ILIKE '%' || '" + req.Search + "' || '%' "
-	}  
+	filter.
+		Search(req.Search, "name").
+		SoftDeleted(req.Include_deleted)
 
 	if req.Offset > 0{
 		offset = fmt.Sprintf(" OFFSET %d", req.Offset)
@@ -105,9 +111,19 @@ func (c *categoryRepo) GetListCategory(ctx context.Context, req *models.GetListC
 		limit = fmt.Sprintf(" LIMIT %d", req.Limit)
 	}
 
-	query += filter + offset + limit
+	orderBy, sort, err := storage.OrderBy(req.Sort, models.CategorySortFields, "name")
+	if err != nil {
+		return nil, err
+	}
 
-	rows, err := c.db.Query(ctx, query)
+	if req.Cursor != nil {
+		orderBy, sort = filter.Keyset(req.Cursor), storage.KeysetOrder
+		offset, limit = " OFFSET 0", fmt.Sprintf(" LIMIT %d", req.Limit+1)
+	}
+
+	query += filter.String() + orderBy + offset + limit
+
+	rows, err := c.db.Query(ctx, query, filter.Args()...)
 	if err != nil{
 		return nil, err
 	}
@@ -122,14 +138,27 @@ func (c *categoryRepo) GetListCategory(ctx context.Context, req *models.GetListC
 			&resp.Count,
 			&category.Id,
 			&category.Name,
+			&category.CreatedAt,
+			&category.DeletedAt,
 		)
 
 		resp.Categories = append(resp.Categories, &category)
 
 	}
 
+	if req.Cursor != nil && len(resp.Categories) > req.Limit {
+		resp.Categories = resp.Categories[:req.Limit]
+
+		resp.Next_cursor, err = nextCursor(ctx, c.db, "categories", resp.Categories[req.Limit-1].Id)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// resp.Count = len(resp.Categories)
 
+	resp.Sort = sort
+
 	return &resp, nil
 }
 
@@ -140,7 +169,7 @@ func (c *categoryRepo) UpdateCategory(ctx context.Context, req *models.UpdateCat
 			categories
 		SET
 			name = $1
-		WHERE id = $2
+		WHERE id = $2 AND deleted_at IS NULL
 	`
 
 	res, err := c.db.Exec(ctx, query, 
@@ -154,15 +183,26 @@ func (c *categoryRepo) UpdateCategory(ctx context.Context, req *models.UpdateCat
 	return res.RowsAffected(), nil
 }
 
+// DeleteCategory soft deletes the category. It is refused while products that
+// are not deleted belong to it.
 func (c *categoryRepo) DeleteCategory(ctx context.Context, req *models.CategoryPrimaryKey) (error) {
 
-	_, err := c.db.Exec(ctx, 
-		"DELETE FROM categories WHERE id = $1", req.Id,
-	)
+	var used bool
 
+	err := c.db.QueryRow(ctx,
+		"SELECT EXISTS (SELECT 1 FROM products WHERE category_id = $1 AND deleted_at IS NULL)", req.Id,
+	).Scan(&used)
 	if err != nil{
 		return err
 	}
 
-	return nil
-}
\ No newline at end of file
+	if used{
+		return storage.ForeignKeyViolation("products_category_id_fkey")
+	}
+
+	return softDelete(ctx, c.db, "categories", req.Id)
+}
+
+func (c *categoryRepo) RestoreCategory(ctx context.Context, req *models.CategoryPrimaryKey) (error) {
+	return restore(ctx, c.db, "categories", req.Id)
+}
@@ -2,20 +2,23 @@ package postgresql
 
 import (
 	"app/api/models"
+	"app/pkg/events"
+	"app/storage"
 	"context"
 	"fmt"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v4/pgxpool"
 )
 
 type courierRepo struct{
-	db	*pgxpool.Pool
+	db		DB
+	events	events.Publisher
 }
 
-func NewCourierRepo(db *pgxpool.Pool) *courierRepo{
+func NewCourierRepo(db DB, publisher events.Publisher) *courierRepo{
 	return &courierRepo{
 		db: db,
+		events: publisher,
 	}
 }
 
@@ -31,14 +34,21 @@ func (c *courierRepo) CreateCourier(ctx context.Context, req *models.CreateCouri
 			id,
 			name,
 			phone_number,
+			latitude,
+			longitude,
+			location_updated_at,
+			is_available,
 			updated_at
-		) VALUES($1, $2, $3, now())
+		) VALUES($1, $2, $3, $4, $5, CASE WHEN $4::NUMERIC IS NOT NULL THEN now() END, COALESCE($6, TRUE), now())
 	`
 
 	_, err := c.db.Exec(ctx, query,
 		id,
 		req.Name,
 		req.Phone_number,
+		req.Latitude,
+		req.Longitude,
+		req.Is_available,
 	)
 	if err != nil{
 		return "", err
@@ -59,18 +69,28 @@ func (c *courierRepo) GetByIDCourier(ctx context.Context, req *models.CourierPri
 			id,
 			name,
 			phone_number,
+			latitude,
+			longitude,
+			COALESCE(TO_CHAR(location_updated_at, 'YYYY-MM-DD HH24-MI-SS'), ''),
+			is_available,
 			TO_CHAR(created_at, 'YYYY-MM-DD HH24-MI-SS'),
-			TO_CHAR(updated_at, 'YYYY-MM-DD HH24-MI-SS')
+			TO_CHAR(updated_at, 'YYYY-MM-DD HH24-MI-SS'),
+			COALESCE(TO_CHAR(deleted_at, 'YYYY-MM-DD HH24-MI-SS'), '')
 		FROM courier
-		WHERE id = $1
+		WHERE id = $1 AND ($2 OR deleted_at IS NULL)
 	`
 
-	err := c.db.QueryRow(ctx, query, req.Id).Scan(
+	err := c.db.QueryRow(ctx, query, req.Id, req.Include_deleted).Scan(
 		&courier.Id,
 		&courier.Name,
 		&courier.Phone_number,
+		&courier.Latitude,
+		&courier.Longitude,
+		&courier.Location_updated_at,
+		&courier.Is_available,
 		&courier.CreatedAt,
 		&courier.UpdatedAt,
+		&courier.DeletedAt,
 	)
 
 	if err != nil{
@@ -84,7 +104,7 @@ func (c *courierRepo) GetListCourier(ctx context.Context, req *models.GetListCou
 
 	var(
 		query	string
-		filter=	" WHERE TRUE"
+		filter=	storage.NewFilter()
 		offset=	" OFFSET 0"
 		limit=	" LIMIT 0"
 	)
@@ -95,12 +115,21 @@ func (c *courierRepo) GetListCourier(ctx context.Context, req *models.GetListCou
 		id,
 		name,
 		phone_number,
+		latitude,
+		longitude,
+		COALESCE(TO_CHAR(location_updated_at, 'YYYY-MM-DD HH24-MI-SS'), ''),
+		is_available,
 		TO_CHAR(created_at, 'YYYY-MM-DD HH24-MI-SS'),
-		TO_CHAR(updated_at, 'YYYY-MM-DD HH24-MI-SS')
+		TO_CHAR(updated_at, 'YYYY-MM-DD HH24-MI-SS'),
+		COALESCE(TO_CHAR(deleted_at, 'YYYY-MM-DD HH24-MI-SS'), '')
 	FROM courier
 	`
-	if len(req.Search) > 0{
-		filter += " AND name ILIKE '%' || '" + req.Search + "' || '%' "
+	filter.
+		Search(req.Search, "name", "phone_number").
+		SoftDeleted(req.Include_deleted)
+
+	if req.Is_available != nil {
+		filter.Where("is_available = ?", *req.Is_available)
 	}  
 
 	if req.Offset > 0{
@@ -111,9 +140,19 @@ func (c *courierRepo) GetListCourier(ctx context.Context, req *models.GetListCou
 		limit = fmt.Sprintf(" LIMIT %d", req.Limit)
 	}
 
-	query += filter + offset + limit
+	orderBy, sort, err := storage.OrderBy(req.Sort, models.CourierSortFields, "name")
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Cursor != nil {
+		orderBy, sort = filter.Keyset(req.Cursor), storage.KeysetOrder
+		offset, limit = " OFFSET 0", fmt.Sprintf(" LIMIT %d", req.Limit+1)
+	}
+
+	query += filter.String() + orderBy + offset + limit
 
-	rows, err := c.db.Query(ctx, query)
+	rows, err := c.db.Query(ctx, query, filter.Args()...)
 	if err != nil{
 		return nil, err
 	}
@@ -129,13 +168,29 @@ func (c *courierRepo) GetListCourier(ctx context.Context, req *models.GetListCou
 			&courier.Id,
 			&courier.Name,
 			&courier.Phone_number,
+			&courier.Latitude,
+			&courier.Longitude,
+			&courier.Location_updated_at,
+			&courier.Is_available,
 			&courier.CreatedAt,
 			&courier.UpdatedAt,
+			&courier.DeletedAt,
 		)
 
 		resp.Couriers = append(resp.Couriers, &courier)
 	}
 
+	if req.Cursor != nil && len(resp.Couriers) > req.Limit {
+		resp.Couriers = resp.Couriers[:req.Limit]
+
+		resp.Next_cursor, err = nextCursor(ctx, c.db, "courier", resp.Couriers[req.Limit-1].Id)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	resp.Sort = sort
+
 	return &resp, nil
 }
 
@@ -147,13 +202,20 @@ func (c *courierRepo) UpdateCourier(ctx context.Context, req *models.UpdateCouri
 		SET
 			name = $1,
 			phone_number = $2,
+			latitude = COALESCE($3, latitude),
+			longitude = COALESCE($4, longitude),
+			location_updated_at = CASE WHEN $3::NUMERIC IS NOT NULL THEN now() ELSE location_updated_at END,
+			is_available = COALESCE($5, is_available),
 			updated_at = now()
-		WHERE id = $3
+		WHERE id = $6 AND deleted_at IS NULL
 	`
 
 	rows, err := c.db.Exec(ctx, query, 
 		req.Name,
 		req.Phone_number,
+		req.Latitude,
+		req.Longitude,
+		req.Is_available,
 		req.Id,
 	)
 	if err != nil{
@@ -163,14 +225,12 @@ func (c *courierRepo) UpdateCourier(ctx context.Context, req *models.UpdateCouri
 	return rows.RowsAffected(), nil
 }
 
+// DeleteCourier soft deletes the courier, who then gets no new orders and
+// can no longer report a location. Orders already assigned keep them.
 func (c *courierRepo) DeleteCourier(ctx context.Context, req *models.CourierPrimaryKey) (error) {
+	return softDelete(ctx, c.db, "courier", req.Id)
+}
 
-	_, err := c.db.Exec(ctx, 
-		"DELETE FROM courier WHERE id = $1", req.Id,
-	)
-	if err != nil{
-		return err
-	}
-
-	return nil
-}
\ No newline at end of file
+func (c *courierRepo) RestoreCourier(ctx context.Context, req *models.CourierPrimaryKey) (error) {
+	return restore(ctx, c.db, "courier", req.Id)
+}
@@ -0,0 +1,186 @@
+package postgresql
+
+import (
+	"app/api/models"
+	"app/pkg/events"
+	"app/pkg/helper"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+)
+
+func (c *courierRepo) CreateCourierLocation(ctx context.Context, req *models.CreateCourierLocation) (int64, error) {
+
+	tx, err := c.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	var id string
+
+	err = tx.QueryRow(ctx, "SELECT id FROM courier WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", req.Courier_id).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	var recordedAt = helper.NewNullString(req.Recorded_at)
+
+	query := `
+		INSERT INTO courier_locations (
+			courier_id,
+			latitude,
+			longitude,
+			recorded_at
+		) VALUES ($1, $2, $3, COALESCE($4::TIMESTAMPTZ, now()))
+	`
+
+	_, err = tx.Exec(ctx, query,
+		req.Courier_id,
+		req.Latitude,
+		req.Longitude,
+		recordedAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	// Points can arrive out of order from a phone that was offline for a
+	// while, so the last known location only moves forward in time.
+	query = `
+		UPDATE
+			courier
+		SET
+			latitude = $1,
+			longitude = $2,
+			location_updated_at = COALESCE($3::TIMESTAMPTZ, now())
+		WHERE id = $4
+			AND (location_updated_at IS NULL OR location_updated_at <= COALESCE($3::TIMESTAMPTZ, now()))
+	`
+
+	_, err = tx.Exec(ctx, query,
+		req.Latitude,
+		req.Longitude,
+		recordedAt,
+		req.Courier_id,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	location := &models.CourierLocation{
+		Courier_id:  req.Courier_id,
+		Latitude:    req.Latitude,
+		Longitude:   req.Longitude,
+		Recorded_at: req.Recorded_at,
+	}
+
+	if len(location.Recorded_at) <= 0 {
+		location.Recorded_at = time.Now().Format(time.RFC3339)
+	}
+
+	c.events.Publish(events.CourierTopic(req.Courier_id), events.CourierLocationUpdated, location)
+
+	return 1, nil
+}
+
+// GetCourierLocation returns the latest reported point of a courier, or nil
+// when the courier has not reported any location yet.
+func (c *courierRepo) GetCourierLocation(ctx context.Context, req *models.CourierPrimaryKey) (*models.CourierLocation, error) {
+
+	var location models.CourierLocation
+
+	query := `
+		SELECT
+			courier_id,
+			latitude,
+			longitude,
+			TO_CHAR(recorded_at, 'YYYY-MM-DD HH24-MI-SS')
+		FROM courier_locations
+		WHERE courier_id = $1
+		ORDER BY recorded_at DESC, id DESC
+		LIMIT 1
+	`
+
+	err := c.db.QueryRow(ctx, query, req.Id).Scan(
+		&location.Courier_id,
+		&location.Latitude,
+		&location.Longitude,
+		&location.Recorded_at,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &location, nil
+}
+
+func (c *courierRepo) GetCourierTrack(ctx context.Context, req *models.GetCourierTrackRequest) (*models.GetCourierTrackResponse, error) {
+
+	var (
+		query string
+		limit = " LIMIT 1000"
+	)
+
+	query = `
+		SELECT
+			courier_id,
+			latitude,
+			longitude,
+			TO_CHAR(recorded_at, 'YYYY-MM-DD HH24-MI-SS')
+		FROM courier_locations
+		WHERE courier_id = $1
+			AND recorded_at >= $2::TIMESTAMPTZ
+			AND recorded_at <= $3::TIMESTAMPTZ
+		ORDER BY recorded_at, id
+	`
+
+	if req.Limit > 0 {
+		limit = fmt.Sprintf(" LIMIT %d", req.Limit)
+	}
+
+	query += limit
+
+	rows, err := c.db.Query(ctx, query, req.Courier_id, req.From, req.To)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	resp := &models.GetCourierTrackResponse{}
+
+	for rows.Next() {
+
+		var location models.CourierLocation
+
+		err = rows.Scan(
+			&location.Courier_id,
+			&location.Latitude,
+			&location.Longitude,
+			&location.Recorded_at,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		resp.Points = append(resp.Points, &location)
+	}
+
+	resp.Count = len(resp.Points)
+
+	return resp, rows.Err()
+}
@@ -2,20 +2,20 @@ package postgresql
 
 import (
 	"app/api/models"
+	"app/storage"
 	"context"
 	"fmt"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v4/pgxpool"
 )
 
 
 type customerRepo struct {
-	db 	*pgxpool.Pool
+	db 	DB
 }
 
 
-func NewCustomerRepo(db *pgxpool.Pool) *customerRepo {
+func NewCustomerRepo(db DB) *customerRepo {
 	return &customerRepo{
 		db: db,
 	}
@@ -61,18 +61,22 @@ func (c *customerRepo) GetByIdCustomer(ctx context.Context, req *models.Customer
 			id,
 			name,
 			phone,
+			COALESCE(TO_CHAR(phone_verified_at, 'YYYY-MM-DD HH24-MI-SS'), ''),
 			TO_CHAR(created_at, 'YYYY-MM-DD HH24-MI-SS'),
-			TO_CHAR(updated_at, 'YYYY-MM-DD HH24-MI-SS')	
+			TO_CHAR(updated_at, 'YYYY-MM-DD HH24-MI-SS'),
+			COALESCE(TO_CHAR(deleted_at, 'YYYY-MM-DD HH24-MI-SS'), '')
 		FROM customers
-		WHERE id = $1
+		WHERE id = $1 AND ($2 OR deleted_at IS NULL)
 	`
 
-	err := c.db.QueryRow(ctx, query, req.Id).Scan(
+	err := c.db.QueryRow(ctx, query, req.Id, req.Include_deleted).Scan(
 		&customer.Id,
 		&customer.Name,
 		&customer.Phone,
+		&customer.Phone_verified_at,
 		&customer.CreatedAt,
 		&customer.UpdatedAt,
+		&customer.DeletedAt,
 	)
 
 	if err != nil{
@@ -87,7 +91,7 @@ func (c *customerRepo) GetListCustomer(ctx context.Context, req *models.GetListC
 
 	var (
 		query		string
-		filter = 	" WHERE TRUE"
+		filter = 	storage.NewFilter()
 		offset =	" OFFSET 0"
 		limit = 	" LIMIT 0"
 	)
@@ -98,14 +102,16 @@ func (c *customerRepo) GetListCustomer(ctx context.Context, req *models.GetListC
 			id,
 			name,
 			phone,
+			COALESCE(TO_CHAR(phone_verified_at, 'YYYY-MM-DD HH24-MI-SS'), ''),
 			TO_CHAR(created_at, 'YYYY-MM-DD HH24-MI-SS'),
-			TO_CHAR(updated_at, 'YYYY-MM-DD HH24-MI-SS')
+			TO_CHAR(updated_at, 'YYYY-MM-DD HH24-MI-SS'),
+			COALESCE(TO_CHAR(deleted_at, 'YYYY-MM-DD HH24-MI-SS'), '')
 		FROM customers
 	`
 
-	if len(req.Search) > 0{
-		filter += " AND name ILIKE '%' || '" + req.Search + "' || '%' "
-	}  
+	filter.
+		Search(req.Search, "name", "phone").
+		SoftDeleted(req.Include_deleted)
 
 	if req.Offset > 0{
 		offset = fmt.Sprintf(" OFFSET %d", req.Offset)
@@ -115,9 +121,19 @@ func (c *customerRepo) GetListCustomer(ctx context.Context, req *models.GetListC
 		limit = fmt.Sprintf(" LIMIT %d", req.Limit)
 	}
 
-	query += filter + offset + limit
+	orderBy, sort, err := storage.OrderBy(req.Sort, models.CustomerSortFields, "name")
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Cursor != nil {
+		orderBy, sort = filter.Keyset(req.Cursor), storage.KeysetOrder
+		offset, limit = " OFFSET 0", fmt.Sprintf(" LIMIT %d", req.Limit+1)
+	}
 
-	rows, err := c.db.Query(ctx, query)
+	query += filter.String() + orderBy + offset + limit
+
+	rows, err := c.db.Query(ctx, query, filter.Args()...)
 	if err != nil{
 		return nil, err
 	}
@@ -133,13 +149,26 @@ func (c *customerRepo) GetListCustomer(ctx context.Context, req *models.GetListC
 			&customer.Id,
 			&customer.Name,
 			&customer.Phone,
+			&customer.Phone_verified_at,
 			&customer.CreatedAt,
 			&customer.UpdatedAt,
+			&customer.DeletedAt,
 		)
 
 		resp.Customers = append(resp.Customers, &customer)
 	}
 
+	if req.Cursor != nil && len(resp.Customers) > req.Limit {
+		resp.Customers = resp.Customers[:req.Limit]
+
+		resp.Next_cursor, err = nextCursor(ctx, c.db, "customers", resp.Customers[req.Limit-1].Id)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	resp.Sort = sort
+
 	return resp, nil
 }
 
@@ -151,8 +180,9 @@ func (c *customerRepo) UpdateCustomer(ctx context.Context, req *models.UpdateCus
 		SET
 			name = $1,
 			phone = $2,
+			phone_verified_at = CASE WHEN phone IS DISTINCT FROM $2 THEN NULL ELSE phone_verified_at END,
 			updated_at = now()
-		WHERE id = $3
+		WHERE id = $3 AND deleted_at IS NULL
 	`	
 
 	rows, err := c.db.Exec(ctx, query, 
@@ -168,14 +198,12 @@ func (c *customerRepo) UpdateCustomer(ctx context.Context, req *models.UpdateCus
 	return rows.RowsAffected(), nil	
 }
 
+// DeleteCustomer soft deletes the customer, keeping their orders, carts and
+// addresses for when they are restored.
 func (c *customerRepo) DeleteCustomer(ctx context.Context, req *models.CustomerPrimaryKey) (error) {
+	return softDelete(ctx, c.db, "customers", req.Id)
+}
 
-	_, err := c.db.Exec(ctx, 
-		"DELETE FROM customers WHERE id = $1", req.Id,
-	)
-
-	if err != nil{
-		return err
-	}
-	return nil
+func (c *customerRepo) RestoreCustomer(ctx context.Context, req *models.CustomerPrimaryKey) (error) {
+	return restore(ctx, c.db, "customers", req.Id)
 }
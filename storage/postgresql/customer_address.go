@@ -0,0 +1,302 @@
+package postgresql
+
+import (
+	"app/api/models"
+	"app/storage"
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+type customerAddressRepo struct {
+	db DB
+}
+
+func NewCustomerAddressRepo(db DB) *customerAddressRepo {
+	return &customerAddressRepo{
+		db: db,
+	}
+}
+
+// CreateCustomerAddress stores a new address. The first address of a customer
+// becomes their default, and a new default replaces the previous one.
+func (c *customerAddressRepo) CreateCustomerAddress(ctx context.Context, req *models.CreateCustomerAddress) (string, error) {
+
+	tx, err := c.db.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback(ctx)
+
+	var (
+		id         = uuid.New().String()
+		hasDefault bool
+	)
+
+	err = tx.QueryRow(ctx,
+		"SELECT EXISTS(SELECT 1 FROM customer_addresses WHERE customer_id = $1 AND is_default)", req.Customer_id,
+	).Scan(&hasDefault)
+	if err != nil {
+		return "", err
+	}
+
+	isDefault := req.Is_default || !hasDefault
+
+	if isDefault {
+		err = clearDefaultAddress(ctx, tx, req.Customer_id)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	query := `
+		INSERT INTO customer_addresses(
+			id,
+			customer_id,
+			label,
+			street,
+			apartment,
+			latitude,
+			longitude,
+			is_default,
+			updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, now())
+	`
+
+	_, err = tx.Exec(ctx, query,
+		id,
+		req.Customer_id,
+		req.Label,
+		req.Street,
+		req.Apartment,
+		req.Latitude,
+		req.Longitude,
+		isDefault,
+	)
+	if err != nil {
+		return "", err
+	}
+
+	return id, tx.Commit(ctx)
+}
+
+const customerAddressColumns = `
+	id,
+	customer_id,
+	label,
+	street,
+	apartment,
+	latitude,
+	longitude,
+	is_default,
+	TO_CHAR(created_at, 'YYYY-MM-DD HH24-MI-SS'),
+	COALESCE(TO_CHAR(updated_at, 'YYYY-MM-DD HH24-MI-SS'), '')
+`
+
+func scanCustomerAddress(row pgx.Row, address *models.CustomerAddress) error {
+	return row.Scan(
+		&address.Id,
+		&address.Customer_id,
+		&address.Label,
+		&address.Street,
+		&address.Apartment,
+		&address.Latitude,
+		&address.Longitude,
+		&address.Is_default,
+		&address.CreatedAt,
+		&address.UpdatedAt,
+	)
+}
+
+func (c *customerAddressRepo) GetByIdCustomerAddress(ctx context.Context, req *models.CustomerAddressPrimaryKey) (*models.CustomerAddress, error) {
+
+	var address models.CustomerAddress
+
+	query := `SELECT ` + customerAddressColumns + ` FROM customer_addresses WHERE id = $1 AND customer_id = $2`
+
+	err := scanCustomerAddress(c.db.QueryRow(ctx, query, req.Id, req.Customer_id), &address)
+	if err != nil {
+		return nil, err
+	}
+
+	return &address, nil
+}
+
+// GetListCustomerAddress returns every address of a customer, default first.
+func (c *customerAddressRepo) GetListCustomerAddress(ctx context.Context, req *models.GetListCustomerAddressRequest) (*models.GetListCustomerAddressResponse, error) {
+
+	resp := &models.GetListCustomerAddressResponse{}
+
+	query := `
+		SELECT ` + customerAddressColumns + `
+		FROM customer_addresses
+		WHERE customer_id = $1
+		ORDER BY is_default DESC, created_at, id
+	`
+
+	rows, err := c.db.Query(ctx, query, req.Customer_id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+
+		var address models.CustomerAddress
+
+		err = scanCustomerAddress(rows, &address)
+		if err != nil {
+			return nil, err
+		}
+
+		resp.Addresses = append(resp.Addresses, &address)
+	}
+
+	resp.Count = len(resp.Addresses)
+
+	return resp, rows.Err()
+}
+
+func (c *customerAddressRepo) UpdateCustomerAddress(ctx context.Context, req *models.UpdateCustomerAddress) (int64, error) {
+
+	tx, err := c.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	if req.Is_default {
+		err = clearDefaultAddress(ctx, tx, req.Customer_id)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	query := `
+		UPDATE
+			customer_addresses
+		SET
+			label = $1,
+			street = $2,
+			apartment = $3,
+			latitude = $4,
+			longitude = $5,
+			is_default = is_default OR $6,
+			updated_at = now()
+		WHERE id = $7 AND customer_id = $8
+	`
+
+	result, err := tx.Exec(ctx, query,
+		req.Label,
+		req.Street,
+		req.Apartment,
+		req.Latitude,
+		req.Longitude,
+		req.Is_default,
+		req.Id,
+		req.Customer_id,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected(), tx.Commit(ctx)
+}
+
+// DeleteCustomerAddress removes an address. If it was the default, the oldest
+// remaining address of the customer becomes the default.
+func (c *customerAddressRepo) DeleteCustomerAddress(ctx context.Context, req *models.CustomerAddressPrimaryKey) error {
+
+	tx, err := c.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx,
+		"DELETE FROM customer_addresses WHERE id = $1 AND customer_id = $2", req.Id, req.Customer_id,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE customer_addresses SET is_default = TRUE, updated_at = now()
+		WHERE id = (
+			SELECT id FROM customer_addresses WHERE customer_id = $1 ORDER BY created_at, id LIMIT 1
+		) AND NOT EXISTS (
+			SELECT 1 FROM customer_addresses WHERE customer_id = $1 AND is_default
+		)
+	`, req.Customer_id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func clearDefaultAddress(ctx context.Context, tx pgx.Tx, customerId string) error {
+
+	_, err := tx.Exec(ctx,
+		"UPDATE customer_addresses SET is_default = FALSE, updated_at = now() WHERE customer_id = $1 AND is_default",
+		customerId,
+	)
+
+	return err
+}
+
+// orderAddress is the snapshot of a customer address stored on an order.
+type orderAddress struct {
+	id        string
+	street    string
+	apartment string
+}
+
+// resolveOrderAddress loads the address an order references and copies its
+// coordinates into latitude and longtitude. When the order has no phone number
+// the customer's phone is used. Orders without an address keep their raw
+// coordinates and get an empty snapshot.
+func resolveOrderAddress(ctx context.Context, tx pgx.Tx, addressId, customerId string, latitude, longtitude *float64, phone *string) (*orderAddress, error) {
+
+	address := &orderAddress{}
+
+	if len(addressId) <= 0 {
+		return address, nil
+	}
+
+	var customerPhone string
+
+	err := tx.QueryRow(ctx, `
+		SELECT
+			a.id,
+			a.street,
+			a.apartment,
+			a.latitude,
+			a.longitude,
+			COALESCE(c.phone, '')
+		FROM customer_addresses AS a
+		JOIN customers AS c ON c.id = a.customer_id
+		WHERE a.id = $1 AND a.customer_id = $2
+	`, addressId, customerId).Scan(
+		&address.id,
+		&address.street,
+		&address.apartment,
+		latitude,
+		longtitude,
+		&customerPhone,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, storage.ErrAddressNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(*phone) <= 0 {
+		*phone = customerPhone
+	}
+
+	return address, nil
+}
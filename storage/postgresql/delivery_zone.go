@@ -0,0 +1,291 @@
+package postgresql
+
+import (
+	"app/api/models"
+	"app/config"
+	"app/pkg/delivery"
+	"app/pkg/geo"
+	"app/storage"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+// queryer is implemented by both *pgxpool.Pool and pgx.Tx.
+type queryer interface {
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+}
+
+type deliveryZoneRepo struct {
+	db       DB
+	delivery *delivery.Calculator
+}
+
+func NewDeliveryZoneRepo(db DB, calculator *delivery.Calculator) *deliveryZoneRepo {
+	return &deliveryZoneRepo{
+		db:       db,
+		delivery: calculator,
+	}
+}
+
+// newDeliveryCalculator prices deliveries from the configured store location.
+func newDeliveryCalculator(cfg *config.Config) *delivery.Calculator {
+	return delivery.NewCalculator(geo.Point{Latitude: cfg.StoreLatitude, Longitude: cfg.StoreLongitude}, cfg.DeliveryTiers)
+}
+
+func (d *deliveryZoneRepo) CreateDeliveryZone(ctx context.Context, req *models.CreateDeliveryZone) (string, error) {
+
+	var (
+		query string
+		id    = uuid.New().String()
+	)
+
+	area, err := json.Marshal(req.Area)
+	if err != nil {
+		return "", err
+	}
+
+	query = `
+		INSERT INTO delivery_zones(
+			id,
+			name,
+			area,
+			delivery_fee,
+			min_order,
+			opens_at,
+			closes_at,
+			is_active,
+			updated_at
+		) VALUES ($1, $2, $3::JSONB, $4, $5, NULLIF($6, '')::TIME, NULLIF($7, '')::TIME, COALESCE($8, TRUE), now())
+	`
+
+	_, err = d.db.Exec(ctx, query,
+		id,
+		req.Name,
+		string(area),
+		req.Delivery_fee,
+		req.Min_order,
+		req.Opens_at,
+		req.Closes_at,
+		req.Is_active,
+	)
+	if err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+const deliveryZoneColumns = `
+	id,
+	name,
+	area::TEXT,
+	delivery_fee,
+	min_order,
+	COALESCE(TO_CHAR(opens_at, 'HH24:MI'), ''),
+	COALESCE(TO_CHAR(closes_at, 'HH24:MI'), ''),
+	is_active,
+	TO_CHAR(created_at, 'YYYY-MM-DD HH24-MI-SS'),
+	COALESCE(TO_CHAR(updated_at, 'YYYY-MM-DD HH24-MI-SS'), '')
+`
+
+func scanDeliveryZone(row pgx.Row, zone *models.DeliveryZone, dest ...interface{}) error {
+
+	var area string
+
+	err := row.Scan(append(dest,
+		&zone.Id,
+		&zone.Name,
+		&area,
+		&zone.Delivery_fee,
+		&zone.Min_order,
+		&zone.Opens_at,
+		&zone.Closes_at,
+		&zone.Is_active,
+		&zone.CreatedAt,
+		&zone.UpdatedAt,
+	)...)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal([]byte(area), &zone.Area)
+}
+
+func (d *deliveryZoneRepo) GetByIdDeliveryZone(ctx context.Context, req *models.DeliveryZonePrimaryKey) (*models.DeliveryZone, error) {
+
+	var zone models.DeliveryZone
+
+	query := `SELECT ` + deliveryZoneColumns + ` FROM delivery_zones WHERE id = $1`
+
+	err := scanDeliveryZone(d.db.QueryRow(ctx, query, req.Id), &zone)
+	if err != nil {
+		return nil, err
+	}
+
+	return &zone, nil
+}
+
+func (d *deliveryZoneRepo) GetListDeliveryZone(ctx context.Context, req *models.GetListDeliveryZoneRequest) (*models.GetListDeliveryZoneResponse, error) {
+
+	var (
+		resp   = &models.GetListDeliveryZoneResponse{}
+		query  string
+		filter = storage.NewFilter()
+		offset = " OFFSET 0"
+		limit  = " LIMIT 10"
+	)
+
+	query = `SELECT COUNT(*) OVER(), ` + deliveryZoneColumns + ` FROM delivery_zones`
+
+	filter.Search(req.Search, "name")
+
+	if req.Is_active != nil {
+		filter.Where("is_active = ?", *req.Is_active)
+	}
+
+	if req.Offset > 0 {
+		offset = fmt.Sprintf(" OFFSET %d", req.Offset)
+	}
+
+	if req.Limit > 0 {
+		limit = fmt.Sprintf(" LIMIT %d", req.Limit)
+	}
+
+	orderBy, sort, err := storage.OrderBy(req.Sort, models.DeliveryZoneSortFields, "name")
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Cursor != nil {
+		orderBy, sort = filter.Keyset(req.Cursor), storage.KeysetOrder
+		offset, limit = " OFFSET 0", fmt.Sprintf(" LIMIT %d", req.Limit+1)
+	}
+
+	query += filter.String() + orderBy + offset + limit
+
+	rows, err := d.db.Query(ctx, query, filter.Args()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+
+		var zone models.DeliveryZone
+
+		err = scanDeliveryZone(rows, &zone, &resp.Count)
+		if err != nil {
+			return nil, err
+		}
+
+		resp.Zones = append(resp.Zones, &zone)
+	}
+
+	if req.Cursor != nil && len(resp.Zones) > req.Limit {
+		resp.Zones = resp.Zones[:req.Limit]
+
+		resp.Next_cursor, err = nextCursor(ctx, d.db, "delivery_zones", resp.Zones[req.Limit-1].Id)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	resp.Sort = sort
+
+	return resp, rows.Err()
+}
+
+func (d *deliveryZoneRepo) UpdateDeliveryZone(ctx context.Context, req *models.UpdateDeliveryZone) (int64, error) {
+
+	area, err := json.Marshal(req.Area)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `
+		UPDATE
+			delivery_zones
+		SET
+			name = $1,
+			area = $2::JSONB,
+			delivery_fee = $3,
+			min_order = $4,
+			opens_at = NULLIF($5, '')::TIME,
+			closes_at = NULLIF($6, '')::TIME,
+			is_active = COALESCE($7, is_active),
+			updated_at = now()
+		WHERE id = $8
+	`
+
+	result, err := d.db.Exec(ctx, query,
+		req.Name,
+		string(area),
+		req.Delivery_fee,
+		req.Min_order,
+		req.Opens_at,
+		req.Closes_at,
+		req.Is_active,
+		req.Id,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected(), nil
+}
+
+func (d *deliveryZoneRepo) DeleteDeliveryZone(ctx context.Context, req *models.DeliveryZonePrimaryKey) error {
+
+	_, err := d.db.Exec(ctx, "DELETE FROM delivery_zones WHERE id = $1", req.Id)
+
+	return err
+}
+
+func (d *deliveryZoneRepo) QuoteDelivery(ctx context.Context, point geo.Point) (*models.DeliveryQuote, error) {
+
+	quote, _, err := quoteDelivery(ctx, d.db, d.delivery, point, time.Now())
+
+	return quote, err
+}
+
+// quoteDelivery prices delivery to point against the active delivery zones,
+// as described by storage.QuoteDelivery.
+func quoteDelivery(ctx context.Context, db queryer, calculator *delivery.Calculator, point geo.Point, now time.Time) (*models.DeliveryQuote, *models.DeliveryZone, error) {
+
+	zones, err := activeDeliveryZones(ctx, db)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return storage.QuoteDelivery(calculator, zones, point, now)
+}
+
+func activeDeliveryZones(ctx context.Context, db queryer) ([]*models.DeliveryZone, error) {
+
+	rows, err := db.Query(ctx, `SELECT `+deliveryZoneColumns+` FROM delivery_zones WHERE is_active ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var zones []*models.DeliveryZone
+
+	for rows.Next() {
+
+		var zone models.DeliveryZone
+
+		err = scanDeliveryZone(rows, &zone)
+		if err != nil {
+			return nil, err
+		}
+
+		zones = append(zones, &zone)
+	}
+
+	return zones, rows.Err()
+}
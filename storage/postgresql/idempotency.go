@@ -0,0 +1,114 @@
+package postgresql
+
+import (
+	"app/api/models"
+	"app/config"
+	"app/storage"
+	"context"
+)
+
+type idempotencyRepo struct {
+	db  DB
+	cfg *config.Config
+}
+
+func NewIdempotencyRepo(db DB, cfg *config.Config) *idempotencyRepo {
+	return &idempotencyRepo{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+// BeginIdempotentRequest reserves req.Key for its owner. It returns nil when
+// the request is new and must be processed, or the stored response when the
+// key was already used for the same request. Expired keys of every owner are
+// purged first.
+func (i *idempotencyRepo) BeginIdempotentRequest(ctx context.Context, req *models.IdempotencyKey) (*models.IdempotentResponse, error) {
+
+	tx, err := i.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= now()`)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := tx.Exec(ctx, `
+		INSERT INTO idempotency_keys(
+			"owner",
+			"key",
+			method,
+			path,
+			request_hash,
+			expires_at
+		) VALUES ($1, $2, $3, $4, $5, now() + make_interval(hours => $6))
+		ON CONFLICT ("owner", "key") DO NOTHING
+	`,
+		req.Owner,
+		req.Key,
+		req.Method,
+		req.Path,
+		req.Request_hash,
+		i.cfg.IdempotencyTTL,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.RowsAffected() > 0 {
+		return nil, tx.Commit(ctx)
+	}
+
+	var (
+		requestHash string
+		statusCode  *int
+		resp        = &models.IdempotentResponse{}
+	)
+
+	err = tx.QueryRow(ctx,
+		`SELECT request_hash, status_code, response FROM idempotency_keys WHERE "owner" = $1 AND "key" = $2`,
+		req.Owner, req.Key,
+	).Scan(&requestHash, &statusCode, &resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if requestHash != req.Request_hash {
+		return nil, storage.ErrIdempotencyKeyReused
+	}
+
+	if statusCode == nil {
+		return nil, storage.ErrIdempotencyKeyInProgress
+	}
+
+	resp.Status_code = *statusCode
+
+	return resp, nil
+}
+
+// FinishIdempotentRequest stores the response of a reserved request so that
+// retries replay it.
+func (i *idempotencyRepo) FinishIdempotentRequest(ctx context.Context, req *models.IdempotencyKey, resp *models.IdempotentResponse) error {
+
+	_, err := i.db.Exec(ctx,
+		`UPDATE idempotency_keys SET status_code = $1, response = $2 WHERE "owner" = $3 AND "key" = $4`,
+		resp.Status_code, resp.Body, req.Owner, req.Key,
+	)
+
+	return err
+}
+
+// ReleaseIdempotentRequest drops the reservation of a request that failed, so
+// it can be retried with the same key.
+func (i *idempotencyRepo) ReleaseIdempotentRequest(ctx context.Context, req *models.IdempotencyKey) error {
+
+	_, err := i.db.Exec(ctx,
+		`DELETE FROM idempotency_keys WHERE "owner" = $1 AND "key" = $2 AND status_code IS NULL`,
+		req.Owner, req.Key,
+	)
+
+	return err
+}
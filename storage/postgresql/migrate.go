@@ -0,0 +1,380 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// migrationLockId is the advisory lock held while migrating, so two
+// instances starting together do not apply the same migration twice.
+const migrationLockId = 4_240_562_001
+
+// Migration is a version of the schema with the SQL that applies and reverts
+// it.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus is a migration and when it was applied, zero while it is
+// pending.
+type MigrationStatus struct {
+	Migration
+	AppliedAt time.Time
+}
+
+// Migrator applies migrations and records the applied versions in the
+// migration_versions table. Each migration runs in its own transaction with
+// its version record.
+type Migrator struct {
+	pool       *pgxpool.Pool
+	migrations []Migration
+}
+
+// NewMigrator loads the migrations in the root of fsys, named
+// <version>_<name>.up.sql and <version>_<name>.down.sql. Every version needs
+// both files.
+func NewMigrator(pool *pgxpool.Pool, fsys fs.FS) (*Migrator, error) {
+
+	files, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+
+	for _, file := range files {
+
+		var (
+			base = path.Base(file)
+			up   = strings.HasSuffix(base, ".up.sql")
+		)
+
+		if !up && !strings.HasSuffix(base, ".down.sql") {
+			return nil, fmt.Errorf("migration %s: expected .up.sql or .down.sql", base)
+		}
+
+		prefix, name, ok := strings.Cut(base, "_")
+		version, err := strconv.Atoi(prefix)
+		if !ok || err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s: expected a positive version prefix", base)
+		}
+
+		body, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version}
+			byVersion[version] = m
+		}
+
+		if up {
+			if len(m.Up) > 0 {
+				return nil, fmt.Errorf("migration %d: more than one up file", version)
+			}
+			m.Name = strings.TrimSuffix(name, ".up.sql")
+			m.Up = string(body)
+		} else {
+			if len(m.Down) > 0 {
+				return nil, fmt.Errorf("migration %d: more than one down file", version)
+			}
+			m.Down = string(body)
+		}
+	}
+
+	migrator := &Migrator{pool: pool}
+
+	for _, m := range byVersion {
+		if len(strings.TrimSpace(m.Up)) <= 0 || len(strings.TrimSpace(m.Down)) <= 0 {
+			return nil, fmt.Errorf("migration %d: needs non-empty up and down files", m.Version)
+		}
+		migrator.migrations = append(migrator.migrations, *m)
+	}
+
+	sort.Slice(migrator.migrations, func(i, j int) bool {
+		return migrator.migrations[i].Version < migrator.migrations[j].Version
+	})
+
+	return migrator, nil
+}
+
+// Latest is the version of the newest migration, 0 when there are none.
+func (m *Migrator) Latest() int {
+
+	if len(m.migrations) <= 0 {
+		return 0
+	}
+
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Status lists every migration, oldest first, with when it was applied.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+
+	var statuses []MigrationStatus
+
+	err := m.locked(ctx, func(conn *pgxpool.Conn, applied map[int]time.Time) error {
+
+		for _, migration := range m.migrations {
+			statuses = append(statuses, MigrationStatus{
+				Migration: migration,
+				AppliedAt: applied[migration.Version],
+			})
+		}
+
+		return nil
+	})
+
+	return statuses, err
+}
+
+// Up applies every pending migration and returns the applied ones.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	return m.Goto(ctx, m.Latest())
+}
+
+// Down reverts the newest applied migration and returns it, nothing when no
+// migration is applied.
+func (m *Migrator) Down(ctx context.Context) ([]Migration, error) {
+
+	var done []Migration
+
+	err := m.locked(ctx, func(conn *pgxpool.Conn, applied map[int]time.Time) error {
+
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			if _, ok := applied[m.migrations[i].Version]; ok {
+				err := m.run(ctx, conn, m.migrations[i], false)
+				if err != nil {
+					return err
+				}
+				done = append(done, m.migrations[i])
+				return nil
+			}
+		}
+
+		return nil
+	})
+
+	return done, err
+}
+
+// Goto applies the pending migrations up to version and reverts the applied
+// ones above it, newest first, returning them in the order they ran. Version 0
+// reverts every migration.
+func (m *Migrator) Goto(ctx context.Context, version int) ([]Migration, error) {
+
+	if version < 0 || (version > 0 && !m.known(version)) {
+		return nil, fmt.Errorf("unknown migration version %d", version)
+	}
+
+	var done []Migration
+
+	err := m.locked(ctx, func(conn *pgxpool.Conn, applied map[int]time.Time) error {
+
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			migration := m.migrations[i]
+			if _, ok := applied[migration.Version]; ok && migration.Version > version {
+				err := m.run(ctx, conn, migration, false)
+				if err != nil {
+					return err
+				}
+				done = append(done, migration)
+			}
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; !ok && migration.Version <= version {
+				err := m.run(ctx, conn, migration, true)
+				if err != nil {
+					return err
+				}
+				done = append(done, migration)
+			}
+		}
+
+		return nil
+	})
+
+	return done, err
+}
+
+func (m *Migrator) known(version int) bool {
+
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return true
+		}
+	}
+
+	return false
+}
+
+// run applies or reverts one migration together with its version record.
+func (m *Migrator) run(ctx context.Context, conn *pgxpool.Conn, migration Migration, up bool) error {
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	script := migration.Down
+	if up {
+		script = migration.Up
+	}
+
+	// Without arguments the script runs over the simple protocol, which
+	// allows several statements.
+	_, err = tx.Exec(ctx, script)
+	if err != nil {
+		return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+
+	if up {
+		_, err = tx.Exec(ctx, `INSERT INTO "migration_versions" ("version", "name") VALUES ($1, $2)`, migration.Version, migration.Name)
+	} else {
+		_, err = tx.Exec(ctx, `DELETE FROM "migration_versions" WHERE "version" = $1`, migration.Version)
+	}
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// locked runs fn on one connection holding the migration lock, with the
+// versions applied so far.
+func (m *Migrator) locked(ctx context.Context, fn func(conn *pgxpool.Conn, applied map[int]time.Time) error) error {
+
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	_, err = conn.Exec(ctx, "SELECT pg_advisory_lock($1)", migrationLockId)
+	if err != nil {
+		return err
+	}
+	defer conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockId)
+
+	_, err = conn.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS "migration_versions" (
+			"version" INT PRIMARY KEY,
+			"name" VARCHAR NOT NULL,
+			"applied_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	if len(applied) <= 0 {
+		applied, err = m.adoptLegacyVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+	}
+
+	return fn(conn, applied)
+}
+
+func (m *Migrator) applied(ctx context.Context, conn *pgxpool.Conn) (map[int]time.Time, error) {
+
+	rows, err := conn.Query(ctx, `SELECT "version", "applied_at" FROM "migration_versions"`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+
+	for rows.Next() {
+
+		var (
+			version   int
+			appliedAt time.Time
+		)
+
+		err = rows.Scan(&version, &appliedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		applied[version] = appliedAt
+	}
+
+	return applied, rows.Err()
+}
+
+// adoptLegacyVersion records as applied the migrations up to the version in
+// the schema_migrations table of the migrate tool used before, so existing
+// databases carry on from where it stopped.
+func (m *Migrator) adoptLegacyVersion(ctx context.Context, conn *pgxpool.Conn) (map[int]time.Time, error) {
+
+	applied := make(map[int]time.Time)
+
+	var exists bool
+
+	err := conn.QueryRow(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists)
+	if err != nil || !exists {
+		return applied, err
+	}
+
+	var (
+		version int
+		dirty   bool
+	)
+
+	err = conn.QueryRow(ctx, `SELECT "version", "dirty" FROM "schema_migrations" LIMIT 1`).Scan(&version, &dirty)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return applied, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if dirty {
+		return nil, fmt.Errorf("schema_migrations is dirty at version %d, fix the schema by hand first", version)
+	}
+
+	for _, migration := range m.migrations {
+
+		if migration.Version > version {
+			break
+		}
+
+		var appliedAt time.Time
+
+		err = conn.QueryRow(ctx,
+			`INSERT INTO "migration_versions" ("version", "name") VALUES ($1, $2) RETURNING "applied_at"`,
+			migration.Version, migration.Name,
+		).Scan(&appliedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		applied[migration.Version] = appliedAt
+	}
+
+	return applied, nil
+}
@@ -0,0 +1,75 @@
+package postgresql
+
+import (
+	"app/migrations"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestEmbeddedMigrations(t *testing.T) {
+
+	migrator, err := NewMigrator(nil, migrations.Postgres())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(migrator.migrations) <= 0 {
+		t.Fatal("no migrations embedded")
+	}
+
+	for i, migration := range migrator.migrations {
+
+		if migration.Version != i+1 {
+			t.Fatalf("migration %s has version %d, want %d", migration.Name, migration.Version, i+1)
+		}
+
+		for _, script := range []string{migration.Up, migration.Down} {
+			if !strings.HasSuffix(strings.TrimSpace(script), ";") {
+				t.Errorf("migration %02d_%s has a statement without a closing semicolon", migration.Version, migration.Name)
+			}
+		}
+	}
+
+	// Products reference categories, which must already exist.
+	categories, products := -1, -1
+
+	for _, migration := range migrator.migrations {
+		switch {
+		case strings.Contains(migration.Up, "CREATE TABLE categories"):
+			categories = migration.Version
+		case strings.Contains(migration.Up, "CREATE TABLE products"):
+			products = migration.Version
+		}
+	}
+
+	if categories <= 0 || categories >= products {
+		t.Fatalf("categories created by migration %d, products by %d", categories, products)
+	}
+}
+
+func TestNewMigratorRejectsIncompleteMigrations(t *testing.T) {
+
+	for name, files := range map[string]fstest.MapFS{
+		"missing down": {
+			"01_create_a.up.sql": {Data: []byte("CREATE TABLE a ();")},
+		},
+		"empty down": {
+			"01_create_a.up.sql": {Data: []byte("CREATE TABLE a ();")},
+			"01_drop_a.down.sql": {Data: []byte("\n")},
+		},
+		"no version": {
+			"create_a.up.sql":   {Data: []byte("CREATE TABLE a ();")},
+			"create_a.down.sql": {Data: []byte("DROP TABLE a;")},
+		},
+		"two ups": {
+			"01_create_a.up.sql": {Data: []byte("CREATE TABLE a ();")},
+			"01_create_b.up.sql": {Data: []byte("CREATE TABLE b ();")},
+			"01_drop_a.down.sql": {Data: []byte("DROP TABLE a;")},
+		},
+	} {
+		if _, err := NewMigrator(nil, files); err == nil {
+			t.Errorf("%s: NewMigrator returned no error", name)
+		}
+	}
+}
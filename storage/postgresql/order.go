@@ -2,68 +2,308 @@ package postgresql
 
 import (
 	"app/api/models"
+	"app/config"
+	"app/pkg/delivery"
+	"app/pkg/events"
+	"app/pkg/geo"
 	"app/pkg/helper"
+	"app/storage"
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/jackc/pgx/v4"
 )
 
 type orderRepo struct{
-	db	*pgxpool.Pool
+	db			DB
+	cfg			*config.Config
+	events		events.Publisher
+	delivery	*delivery.Calculator
 }
 
-func NewOrderRepo(db *pgxpool.Pool) *orderRepo {
+func NewOrderRepo(db DB, cfg *config.Config, publisher events.Publisher) *orderRepo {
 	return &orderRepo{
 		db: db,
+		cfg: cfg,
+		events: publisher,
+		delivery: newDeliveryCalculator(cfg),
 	}
 }
 
 func (o *orderRepo) CreateOrder(ctx context.Context, req *models.CreateOrder) (string, error) {
 
+	tx, err := o.db.Begin(ctx)
+	if err != nil{
+		return "", err
+	}
+	defer tx.Rollback(ctx)
+
+	id, assignment, err := o.createOrder(ctx, tx, req)
+	if err != nil{
+		return "", err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil{
+		return "", err
+	}
+
+	o.events.Publish(events.OrderTopic(id), events.OrderCourierAssigned, assignment)
+
+	return id, nil
+}
+
+// createOrder inserts an order with its items inside tx: it assigns a courier,
+// reserves stock, prices the items and delivery and charges the payer. The courier
+// assignment is returned so the caller can publish it once tx is committed.
+func (o *orderRepo) createOrder(ctx context.Context, tx pgx.Tx, req *models.CreateOrder) (string, *models.OrderCourierEvent, error) {
+
+	if len(req.Items) <= 0 {
+		return "", nil, errors.New("order must contain at least one item")
+	}
+
 	var (
 		query	string
 		id	= 	uuid.New().String()
 	)
 
+	address, err := resolveOrderAddress(ctx, tx, req.Address_id, req.Customer_id, &req.Latitude, &req.Longtitude, &req.Phone_number)
+	if err != nil{
+		return "", nil, err
+	}
+
 	query = `
 		INSERT INTO orders(
 			id,
 			name,
 			price,
 			phone_number,
+			address_id,
+			address_street,
+			address_apartment,
 			latitude,
 			longtitude,
 			user_id,
 			customer_id,
-			courier_id,
-			product_id,
-			quantity,
 			updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, now())
+		) VALUES ($1, $2, 0, $3, $4, $5, $6, $7, $8, $9, $10, now())
 	`
 
-	_, err := o.db.Exec(ctx, query, 
+	_, err = tx.Exec(ctx, query, 
 		id,
 		req.Name,
-		req.Price,
 		req.Phone_number,
+		helper.NewNullString(address.id),
+		helper.NewNullString(address.street),
+		helper.NewNullString(address.apartment),
 		req.Latitude,
 		req.Longtitude,
 		req.User_id,
 		req.Customer_id,
-		req.Courier_id,
-		req.Product_id,
-		req.Quantity,
 	)
 
 	if err != nil{
-		return "", err
+		return "", nil, err
 	}
 
-	return id, nil
+	point := geo.Point{Latitude: req.Latitude, Longitude: req.Longtitude}
+
+	var assignment *models.OrderCourierEvent
+
+	if len(req.Courier_id) > 0 {
+		assignment, err = o.manualAssignCourier(ctx, tx, id, req.Courier_id, point)
+	} else {
+		assignment, err = o.autoAssignCourier(ctx, tx, id, point)
+	}
+	if err != nil{
+		return "", nil, err
+	}
+
+	err = o.insertOrderItems(ctx, tx, id, req.Items)
+	if err != nil{
+		return "", nil, err
+	}
+
+	err = o.applyDeliveryFee(ctx, tx, id)
+	if err != nil{
+		return "", nil, err
+	}
+
+	err = settleOrderPayment(ctx, tx, id)
+	if err != nil{
+		return "", nil, err
+	}
+
+	err = o.insertStatusHistory(ctx, tx, &models.OrderStatusHistory{
+		Order_id:	id,
+		To_status:	models.OrderStatusNew,
+		Changed_by:	req.User_id,
+		Api_key_id:	req.Api_key_id,
+		Reason:		"order created",
+	})
+	if err != nil{
+		return "", nil, err
+	}
+
+	return id, assignment, nil
+}
+
+// insertOrderItems stores the line items of an order, reserves their stock and
+// sets the order price to the sum of their totals plus the delivery fee. Unit prices are read from
+// products at this point and snapshotted on the items, so later price changes
+// do not rewrite the order. It must run inside the order's transaction.
+func (o *orderRepo) insertOrderItems(ctx context.Context, tx pgx.Tx, orderId string, items []*models.CreateOrderItem) error {
+
+	var query string
+
+	prices, err := reserveStock(ctx, tx, items)
+	if err != nil{
+		return err
+	}
+
+	query = `
+		INSERT INTO order_items(
+			id,
+			order_id,
+			product_id,
+			quantity,
+			price,
+			total_price
+		) VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	for _, item := range items {
+
+		unitPrice := prices[item.Product_id]
+		total := unitPrice * float64(item.Quantity)
+
+		_, err := tx.Exec(ctx, query,
+			uuid.New().String(),
+			orderId,
+			item.Product_id,
+			item.Quantity,
+			unitPrice,
+			total,
+		)
+		if err != nil{
+			return err
+		}
+	}
+
+	return refreshOrderPrice(ctx, tx, orderId)
+}
+
+// applyDeliveryFee quotes delivery to the order's coordinates and stores the
+// fee, distance and delivery zone as a separate line of the order price. The
+// items of the order must already be stored so the minimum order of the zone
+// can be checked. It must run inside the order's transaction.
+func (o *orderRepo) applyDeliveryFee(ctx context.Context, tx pgx.Tx, orderId string) error {
+
+	var (
+		point		geo.Point
+		subtotal	float64
+	)
+
+	err := tx.QueryRow(ctx, `
+		SELECT
+			latitude,
+			longtitude,
+			COALESCE((SELECT SUM(total_price) FROM order_items WHERE order_id = $1), 0)
+		FROM orders
+		WHERE id = $1
+	`, orderId).Scan(&point.Latitude, &point.Longitude, &subtotal)
+	if err != nil{
+		return err
+	}
+
+	quote, zone, err := quoteDelivery(ctx, tx, o.delivery, point, time.Now())
+	if err != nil{
+		return err
+	}
+
+	if zone != nil && subtotal < zone.Min_order {
+		return &storage.BelowMinimumOrderError{Zone: zone.Name, MinOrder: zone.Min_order, Subtotal: subtotal}
+	}
+
+	_, err = tx.Exec(ctx,
+		"UPDATE orders SET delivery_fee = $1, delivery_distance = $2, delivery_zone_id = $3 WHERE id = $4",
+		quote.Fee, quote.Distance, helper.NewNullString(quote.Zone_id), orderId,
+	)
+	if err != nil{
+		return err
+	}
+
+	return refreshOrderPrice(ctx, tx, orderId)
+}
+
+// refreshOrderPrice sets the order price to the total of its items plus its
+// delivery fee.
+func refreshOrderPrice(ctx context.Context, tx pgx.Tx, orderId string) error {
+
+	_, err := tx.Exec(ctx, `
+		UPDATE orders SET
+			price = delivery_fee + COALESCE((SELECT SUM(total_price) FROM order_items WHERE order_id = $1), 0)
+		WHERE id = $1
+	`, orderId)
+
+	return err
+}
+
+// getOrderItems loads the line items of the given orders grouped by order id.
+func (o *orderRepo) getOrderItems(ctx context.Context, orderIds []string) (map[string][]*models.OrderItem, error) {
+
+	var (
+		query	string
+		items	= make(map[string][]*models.OrderItem)
+	)
+
+	if len(orderIds) <= 0 {
+		return items, nil
+	}
+
+	query = `
+		SELECT
+			id,
+			order_id,
+			product_id,
+			quantity,
+			price,
+			total_price
+		FROM
+			order_items
+		WHERE order_id = ANY($1::UUID[])
+		ORDER BY created_at, id
+	`
+
+	rows, err := o.db.Query(ctx, query, orderIds)
+	if err != nil{
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+
+		var item models.OrderItem
+
+		err = rows.Scan(
+			&item.Id,
+			&item.Order_id,
+			&item.Product_id,
+			&item.Quantity,
+			&item.Price,
+			&item.Total_price,
+		)
+		if err != nil{
+			return nil, err
+		}
+
+		items[item.Order_id] = append(items[item.Order_id], &item)
+	}
+
+	return items, rows.Err()
 }
 
 func (o *orderRepo) GetByIdOrder(ctx context.Context, req *models.OrderPrimaryKey) (*models.Order, error) {
@@ -78,14 +318,22 @@ func (o *orderRepo) GetByIdOrder(ctx context.Context, req *models.OrderPrimaryKe
 			id,
 			name,
 			COALESCE(price, 0),
+			delivery_fee,
+			delivery_distance,
+			COALESCE(delivery_zone_id::VARCHAR, ''),
 			phone_number,
+			COALESCE(address_id::VARCHAR, ''),
+			COALESCE(address_street, ''),
+			COALESCE(address_apartment, ''),
 			latitude,
 			longtitude,
 			user_id,
 			customer_id,
-			courier_id,
-			product_id,
-			COALESCE(quantity, 0),
+			COALESCE(courier_id::VARCHAR, ''),
+			courier_distance,
+			COALESCE(assignment_mode, ''),
+			COALESCE(TO_CHAR(assigned_at, 'YYYY-MM-DD HH24-MI-SS'), ''),
+			status,
 			TO_CHAR(created_at, 'YYYY-MM-DD HH24-MI-SS'),
 			TO_CHAR(updated_at, 'YYYY-MM-DD HH24-MI-SS')
 		FROM
@@ -97,14 +345,22 @@ func (o *orderRepo) GetByIdOrder(ctx context.Context, req *models.OrderPrimaryKe
 		&order.Id,
 		&order.Name,
 		&order.Price,
+		&order.Delivery_fee,
+		&order.Delivery_distance,
+		&order.Delivery_zone_id,
 		&order.Phone_number,
+		&order.Address_id,
+		&order.Address_street,
+		&order.Address_apartment,
 		&order.Latitude,
 		&order.Longtitude,
 		&order.User_id,
 		&order.Customer_id,
 		&order.Courier_id,
-		&order.Product_id,
-		&order.Quantity,
+		&order.Courier_distance,
+		&order.Assignment_mode,
+		&order.Assigned_at,
+		&order.Status,
 		&order.CreatedAt,
 		&order.UpdatedAt,
 	)
@@ -113,6 +369,13 @@ func (o *orderRepo) GetByIdOrder(ctx context.Context, req *models.OrderPrimaryKe
 		return nil, err
 	}
 
+	items, err := o.getOrderItems(ctx, []string{order.Id})
+	if err != nil{
+		return nil, err
+	}
+
+	order.Items = items[order.Id]
+
 	return &order, nil
 }
 
@@ -123,7 +386,7 @@ func (o *orderRepo) GetListOrders(ctx context.Context, req *models.GetListOrderR
 	
 	var (
 		query  string
-		filter = " WHERE TRUE "
+		filter = storage.NewFilter()
 		offset = " OFFSET 0"
 		limit  = " LIMIT 10"
 	)
@@ -134,21 +397,41 @@ func (o *orderRepo) GetListOrders(ctx context.Context, req *models.GetListOrderR
 			id,
 			name,
 			COALESCE(price, 0),
+			delivery_fee,
+			delivery_distance,
+			COALESCE(delivery_zone_id::VARCHAR, ''),
 			phone_number,
+			COALESCE(address_id::VARCHAR, ''),
+			COALESCE(address_street, ''),
+			COALESCE(address_apartment, ''),
 			latitude,
 			longtitude,
 			user_id,
 			customer_id,
-			courier_id,
-			product_id,
-			COALESCE(quantity, 0),
+			COALESCE(courier_id::VARCHAR, ''),
+			courier_distance,
+			COALESCE(assignment_mode, ''),
+			COALESCE(TO_CHAR(assigned_at, 'YYYY-MM-DD HH24-MI-SS'), ''),
+			status,
 			TO_CHAR(created_at, 'YYYY-MM-DD HH24-MI-SS'),
 			TO_CHAR(updated_at, 'YYYY-MM-DD HH24-MI-SS')
 		FROM orders
 	`
 
-	if len(req.Search) > 0 {
-		filter += " AND name ILIKE '%' || '" + req.Search + "' || '%' "
+	filter.
+		Search(req.Search, "name", "phone_number").
+		Equal("customer_id", req.Customer_id).
+		Equal("courier_id", req.Courier_id).
+		Equal("status", req.Status).
+		Range("price", req.Min_price, req.Max_price)
+
+	// Dates are whole days: created_to includes every order of that day.
+	if len(req.Created_from) > 0 {
+		filter.Where("created_at >= ?::DATE", req.Created_from)
+	}
+
+	if len(req.Created_to) > 0 {
+		filter.Where("created_at < ?::DATE + 1", req.Created_to)
 	}
 
 	if req.Offset > 0 {
@@ -159,15 +442,26 @@ func (o *orderRepo) GetListOrders(ctx context.Context, req *models.GetListOrderR
 		limit = fmt.Sprintf(" LIMIT %d", req.Limit)
 	}
 
-	query += filter + offset + limit
+	orderBy, sort, err := storage.OrderBy(req.Sort, models.OrderSortFields, "-created_at")
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Cursor != nil {
+		orderBy, sort = filter.Keyset(req.Cursor), storage.KeysetOrder
+		offset, limit = " OFFSET 0", fmt.Sprintf(" LIMIT %d", req.Limit+1)
+	}
+
+	query += filter.String() + orderBy + offset + limit
 
 	
-	rows, err := o.db.Query(ctx, query)
+	rows, err := o.db.Query(ctx, query, filter.Args()...)
 	if err != nil{
 		return nil, err
 	}
 	defer rows.Close()
 
+	var ids []string
 
 	for rows.Next() {
 
@@ -178,14 +472,22 @@ func (o *orderRepo) GetListOrders(ctx context.Context, req *models.GetListOrderR
 			&order.Id,
 			&order.Name,
 			&order.Price,
+			&order.Delivery_fee,
+			&order.Delivery_distance,
+			&order.Delivery_zone_id,
 			&order.Phone_number,
+			&order.Address_id,
+			&order.Address_street,
+			&order.Address_apartment,
 			&order.Latitude,
 			&order.Longtitude,
 			&order.User_id,
+			&order.Customer_id,
 			&order.Courier_id,
-			&order.Courier_id,
-			&order.Product_id,
-			&order.Quantity,
+			&order.Courier_distance,
+			&order.Assignment_mode,
+			&order.Assigned_at,
+			&order.Status,
 			&order.CreatedAt,
 			&order.UpdatedAt,
 		)
@@ -195,83 +497,371 @@ func (o *orderRepo) GetListOrders(ctx context.Context, req *models.GetListOrderR
 		}
 
 		resp.Orders = append(resp.Orders, &order)
+		ids = append(ids, order.Id)
+	}
+	rows.Close()
+
+	if req.Cursor != nil && len(resp.Orders) > req.Limit {
+		resp.Orders = resp.Orders[:req.Limit]
+
+		resp.Next_cursor, err = nextCursor(ctx, o.db, "orders", resp.Orders[req.Limit-1].Id)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ids = ids[:len(resp.Orders)]
+
+	items, err := o.getOrderItems(ctx, ids)
+	if err != nil{
+		return nil, err
+	}
+
+	for _, order := range resp.Orders {
+		order.Items = items[order.Id]
 	}
 
 	resp.Count = len(resp.Orders)
 
+	resp.Sort = sort
+
 	return resp, nil
 }
 
 
 func (o *orderRepo) UpdateOrder(ctx context.Context, req *models.UpdateOrder) (int64, error) {
 
+	tx, err := o.db.Begin(ctx)
+	if err != nil{
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	var (
+		status		string
+		courierId	string
+		location	geo.Point
+	)
+
+	err = tx.QueryRow(ctx,
+		"SELECT status, COALESCE(courier_id::VARCHAR, ''), latitude, longtitude FROM orders WHERE id = $1 FOR UPDATE", req.Id,
+	).Scan(&status, &courierId, &location.Latitude, &location.Longitude)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil{
+		return 0, err
+	}
+
+	address, err := resolveOrderAddress(ctx, tx, req.Address_id, req.Customer_id, &req.Latitude, &req.Longtitude, &req.Phone_number)
+	if err != nil{
+		return 0, err
+	}
+
+	if len(req.Courier_id) > 0 && req.Courier_id != courierId{
+		_, err = lockAssignableCourier(ctx, tx, req.Courier_id)
+		if err != nil{
+			return 0, err
+		}
+	}
+
 	query := `
 		UPDATE
 			orders
 		SET
 			name = $1,
-			price = $2,
-			phone_number = $3,
-			latitude = $4,
-			longtitude = $5,
-			user_id = $6,
-			customer_id = $7,
-			courier_id = $8,
-			product_id = $9,
-			quantity = $10,
+			phone_number = $2,
+			latitude = $3,
+			longtitude = $4,
+			user_id = $5,
+			customer_id = $6,
+			courier_id = $7,
+			courier_distance = CASE WHEN courier_id IS DISTINCT FROM $7 THEN NULL ELSE courier_distance END,
+			assignment_mode = CASE WHEN courier_id IS DISTINCT FROM $7 THEN $9 ELSE assignment_mode END,
+			assigned_at = CASE WHEN courier_id IS DISTINCT FROM $7 THEN now() ELSE assigned_at END,
+			address_id = $10,
+			address_street = $11,
+			address_apartment = $12,
 			updated_at = now()
-		WHERE id = $11
+		WHERE id = $8
 	`
 
-	rows, err := o.db.Exec(ctx, query, 
+	rows, err := tx.Exec(ctx, query, 
 		req.Name,
-		req.Price,
 		req.Phone_number,
 		req.Latitude,
 		req.Longtitude,
 		req.User_id,
 		req.Customer_id,
-		req.Courier_id,
-		req.Product_id,
-		req.Quantity,
+		helper.NewNullString(req.Courier_id),
 		req.Id,
+		models.AssignmentModeManual,
+		helper.NewNullString(address.id),
+		helper.NewNullString(address.street),
+		helper.NewNullString(address.apartment),
 	)
 
 	if err != nil{
 		return 0, err
 	}
 
+	if len(req.Items) > 0 {
+
+		if !models.OrderHoldsReservation(status) || status == models.OrderStatusPickedUp {
+			return 0, storage.ErrOrderItemsLocked
+		}
+
+		err = releaseStock(ctx, tx, req.Id)
+		if err != nil{
+			return 0, err
+		}
+
+		_, err = tx.Exec(ctx, "DELETE FROM order_items WHERE order_id = $1", req.Id)
+		if err != nil{
+			return 0, err
+		}
+
+		err = o.insertOrderItems(ctx, tx, req.Id, req.Items)
+		if err != nil{
+			return 0, err
+		}
+	}
+
+	if len(req.Items) > 0 || location.Latitude != req.Latitude || location.Longitude != req.Longtitude {
+		err = o.applyDeliveryFee(ctx, tx, req.Id)
+		if err != nil{
+			return 0, err
+		}
+	}
+
+	err = settleOrderPayment(ctx, tx, req.Id)
+	if err != nil{
+		return 0, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil{
+		return 0, err
+	}
+
+	if courierId != req.Courier_id {
+		o.events.Publish(events.OrderTopic(req.Id), events.OrderCourierAssigned, &models.OrderCourierEvent{
+			Order_id:			req.Id,
+			Courier_id:			req.Courier_id,
+			Assignment_mode:	models.AssignmentModeManual,
+		})
+	}
+
 	return rows.RowsAffected(), nil
 }
 
-func (o *orderRepo) PatchOrder(ctx context.Context, req *models.PatchRequest) (int64, error) {
+func (o *orderRepo) UpdateOrderStatus(ctx context.Context, req *models.UpdateOrderStatus) (int64, error) {
 
-	var (
-		query	string
-		set		string
+	tx, err := o.db.Begin(ctx)
+	if err != nil{
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	var current string
+
+	err = tx.QueryRow(ctx, "SELECT status FROM orders WHERE id = $1 FOR UPDATE", req.Id).Scan(&current)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil{
+		return 0, err
+	}
+
+	if !models.CanTransitionOrderStatus(current, req.Status) {
+		return 0, &storage.StatusTransitionError{From: current, To: req.Status}
+	}
+
+	err = applyStatusStock(ctx, tx, req.Id, current, req.Status)
+	if err != nil{
+		return 0, err
+	}
+
+	rows, err := tx.Exec(ctx, 
+		"UPDATE orders SET status = $1, updated_at = now() WHERE id = $2", req.Status, req.Id,
 	)
+	if err != nil{
+		return 0, err
+	}
+
+	err = settleOrderPayment(ctx, tx, req.Id)
+	if err != nil{
+		return 0, err
+	}
+
+	history := &models.OrderStatusHistory{
+		Order_id:		req.Id,
+		From_status:	current,
+		To_status:		req.Status,
+		Changed_by:		req.Changed_by,
+		Api_key_id:		req.Api_key_id,
+		Reason:			req.Reason,
+	}
+
+	err = o.insertStatusHistory(ctx, tx, history)
+	if err != nil{
+		return 0, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil{
+		return 0, err
+	}
+
+	o.events.Publish(events.OrderTopic(req.Id), events.OrderStatusChanged, history)
+
+	return rows.RowsAffected(), nil
+}
+
+// insertStatusHistory records a status change of an order inside the given transaction.
+func (o *orderRepo) insertStatusHistory(ctx context.Context, tx pgx.Tx, req *models.OrderStatusHistory) error {
+
+	query := `
+		INSERT INTO order_status_history(
+			id,
+			order_id,
+			from_status,
+			to_status,
+			changed_by,
+			api_key_id,
+			reason
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	_, err := tx.Exec(ctx, query,
+		uuid.New().String(),
+		req.Order_id,
+		helper.NewNullString(req.From_status),
+		req.To_status,
+		helper.NewNullString(req.Changed_by),
+		helper.NewNullString(req.Api_key_id),
+		helper.NewNullString(req.Reason),
+	)
+
+	return err
+}
+
+func (o *orderRepo) GetOrderStatusHistory(ctx context.Context, req *models.OrderPrimaryKey) (*models.GetOrderStatusHistoryResponse, error) {
+
+	query := `
+		SELECT
+			id,
+			order_id,
+			COALESCE(from_status, ''),
+			to_status,
+			COALESCE(changed_by::VARCHAR, ''),
+			COALESCE(api_key_id::VARCHAR, ''),
+			COALESCE(reason, ''),
+			TO_CHAR(created_at, 'YYYY-MM-DD HH24-MI-SS')
+		FROM
+			order_status_history
+		WHERE order_id = $1
+		ORDER BY created_at, id
+	`
+
+	rows, err := o.db.Query(ctx, query, req.Id)
+	if err != nil{
+		return nil, err
+	}
+	defer rows.Close()
+
+	resp := &models.GetOrderStatusHistoryResponse{}
+
+	for rows.Next() {
+
+		var history models.OrderStatusHistory
+
+		err = rows.Scan(
+			&history.Id,
+			&history.Order_id,
+			&history.From_status,
+			&history.To_status,
+			&history.Changed_by,
+			&history.Api_key_id,
+			&history.Reason,
+			&history.CreatedAt,
+		)
+		if err != nil{
+			return nil, err
+		}
+
+		resp.History = append(resp.History, &history)
+	}
+
+	resp.Count = len(resp.History)
+
+	return resp, rows.Err()
+}
+
+// PatchOrder sets the fields of req, which must all be in
+// models.OrderPatchFields. Column names come from that list, never from the
+// request.
+func (o *orderRepo) PatchOrder(ctx context.Context, req *models.PatchRequest) (int64, error) {
 
 	if len(req.Fields) <= 0{
 		return 0, errors.New("no fields to update")
 	}
 
-	for key := range req.Fields{
-		set += fmt.Sprintf(" %s = :%s, ", key, key)
+	err := models.CheckPatchFields(req.Fields, models.OrderPatchFields)
+	if err != nil{
+		return 0, &storage.Error{Kind: storage.KindValidation, Message: err.Error()}
 	}
 
-	query = `
+	var (
+		set		string
+		args	[]interface{}
+	)
+
+	for _, column := range models.OrderPatchFields{
+		if value, ok := req.Fields[column]; ok{
+			args = append(args, value)
+			set += fmt.Sprintf(` "%s" = $%d,`, column, len(args))
+		}
+	}
+
+	args = append(args, req.ID)
+
+	query := fmt.Sprintf(`
 			UPDATE
 				orders
 			SET
-		` + set + ` updated_at = now()
-			WHERE id = :id
-	`
+		%s updated_at = now()
+			WHERE id = $%d
+	`, set, len(args))
+
+	tx, err := o.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
 
-	req.Fields["id"] = req.ID
+	result, err := tx.Exec(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	if result.RowsAffected() > 0 {
+		_, latitude := req.Fields["latitude"]
+		_, longtitude := req.Fields["longtitude"]
+		if latitude || longtitude {
+			err = o.applyDeliveryFee(ctx, tx, req.ID)
+			if err != nil {
+				return 0, err
+			}
+		}
 
-	query, args := helper.ReplaceQueryParams(query, req.Fields)
+		err = settleOrderPayment(ctx, tx, req.ID)
+		if err != nil {
+			return 0, err
+		}
+	}
 
-	result, err := o.db.Exec(ctx, query, args...)
+	err = tx.Commit(ctx)
 	if err != nil {
 		return 0, err
 	}
@@ -282,7 +872,35 @@ func (o *orderRepo) PatchOrder(ctx context.Context, req *models.PatchRequest) (i
 
 func (o *orderRepo) DeleteOrder(ctx context.Context, req *models.OrderPrimaryKey) (error) {
 
-	_, err := o.db.Exec(ctx, 
+	tx, err := o.db.Begin(ctx)
+	if err != nil{
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var status string
+
+	err = tx.QueryRow(ctx, "SELECT status FROM orders WHERE id = $1 FOR UPDATE", req.Id).Scan(&status)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil{
+		return err
+	}
+
+	if models.OrderHoldsReservation(status) {
+		err = releaseStock(ctx, tx, req.Id)
+		if err != nil{
+			return err
+		}
+
+		err = refundOrderPayment(ctx, tx, req.Id)
+		if err != nil{
+			return err
+		}
+	}
+
+	_, err = tx.Exec(ctx, 
 		"DELETE FROM orders WHERE id = $1", req.Id,
 	)
 
@@ -290,5 +908,5 @@ func (o *orderRepo) DeleteOrder(ctx context.Context, req *models.OrderPrimaryKey
 		return err
 	}
 
-	return nil
-}
\ No newline at end of file
+	return tx.Commit(ctx)
+}
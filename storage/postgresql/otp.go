@@ -0,0 +1,185 @@
+package postgresql
+
+import (
+	"app/api/models"
+	"app/config"
+	"app/pkg/helper"
+	"app/storage"
+	"context"
+	"errors"
+	"math"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+type otpRepo struct {
+	db  DB
+	cfg *config.Config
+}
+
+func NewOtpRepo(db DB, cfg *config.Config) *otpRepo {
+	return &otpRepo{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+// CreateCustomerOtp stores a hashed one-time code for a phone. Codes for the
+// same phone are serialized and rate limited by the resend interval.
+func (o *otpRepo) CreateCustomerOtp(ctx context.Context, req *models.CreateCustomerOtp) (string, error) {
+
+	tx, err := o.db.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, "SELECT pg_advisory_xact_lock(hashtext($1))", req.Phone)
+	if err != nil {
+		return "", err
+	}
+
+	var elapsed float64
+
+	err = tx.QueryRow(ctx,
+		"SELECT EXTRACT(EPOCH FROM now() - created_at) FROM customer_otps WHERE phone = $1 ORDER BY created_at DESC LIMIT 1",
+		req.Phone,
+	).Scan(&elapsed)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return "", err
+	}
+
+	if err == nil && elapsed < float64(o.cfg.OtpResendInterval) {
+		return "", &storage.OtpTooSoonError{RetryAfter: int(math.Ceil(float64(o.cfg.OtpResendInterval) - elapsed))}
+	}
+
+	id := uuid.New().String()
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO customer_otps(
+			id,
+			phone,
+			code_hash,
+			expires_at
+		) VALUES ($1, $2, $3, now() + make_interval(mins => $4))
+	`,
+		id,
+		req.Phone,
+		req.Code_hash,
+		o.cfg.OtpTTL,
+	)
+	if err != nil {
+		return "", err
+	}
+
+	return id, tx.Commit(ctx)
+}
+
+// VerifyCustomerOtp checks code against the latest active code of phone. On
+// success the code is consumed, the phone of the matching customer is marked
+// verified and the customer's user account is returned, creating the
+// customer and the account on first login.
+func (o *otpRepo) VerifyCustomerOtp(ctx context.Context, req *models.VerifyOtp) (*models.OtpLogin, error) {
+
+	tx, err := o.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var (
+		id       string
+		codeHash string
+		attempts int
+	)
+
+	err = tx.QueryRow(ctx, `
+		SELECT id, code_hash, attempts
+		FROM customer_otps
+		WHERE phone = $1 AND consumed_at IS NULL AND expires_at > now()
+		ORDER BY created_at DESC
+		LIMIT 1
+		FOR UPDATE
+	`, req.Phone).Scan(&id, &codeHash, &attempts)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, storage.ErrOtpNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if attempts >= o.cfg.OtpMaxAttempts {
+		return nil, storage.ErrOtpAttemptsExceeded
+	}
+
+	if !helper.CheckPassword(codeHash, req.Code) {
+
+		_, err = tx.Exec(ctx, "UPDATE customer_otps SET attempts = attempts + 1 WHERE id = $1", id)
+		if err != nil {
+			return nil, err
+		}
+
+		// The failed attempt must be committed, or guesses would be free.
+		err = tx.Commit(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, &storage.InvalidOtpError{AttemptsLeft: o.cfg.OtpMaxAttempts - attempts - 1}
+	}
+
+	_, err = tx.Exec(ctx,
+		"UPDATE customer_otps SET consumed_at = now() WHERE phone = $1 AND consumed_at IS NULL", req.Phone,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	login := &models.OtpLogin{}
+
+	err = tx.QueryRow(ctx, `
+		SELECT id FROM customers
+		WHERE phone = $1 AND deleted_at IS NULL
+		ORDER BY phone_verified_at IS NULL, created_at
+		LIMIT 1
+		FOR UPDATE
+	`, req.Phone).Scan(&login.Customer_id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		login.Customer_id = uuid.New().String()
+		_, err = tx.Exec(ctx,
+			"INSERT INTO customers(id, name, phone, updated_at) VALUES ($1, '', $2, now())",
+			login.Customer_id, req.Phone,
+		)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(ctx,
+		"UPDATE customers SET phone_verified_at = COALESCE(phone_verified_at, now()), updated_at = now() WHERE id = $1",
+		login.Customer_id,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Staff linked to the customer do not sign in by phone, so only customer
+	// users are picked or created.
+	err = tx.QueryRow(ctx,
+		"SELECT id FROM users WHERE customer_id = $1 AND role = 'customer' AND deleted_at IS NULL ORDER BY created_at LIMIT 1", login.Customer_id,
+	).Scan(&login.User_id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		login.User_id = uuid.New().String()
+		_, err = tx.Exec(ctx, `
+			INSERT INTO users(id, name, balance, role, customer_id, updated_at)
+			SELECT $1, COALESCE(NULLIF(name, ''), phone), 0, 'customer', id, now()
+			FROM customers WHERE id = $2
+		`, login.User_id, login.Customer_id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return login, tx.Commit(ctx)
+}
@@ -0,0 +1,21 @@
+package postgresql
+
+import (
+	"app/api/models"
+	"context"
+)
+
+// nextCursor returns the cursor continuing a keyset page of table after the
+// row id. The exact created_at is read back because listed rows only carry
+// it formatted to the second.
+func nextCursor(ctx context.Context, db DB, table string, id string) (string, error) {
+
+	cursor := &models.Cursor{Id: id}
+
+	err := db.QueryRow(ctx, "SELECT created_at FROM "+table+" WHERE id = $1", id).Scan(&cursor.Created_at)
+	if err != nil {
+		return "", err
+	}
+
+	return cursor.String(), nil
+}
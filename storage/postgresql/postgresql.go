@@ -8,11 +8,19 @@ import (
 	// _ "github.com/lib/pq"
 
 	"app/config"
+	"app/migrations"
+	"app/pkg/events"
 	"app/storage"
 )
 
 type Store struct {
-	db   		*pgxpool.Pool
+	db   		DB
+	// pool is nil in the stores of transactions.
+	pool		*pgxpool.Pool
+	cfg			*config.Config
+	events		*events.Bus
+	// publisher is events, or a buffer flushed when the transaction commits.
+	publisher	events.Publisher
 	book 		storage.BookRepoI
 	user 		storage.UserRepoI
 	author 		storage.AuthorRepoI
@@ -21,9 +29,17 @@ type Store struct {
 	product		storage.ProductRepoI
 	category	storage.CategoryRepoI
 	order		storage.OrderRepoI
+	cart		storage.CartRepoI
+	deliveryZone	storage.DeliveryZoneRepoI
+	customerAddress	storage.CustomerAddressRepoI
+	role		storage.RoleRepoI
+	otp			storage.OtpRepoI
+	apiKey		storage.ApiKeyRepoI
+	idempotency	storage.IdempotencyRepoI
 }
 
-func NewConnectPostgresql(cfg *config.Config) (storage.StorageI, error) {
+// NewPool connects to the PostgreSQL database in cfg.
+func NewPool(cfg *config.Config) (*pgxpool.Pool, error) {
 
 	config, err := pgxpool.ParseConfig(fmt.Sprintf(
 		"host=%s user=%s dbname=%s password=%s port=%s sslmode=disable",
@@ -37,27 +53,72 @@ func NewConnectPostgresql(cfg *config.Config) (storage.StorageI, error) {
 		return nil, err
 	}
 
-	pgpool, err := pgxpool.ConnectConfig(context.Background(), config)
+	return pgxpool.ConnectConfig(context.Background(), config)
+}
+
+func NewConnectPostgresql(cfg *config.Config) (storage.StorageI, error) {
+
+	pgpool, err := NewPool(cfg)
 	if err != nil {
 		return nil, err
 	}
 
+	if cfg.MigrateOnStart {
+		err = migrateUp(pgpool)
+		if err != nil {
+			pgpool.Close()
+			return nil, err
+		}
+	}
+
+	bus := events.NewBus(cfg.EventBufferSize)
+	order := NewOrderRepo(pgpool, cfg, bus)
 
 	return &Store{
 		db:   		pgpool,
+		pool:		pgpool,
+		cfg:		cfg,
+		events:		bus,
+		publisher:	bus,
 		book: 		NewBookRepo(pgpool),
 		user: 		NewUserRepo(pgpool),
 		author: 	NewAuthorRepo(pgpool),
 		customer: 	NewCustomerRepo(pgpool),
-		couerier: 	NewCourierRepo(pgpool),
+		couerier: 	NewCourierRepo(pgpool, bus),
 		product: 	NewProductRepoI(pgpool),
 		category: 	NewCategoryRepoI(pgpool),
-		order: 		NewOrderRepo(pgpool),
+		order: 		order,
+		cart:		NewCartRepo(pgpool, order),
+		deliveryZone:	NewDeliveryZoneRepo(pgpool, newDeliveryCalculator(cfg)),
+		customerAddress:	NewCustomerAddressRepo(pgpool),
+		role:		NewRoleRepo(pgpool),
+		otp:		NewOtpRepo(pgpool, cfg),
+		apiKey:		NewApiKeyRepo(pgpool),
+		idempotency:	NewIdempotencyRepo(pgpool, cfg),
 	}, nil
 }
 
+// migrateUp applies the pending embedded migrations.
+func migrateUp(pool *pgxpool.Pool) error {
+
+	migrator, err := NewMigrator(pool, migrations.Postgres())
+	if err != nil {
+		return err
+	}
+
+	_, err = migrator.Up(context.Background())
+
+	return err
+}
+
 func (s *Store) CloseDB() {
-	s.db.Close()
+	if s.pool != nil {
+		s.pool.Close()
+	}
+}
+
+func (s *Store) Events() *events.Bus {
+	return s.events
 }
 
 func (s *Store) Book() storage.BookRepoI {
@@ -98,7 +159,7 @@ func (s *Store) Customer() storage.CustomerRepoI {
 func (s *Store) Courier() storage.CourierRepoI {
 
 	if s.couerier == nil {
-		s.couerier = NewCourierRepo(s.db)
+		s.couerier = NewCourierRepo(s.db, s.publisher)
 	}
 	return s.couerier
 }
@@ -121,8 +182,64 @@ func (s *Store) Category() storage.CategoryRepoI {
 
 func (s *Store) Order() storage.OrderRepoI {
 	if s.order == nil{
-		s.order = NewOrderRepo(s.db)
+		s.order = NewOrderRepo(s.db, s.cfg, s.publisher)
 	}
 
 	return s.order
-}
\ No newline at end of file
+}
+
+func (s *Store) Cart() storage.CartRepoI {
+	if s.cart == nil{
+		s.cart = NewCartRepo(s.db, NewOrderRepo(s.db, s.cfg, s.publisher))
+	}
+
+	return s.cart
+}
+
+func (s *Store) DeliveryZone() storage.DeliveryZoneRepoI {
+	if s.deliveryZone == nil{
+		s.deliveryZone = NewDeliveryZoneRepo(s.db, newDeliveryCalculator(s.cfg))
+	}
+
+	return s.deliveryZone
+}
+
+func (s *Store) CustomerAddress() storage.CustomerAddressRepoI {
+	if s.customerAddress == nil{
+		s.customerAddress = NewCustomerAddressRepo(s.db)
+	}
+
+	return s.customerAddress
+}
+
+func (s *Store) Role() storage.RoleRepoI {
+	if s.role == nil{
+		s.role = NewRoleRepo(s.db)
+	}
+
+	return s.role
+}
+
+func (s *Store) Otp() storage.OtpRepoI {
+	if s.otp == nil{
+		s.otp = NewOtpRepo(s.db, s.cfg)
+	}
+
+	return s.otp
+}
+
+func (s *Store) ApiKey() storage.ApiKeyRepoI {
+	if s.apiKey == nil{
+		s.apiKey = NewApiKeyRepo(s.db)
+	}
+
+	return s.apiKey
+}
+
+func (s *Store) Idempotency() storage.IdempotencyRepoI {
+	if s.idempotency == nil{
+		s.idempotency = NewIdempotencyRepo(s.db, s.cfg)
+	}
+
+	return s.idempotency
+}
@@ -2,18 +2,18 @@ package postgresql
 
 import (
 	"app/api/models"
+	"app/storage"
 	"context"
 	"fmt"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v4/pgxpool"
 )
 
 type productRepo struct{
-	db	*pgxpool.Pool
+	db	DB
 }
 
-func NewProductRepoI(db *pgxpool.Pool) *productRepo{
+func NewProductRepoI(db DB) *productRepo{
 	return &productRepo{
 		db: db,
 	}
@@ -32,8 +32,9 @@ func (p *productRepo) CreateProduct(ctx context.Context, req *models.CreateProdu
 			name,
 			price,
 			category_id,
+			stock,
 			updated_at
-		) VALUES ($1, $2, $3, $4, now())
+		) VALUES ($1, $2, $3, $4, $5, now())
 	`
 
 	_, err := p.db.Exec(ctx, query, 
@@ -41,6 +42,7 @@ func (p *productRepo) CreateProduct(ctx context.Context, req *models.CreateProdu
 		req.Name,
 		req.Price,
 		req.Category_id,
+		req.Stock,
 	)
 	if err != nil{
 		return "", err
@@ -62,20 +64,26 @@ func (p *productRepo) GetByIdProduct(ctx context.Context, req *models.ProductPri
 			name,
 			COALESCE(price, 0),
 			category_id,
+			stock,
+			reserved,
 			TO_CHAR(created_at, 'YYYY-MM-DD HH24-MI-SS'),
-			TO_CHAR(updated_at, 'YYYY-MM-DD HH24-MI-SS')
+			TO_CHAR(updated_at, 'YYYY-MM-DD HH24-MI-SS'),
+			COALESCE(TO_CHAR(deleted_at, 'YYYY-MM-DD HH24-MI-SS'), '')
 		FROM
 			products
-		WHERE id = $1
+		WHERE id = $1 AND ($2 OR deleted_at IS NULL)
 	`
 
-	err := p.db.QueryRow(ctx, query, req.Id).Scan(
+	err := p.db.QueryRow(ctx, query, req.Id, req.Include_deleted).Scan(
 		&product.Id,
 		&product.Name,
 		&product.Price,
 		&product.Category_id,
+		&product.Stock,
+		&product.Reserved,
 		&product.CreatedAt,
 		&product.UpdatedAt,
+		&product.DeletedAt,
 	)
 
 	if err != nil{
@@ -89,7 +97,7 @@ func (p *productRepo) GetListProduct(ctx context.Context, req *models.GetListPro
 
 	var (
 		query		string
-		filter =	" WHERE TRUE"
+		filter =	storage.NewFilter()
 		offset = 	" OFFSET 0"
 		limit = 	" LIMIT 0" 
 	)
@@ -101,14 +109,19 @@ func (p *productRepo) GetListProduct(ctx context.Context, req *models.GetListPro
 			name,
 			COALESCE(price, 0),
 			category_id,
+			stock,
+			reserved,
 			TO_CHAR(created_at, 'YYYY-MM-DD HH24-MI-SS'),
-			TO_CHAR(updated_at, 'YYYY-MM-DD HH24-MI-SS')
+			TO_CHAR(updated_at, 'YYYY-MM-DD HH24-MI-SS'),
+			COALESCE(TO_CHAR(deleted_at, 'YYYY-MM-DD HH24-MI-SS'), '')
 		FROM products
 	`	
 
-	if len(req.Search) > 0{
-		filter += " AND name ILIKE '%' || '" + req.Search + "' || '%' "
-	}  
+	filter.
+		Search(req.Search, "name").
+		Equal("category_id", req.Category_id).
+		Range("price", req.Min_price, req.Max_price).
+		SoftDeleted(req.Include_deleted)
 
 	if req.Offset > 0{
 		offset = fmt.Sprintf(" OFFSET %d", req.Offset)
@@ -118,11 +131,19 @@ func (p *productRepo) GetListProduct(ctx context.Context, req *models.GetListPro
 		limit = fmt.Sprintf(" LIMIT %d", req.Limit)
 	}
 
-	query += filter + offset + limit
+	orderBy, sort, err := storage.OrderBy(req.Sort, models.ProductSortFields, "name")
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Cursor != nil {
+		orderBy, sort = filter.Keyset(req.Cursor), storage.KeysetOrder
+		offset, limit = " OFFSET 0", fmt.Sprintf(" LIMIT %d", req.Limit+1)
+	}
 
-	fmt.Println(query)
+	query += filter.String() + orderBy + offset + limit
 
-	rows, err := p.db.Query(ctx, query)
+	rows, err := p.db.Query(ctx, query, filter.Args()...)
 	if err != nil{
 		return nil, err
 	}
@@ -139,15 +160,29 @@ func (p *productRepo) GetListProduct(ctx context.Context, req *models.GetListPro
 			&product.Name,
 			&product.Price,
 			&product.Category_id,
+			&product.Stock,
+			&product.Reserved,
 			&product.CreatedAt,
 			&product.UpdatedAt,
+			&product.DeletedAt,
 		)
 
 		resp.Products = append(resp.Products, &product)
 	}
 
+	if req.Cursor != nil && len(resp.Products) > req.Limit {
+		resp.Products = resp.Products[:req.Limit]
+
+		resp.Next_cursor, err = nextCursor(ctx, p.db, "products", resp.Products[req.Limit-1].Id)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	resp.Count = len(resp.Products)
 
+	resp.Sort = sort
+
 	return &resp, nil
 
 }
@@ -161,14 +196,16 @@ func (p *productRepo) UpdateProduct(ctx context.Context, req *models.UpdateProdu
 			name = $1,
 			price = $2,
 			category_id = $3,
+			stock = $4,
 			updated_at = now()
-		WHERE id = $4
+		WHERE id = $5 AND deleted_at IS NULL
 	`
 
 	res, err := p.db.Exec(ctx, query,
 		req.Name,
 		req.Price,
 		req.Category_id,
+		req.Stock,
 		req.Id,
 	)
 	if err != nil{
@@ -178,15 +215,14 @@ func (p *productRepo) UpdateProduct(ctx context.Context, req *models.UpdateProdu
 	return res.RowsAffected(), nil
 }
 
+// DeleteProduct soft deletes the product. Orders keep their items of it, but
+// it can no longer be ordered.
 func (p *productRepo) DeleteProduct(ctx context.Context, req *models.ProductPrimaryKey) (error) {
+	return softDelete(ctx, p.db, "products", req.Id)
+}
 
-	_, err := p.db.Exec(ctx,
-		"DELETE FROM products WHERE id = $1", req.Id,
-	)
-
-	if err != nil{
-		return err
-	}
-
-	return nil
-}
\ No newline at end of file
+// RestoreProduct brings back a soft deleted product, unless its category is
+// deleted.
+func (p *productRepo) RestoreProduct(ctx context.Context, req *models.ProductPrimaryKey) (error) {
+	return restore(ctx, p.db, "products", req.Id)
+}
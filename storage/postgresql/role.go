@@ -0,0 +1,146 @@
+package postgresql
+
+import (
+	"app/api/models"
+	"app/pkg/helper"
+	"context"
+)
+
+type roleRepo struct {
+	db DB
+}
+
+func NewRoleRepo(db DB) *roleRepo {
+	return &roleRepo{
+		db: db,
+	}
+}
+
+func (r *roleRepo) GetListRoles(ctx context.Context) (*models.GetListRoleResponse, error) {
+
+	var (
+		resp  = &models.GetListRoleResponse{}
+		roles = make(map[string]*models.Role)
+	)
+
+	query := `
+		SELECT
+			r.name,
+			r.description,
+			COALESCE(rp.permission, ''),
+			COALESCE(rp.scope, '')
+		FROM roles AS r
+		LEFT JOIN role_permissions AS rp ON rp.role = r.name
+		ORDER BY r.name, rp.permission
+	`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+
+		var (
+			role       models.Role
+			permission models.RolePermission
+		)
+
+		err = rows.Scan(&role.Name, &role.Description, &permission.Permission, &permission.Scope)
+		if err != nil {
+			return nil, err
+		}
+
+		current, ok := roles[role.Name]
+		if !ok {
+			current = &role
+			roles[role.Name] = current
+			resp.Roles = append(resp.Roles, current)
+		}
+
+		if len(permission.Permission) > 0 {
+			current.Permissions = append(current.Permissions, &permission)
+		}
+	}
+
+	resp.Count = len(resp.Roles)
+
+	return resp, rows.Err()
+}
+
+// AssignUserRole sets the role of a user and links the user to the courier or
+// customer records their "own" permissions apply to.
+func (r *roleRepo) AssignUserRole(ctx context.Context, req *models.AssignUserRole) (int64, error) {
+
+	query := `
+		UPDATE
+			users
+		SET
+			role = $1,
+			courier_id = $2,
+			customer_id = $3,
+			updated_at = now()
+		WHERE id = $4
+	`
+
+	result, err := r.db.Exec(ctx, query,
+		helper.NewNullString(req.Role),
+		helper.NewNullString(req.Courier_id),
+		helper.NewNullString(req.Customer_id),
+		req.User_id,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// GetPrincipal loads a user with the permissions granted by their role.
+func (r *roleRepo) GetPrincipal(ctx context.Context, req *models.UserPrimaryKey) (*models.Principal, error) {
+
+	principal := &models.Principal{
+		Permissions: make(map[string]string),
+	}
+
+	err := r.db.QueryRow(ctx, `
+		SELECT
+			id,
+			COALESCE(role, ''),
+			COALESCE(courier_id::VARCHAR, ''),
+			COALESCE(customer_id::VARCHAR, '')
+		FROM users
+		WHERE id = $1 AND deleted_at IS NULL
+	`, req.Id).Scan(
+		&principal.User_id,
+		&principal.Role,
+		&principal.Courier_id,
+		&principal.Customer_id,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(ctx,
+		"SELECT permission, scope FROM role_permissions WHERE role = $1", principal.Role,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+
+		var permission, scope string
+
+		err = rows.Scan(&permission, &scope)
+		if err != nil {
+			return nil, err
+		}
+
+		principal.Permissions[permission] = scope
+	}
+
+	return principal, rows.Err()
+}
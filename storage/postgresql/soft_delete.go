@@ -0,0 +1,36 @@
+package postgresql
+
+import (
+	"app/storage"
+	"context"
+	"fmt"
+)
+
+// softDelete marks the row id of table as deleted. A missing or already
+// deleted row is storage.ErrNotFound.
+func softDelete(ctx context.Context, db DB, table, id string) error {
+
+	res, err := db.Exec(ctx,
+		fmt.Sprintf("UPDATE %s SET deleted_at = now() WHERE id = $1 AND deleted_at IS NULL", table), id,
+	)
+	if err != nil {
+		return err
+	}
+
+	if res.RowsAffected() <= 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
+
+// restore brings back the soft deleted row id of table. A row that is not
+// deleted is left as it is.
+func restore(ctx context.Context, db DB, table, id string) error {
+
+	_, err := db.Exec(ctx,
+		fmt.Sprintf("UPDATE %s SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL", table), id,
+	)
+
+	return err
+}
@@ -0,0 +1,161 @@
+package postgresql
+
+import (
+	"app/api/models"
+	"app/storage"
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// reserveStock locks the products referenced by items, checks that enough
+// unreserved stock is left for every one of them and reserves the requested
+// quantities. It returns the current unit price of each product so the caller
+// can snapshot it on the order. A missing or deleted product is reported as
+// a violation of order_items_product_id_fkey. Rows are locked in id order so
+// concurrent orders touching the same products cannot deadlock.
+func reserveStock(ctx context.Context, tx pgx.Tx, items []*models.CreateOrderItem) (map[string]float64, error) {
+
+	var (
+		ids       []string
+		requested = make(map[string]int)
+		prices    = make(map[string]float64)
+		shortages []*models.StockShortage
+	)
+
+	for _, item := range items {
+		if _, ok := requested[item.Product_id]; !ok {
+			ids = append(ids, item.Product_id)
+		}
+		requested[item.Product_id] += item.Quantity
+	}
+
+	sort.Strings(ids)
+
+	query := `
+		SELECT
+			id,
+			price,
+			stock,
+			reserved
+		FROM products
+		WHERE id = ANY($1::UUID[]) AND deleted_at IS NULL
+		ORDER BY id
+		FOR UPDATE
+	`
+
+	rows, err := tx.Query(ctx, query, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+
+		var (
+			id       string
+			price    *float64
+			stock    int
+			reserved int
+		)
+
+		err = rows.Scan(&id, &price, &stock, &reserved)
+		if err != nil {
+			return nil, err
+		}
+
+		if price == nil {
+			return nil, fmt.Errorf("product %s has no price", id)
+		}
+
+		prices[id] = *price
+
+		if available := stock - reserved; available < requested[id] {
+			shortages = append(shortages, &models.StockShortage{
+				Product_id: id,
+				Requested:  requested[id],
+				Available:  available,
+			})
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	for _, id := range ids {
+		if _, ok := prices[id]; !ok {
+			return nil, storage.ForeignKeyViolation("order_items_product_id_fkey")
+		}
+	}
+
+	if len(shortages) > 0 {
+		return nil, &storage.InsufficientStockError{Products: shortages}
+	}
+
+	for _, id := range ids {
+		_, err = tx.Exec(ctx,
+			"UPDATE products SET reserved = reserved + $1, updated_at = now() WHERE id = $2", requested[id], id,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return prices, nil
+}
+
+// releaseStock gives back the stock reserved by an order, e.g. when it is cancelled.
+func releaseStock(ctx context.Context, tx pgx.Tx, orderId string) error {
+	return adjustOrderStock(ctx, tx, orderId, "reserved = reserved - i.quantity")
+}
+
+// consumeStock turns the reservation of a delivered order into an actual decrement of stock.
+func consumeStock(ctx context.Context, tx pgx.Tx, orderId string) error {
+	return adjustOrderStock(ctx, tx, orderId, "stock = stock - i.quantity, reserved = reserved - i.quantity")
+}
+
+// restock puts the items of a delivered order that was returned back on the shelf.
+func restock(ctx context.Context, tx pgx.Tx, orderId string) error {
+	return adjustOrderStock(ctx, tx, orderId, "stock = stock + i.quantity")
+}
+
+func adjustOrderStock(ctx context.Context, tx pgx.Tx, orderId string, set string) error {
+
+	query := `
+		UPDATE
+			products p
+		SET
+			` + set + `,
+			updated_at = now()
+		FROM (
+			SELECT product_id, SUM(quantity) AS quantity
+			FROM order_items
+			WHERE order_id = $1
+			GROUP BY product_id
+		) i
+		WHERE p.id = i.product_id
+	`
+
+	_, err := tx.Exec(ctx, query, orderId)
+
+	return err
+}
+
+// applyStatusStock updates product stock for an order moving between statuses.
+func applyStatusStock(ctx context.Context, tx pgx.Tx, orderId, from, to string) error {
+
+	switch {
+	case to == models.OrderStatusDelivered:
+		return consumeStock(ctx, tx, orderId)
+	case from == models.OrderStatusDelivered && to == models.OrderStatusReturned:
+		return restock(ctx, tx, orderId)
+	case models.OrderHoldsReservation(from) && !models.OrderHoldsReservation(to):
+		return releaseStock(ctx, tx, orderId)
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package postgresql
+
+import (
+	"app/pkg/events"
+	"app/storage"
+	"context"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+)
+
+// DB is implemented by both *pgxpool.Pool and pgx.Tx, so repositories run the
+// same queries in and out of a transaction. Begin on a pgx.Tx starts a
+// savepoint, which lets repositories keep their own transactions.
+type DB interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+// WithTx runs fn with a store whose repositories all work in one transaction.
+// The transaction commits when fn returns nil and rolls back when it returns
+// an error or panics. A top-level transaction is serializable and is run
+// again, up to TxMaxAttempts times, on a serialization failure or deadlock,
+// so fn must be safe to repeat. Called on the store passed to fn, WithTx runs
+// in a savepoint instead. Events are published once the outermost
+// transaction commits.
+func (s *Store) WithTx(ctx context.Context, fn func(storage.StorageI) error) error {
+
+	if s.pool == nil {
+		return s.runTx(ctx, s.db.Begin, fn)
+	}
+
+	begin := func(ctx context.Context) (pgx.Tx, error) {
+		return s.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.Serializable})
+	}
+
+	return s.retryTx(ctx, begin, fn)
+}
+
+// retryTx runs fn in transactions started by begin until one does not fail to
+// serialize or TxMaxAttempts is reached.
+func (s *Store) retryTx(ctx context.Context, begin func(context.Context) (pgx.Tx, error), fn func(storage.StorageI) error) error {
+
+	for attempt := 1; ; attempt++ {
+
+		err := s.runTx(ctx, begin, fn)
+		if attempt >= s.cfg.TxMaxAttempts || !storage.IsSerializationFailure(err) || ctx.Err() != nil {
+			return err
+		}
+	}
+}
+
+func (s *Store) runTx(ctx context.Context, begin func(context.Context) (pgx.Tx, error), fn func(storage.StorageI) error) error {
+
+	tx, err := begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback(ctx)
+			panic(p)
+		}
+	}()
+
+	buffer := &events.Buffer{}
+
+	err = fn(&Store{
+		db:        tx,
+		cfg:       s.cfg,
+		events:    s.events,
+		publisher: buffer,
+	})
+	if err != nil {
+		tx.Rollback(ctx)
+		return err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return err
+	}
+
+	buffer.Flush(s.publisher)
+
+	return nil
+}
@@ -0,0 +1,189 @@
+package postgresql
+
+import (
+	"app/config"
+	"app/storage"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+)
+
+// fakeTx records how a transaction ended. Its other methods are those of the
+// nil pgx.Tx it embeds and are not called by the tests.
+type fakeTx struct {
+	pgx.Tx
+	committed  bool
+	rolledBack bool
+	commitErr  error
+}
+
+func (tx *fakeTx) Commit(ctx context.Context) error {
+	tx.committed = true
+	return tx.commitErr
+}
+
+func (tx *fakeTx) Rollback(ctx context.Context) error {
+	tx.rolledBack = true
+	return nil
+}
+
+// recorder is a publisher keeping the topics of the events it is given.
+type recorder struct {
+	topics []string
+}
+
+func (r *recorder) Publish(topic string, eventType string, data interface{}) {
+	r.topics = append(r.topics, topic)
+}
+
+// newTxStore returns a store that starts fake transactions, and the
+// transactions it started.
+func newTxStore() (*Store, *[]*fakeTx, func(context.Context) (pgx.Tx, error)) {
+
+	store := &Store{
+		cfg:       &config.Config{TxMaxAttempts: 3},
+		publisher: &recorder{},
+	}
+
+	var started []*fakeTx
+
+	begin := func(ctx context.Context) (pgx.Tx, error) {
+		tx := &fakeTx{}
+		started = append(started, tx)
+		return tx, nil
+	}
+
+	return store, &started, begin
+}
+
+func publish(tx storage.StorageI) {
+	tx.(*Store).publisher.Publish("order.1", "order.updated", nil)
+}
+
+func TestWithTxCommit(t *testing.T) {
+
+	store, started, begin := newTxStore()
+
+	err := store.retryTx(context.Background(), begin, func(tx storage.StorageI) error {
+		publish(tx)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(*started) != 1 || !(*started)[0].committed || (*started)[0].rolledBack {
+		t.Fatalf("transaction was not committed alone: %+v", *started)
+	}
+
+	if topics := store.publisher.(*recorder).topics; len(topics) != 1 {
+		t.Fatalf("published %v after commit, want one event", topics)
+	}
+}
+
+func TestWithTxRollbackOnError(t *testing.T) {
+
+	store, started, begin := newTxStore()
+	failure := errors.New("failed")
+
+	err := store.retryTx(context.Background(), begin, func(tx storage.StorageI) error {
+		publish(tx)
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("got error %v, want %v", err, failure)
+	}
+
+	if len(*started) != 1 || (*started)[0].committed || !(*started)[0].rolledBack {
+		t.Fatalf("transaction was not rolled back: %+v", *started)
+	}
+
+	if topics := store.publisher.(*recorder).topics; len(topics) != 0 {
+		t.Fatalf("published %v after rollback", topics)
+	}
+}
+
+func TestWithTxRollbackOnPanic(t *testing.T) {
+
+	store, started, begin := newTxStore()
+
+	defer func() {
+		if p := recover(); p != "boom" {
+			t.Fatalf("recovered %v, want the panic of fn", p)
+		}
+
+		if len(*started) != 1 || (*started)[0].committed || !(*started)[0].rolledBack {
+			t.Fatalf("transaction was not rolled back: %+v", *started)
+		}
+
+		if topics := store.publisher.(*recorder).topics; len(topics) != 0 {
+			t.Fatalf("published %v after a panic", topics)
+		}
+	}()
+
+	store.retryTx(context.Background(), begin, func(tx storage.StorageI) error {
+		publish(tx)
+		panic("boom")
+	})
+}
+
+func TestWithTxRetry(t *testing.T) {
+
+	serialization := &pgconn.PgError{Code: "40001"}
+
+	for _, test := range []struct {
+		name     string
+		failures int
+		err      error
+		attempts int
+	}{
+		{name: "succeeds after serialization failures", failures: 2, err: serialization, attempts: 3},
+		{name: "gives up after TxMaxAttempts", failures: 5, err: serialization, attempts: 3},
+		{name: "deadlock", failures: 1, err: &pgconn.PgError{Code: "40P01"}, attempts: 2},
+		{name: "other errors are not retried", failures: 5, err: errors.New("failed"), attempts: 1},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+
+			store, started, begin := newTxStore()
+			calls := 0
+
+			err := store.retryTx(context.Background(), begin, func(tx storage.StorageI) error {
+				calls++
+				if calls <= test.failures {
+					return test.err
+				}
+				return nil
+			})
+
+			if calls != test.attempts || len(*started) != test.attempts {
+				t.Fatalf("fn ran %d times in %d transactions, want %d", calls, len(*started), test.attempts)
+			}
+
+			if succeeded := test.failures < test.attempts; succeeded != (err == nil) {
+				t.Fatalf("got error %v after %d attempts", err, calls)
+			}
+		})
+	}
+
+	// A transaction failing to commit is run again too.
+	store, started, _ := newTxStore()
+
+	begin := func(ctx context.Context) (pgx.Tx, error) {
+		tx := &fakeTx{}
+		if len(*started) == 0 {
+			tx.commitErr = serialization
+		}
+		*started = append(*started, tx)
+		return tx, nil
+	}
+
+	err := store.retryTx(context.Background(), begin, func(tx storage.StorageI) error {
+		return nil
+	})
+	if err != nil || len(*started) != 2 {
+		t.Fatalf("got error %v after %d transactions", err, len(*started))
+	}
+}
@@ -2,20 +2,23 @@ package postgresql
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/jackc/pgconn"
 
 	"app/api/models"
+	"app/pkg/helper"
+	"app/storage"
 )
 
 type userRepo struct{
-	db *pgxpool.Pool
+	db DB
 }
 
 
-func NewUserRepo(db *pgxpool.Pool) *userRepo{
+func NewUserRepo(db DB) *userRepo{
 	return &userRepo{
 		db: db,
 	}
@@ -29,20 +32,50 @@ func (u *userRepo) CreateUser(ctx context.Context, req *models.CreateUser) (stri
 		id 	= uuid.New().String()
 	)
 
+	tx, err := u.db.Begin(ctx)
+	if err != nil{
+		return "", err
+	}
+	defer tx.Rollback(ctx)
+
 	query = `INSERT INTO users(
 			id,
 			name,
 			balance,
+			login,
+			password_hash,
 			updated_at
 			)
-			VALUES($1, $2, $3, now())
+			VALUES($1, $2, 0, $3, $4, now())
 		`
-	_, err := u.db.Exec(ctx, query, 
+	_, err = tx.Exec(ctx, query, 
 		id,
 		req.Name,
-		req.Balance,
+		helper.NewNullString(req.Login),
+		helper.NewNullString(req.Password_hash),
 	)
 
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return "", storage.ErrLoginTaken
+	}
+	if err != nil{
+		return "", err
+	}
+
+	if req.Balance > 0 {
+		_, err = addBalanceTransaction(ctx, tx, &models.CreateBalanceTransaction{
+			User_id:		id,
+			Type:			models.BalanceTransactionTopUp,
+			Amount:			req.Balance,
+			Description:	"initial balance",
+		})
+		if err != nil{
+			return "", err
+		}
+	}
+
+	err = tx.Commit(ctx)
 	if err != nil{
 		return "", err
 	}
@@ -62,19 +95,29 @@ func (u *userRepo) UserGetByID(ctx context.Context, req *models.UserPrimaryKey)
 			SELECT
 				id,
 				name,
+				COALESCE(login, ''),
+				COALESCE(role, ''),
+				COALESCE(courier_id::VARCHAR, ''),
+				COALESCE(customer_id::VARCHAR, ''),
 				COALESCE(balance, 0),
 				TO_CHAR(created_at, 'YYYY-MM-DD HH24-MI-SS'),
-				TO_CHAR(updated_at, 'YYYY-MM-DD HH24-MI-SS')
+				TO_CHAR(updated_at, 'YYYY-MM-DD HH24-MI-SS'),
+				COALESCE(TO_CHAR(deleted_at, 'YYYY-MM-DD HH24-MI-SS'), '')
 			FROM users
-			WHERE id = $1
+			WHERE id = $1 AND ($2 OR deleted_at IS NULL)
 		`
 	
-	err := u.db.QueryRow(ctx, query, req.Id).Scan(
+	err := u.db.QueryRow(ctx, query, req.Id, req.Include_deleted).Scan(
 		&user.Id,
 		&user.Name,
+		&user.Login,
+		&user.Role,
+		&user.Courier_id,
+		&user.Customer_id,
 		&user.Balance,
 		&user.CreatedAt,
 		&user.UpdatedAt,
+		&user.DeletedAt,
 	)
 
 	if err != nil{
@@ -84,13 +127,30 @@ func (u *userRepo) UserGetByID(ctx context.Context, req *models.UserPrimaryKey)
 	return &user, nil
 }
 
+// GetUserCredentials returns the id and password hash of the user with the
+// given login. Users without a password, and deleted users, cannot log in and
+// are not found.
+func (u *userRepo) GetUserCredentials(ctx context.Context, req *models.LoginRequest) (*models.UserCredentials, error){
+
+	var credentials models.UserCredentials
+
+	err := u.db.QueryRow(ctx,
+		"SELECT id, password_hash FROM users WHERE login = $1 AND password_hash IS NOT NULL AND deleted_at IS NULL", req.Login,
+	).Scan(&credentials.Id, &credentials.Password_hash)
+	if err != nil{
+		return nil, err
+	}
+
+	return &credentials, nil
+}
+
 func (u *userRepo) UserGetList(ctx context.Context, req *models.GetListUserRequest) (*models.GetListUserResponse, error){
 
 	resp := &models.GetListUserResponse{}
 
 	var (
 		query string
-		filter = " WHERE TRUE "
+		filter = storage.NewFilter()
 		offset = " OFFSET 0"
 		limit = " LIMIT 0"
 	)
@@ -100,15 +160,21 @@ func (u *userRepo) UserGetList(ctx context.Context, req *models.GetListUserReque
 			COUNT(*) OVER(),
 			id,
 			name,
+			COALESCE(login, ''),
+			COALESCE(role, ''),
+			COALESCE(courier_id::VARCHAR, ''),
+			COALESCE(customer_id::VARCHAR, ''),
 			COALESCE(balance, 0),
 			TO_CHAR(created_at, 'YYYY-MM-DD HH24-MI-SS'),
-			TO_CHAR(updated_at, 'YYYY-MM-DD HH24-MI-SS')
+			TO_CHAR(updated_at, 'YYYY-MM-DD HH24-MI-SS'),
+			COALESCE(TO_CHAR(deleted_at, 'YYYY-MM-DD HH24-MI-SS'), '')
 		FROM users
 	`
 
-	if len(req.Search) > 0{
-		filter += " AND name ILIKE '%' || '" + req.Search + "' || '%' "
-	}
+	filter.
+		Search(req.Search, "name", "login").
+		Equal("role", req.Role).
+		SoftDeleted(req.Include_deleted)
 
 	if req.Offset > 0{
 		offset = fmt.Sprintf(" OFFSET %d", req.Offset)
@@ -118,9 +184,19 @@ func (u *userRepo) UserGetList(ctx context.Context, req *models.GetListUserReque
 		limit = fmt.Sprintf(" LIMIT %d", req.Limit)
 	}
 
-	query += filter + offset + limit
+	orderBy, sort, err := storage.OrderBy(req.Sort, models.UserSortFields, "name")
+	if err != nil {
+		return nil, err
+	}
 
-	rows, err := u.db.Query(ctx, query)
+	if req.Cursor != nil {
+		orderBy, sort = filter.Keyset(req.Cursor), storage.KeysetOrder
+		offset, limit = " OFFSET 0", fmt.Sprintf(" LIMIT %d", req.Limit+1)
+	}
+
+	query += filter.String() + orderBy + offset + limit
+
+	rows, err := u.db.Query(ctx, query, filter.Args()...)
 	if err != nil{
 		return nil, err
 	}
@@ -133,9 +209,14 @@ func (u *userRepo) UserGetList(ctx context.Context, req *models.GetListUserReque
 			&resp.Count,
 			&user.Id,
 			&user.Name,
+			&user.Login,
+			&user.Role,
+			&user.Courier_id,
+			&user.Customer_id,
 			&user.Balance,
 			&user.CreatedAt,
 			&user.UpdatedAt,
+			&user.DeletedAt,
 		)
 
 		resp.Users = append(resp.Users, &user)
@@ -143,6 +224,17 @@ func (u *userRepo) UserGetList(ctx context.Context, req *models.GetListUserReque
 	}
 
 
+	if req.Cursor != nil && len(resp.Users) > req.Limit {
+		resp.Users = resp.Users[:req.Limit]
+
+		resp.Next_cursor, err = nextCursor(ctx, u.db, "users", resp.Users[req.Limit-1].Id)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	resp.Sort = sort
+
 	return resp, nil
 }
 
@@ -154,14 +246,12 @@ func (u *userRepo) UpdateUser(ctx context.Context, req *models.UpdateUser) (int6
 			users
 		SET
 			name = $1,
-			balance = $2,
 			updated_at = now()
-		WHERE id = $3
+		WHERE id = $2 AND deleted_at IS NULL
 	`
 
 	result, err := u.db.Exec(ctx, query, 
 		req.Name,
-		req.Balance,
 		req.Id,
 	)
 
@@ -173,15 +263,12 @@ func (u *userRepo) UpdateUser(ctx context.Context, req *models.UpdateUser) (int6
 }
 
 
+// DeleteUser soft deletes the user, who can then no longer log in or use
+// their tokens. Their orders and balance are kept.
 func (u *userRepo) DeleteUser(ctx context.Context, req *models.UserPrimaryKey) error {
+	return softDelete(ctx, u.db, "users", req.Id)
+}
 
-	_, err := u.db.Exec(
-		ctx, "DELETE FROM users WHERE id = $1", req.Id,
-	)
-
-	if err != nil{
-		return err
-	}
-
-	return nil
-}
\ No newline at end of file
+func (u *userRepo) RestoreUser(ctx context.Context, req *models.UserPrimaryKey) error {
+	return restore(ctx, u.db, "users", req.Id)
+}
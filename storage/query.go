@@ -0,0 +1,192 @@
+package storage
+
+import (
+	"app/api/models"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Filter builds a parameterized WHERE clause. Values are never written into
+// the SQL text: each one is bound as a $n placeholder and returned by Args.
+type Filter struct {
+	conditions []string
+	args       []interface{}
+}
+
+// NewFilter returns a Filter with no conditions, matching every row.
+func NewFilter() *Filter {
+	return &Filter{}
+}
+
+// Arg binds value and returns its placeholder, for building conditions or
+// other clauses by hand.
+func (f *Filter) Arg(value interface{}) string {
+	f.args = append(f.args, value)
+	return fmt.Sprintf("$%d", len(f.args))
+}
+
+// Where adds condition, in which every "?" is replaced by a placeholder bound
+// to the next value of args.
+func (f *Filter) Where(condition string, args ...interface{}) *Filter {
+
+	var (
+		builder strings.Builder
+		next    int
+	)
+
+	for _, r := range condition {
+		if r == '?' && next < len(args) {
+			builder.WriteString(f.Arg(args[next]))
+			next++
+			continue
+		}
+		builder.WriteRune(r)
+	}
+
+	f.conditions = append(f.conditions, builder.String())
+
+	return f
+}
+
+// Search matches rows where any of columns contains value, case-insensitively.
+// An empty value adds no condition. LIKE wildcards in value match literally.
+func (f *Filter) Search(value string, columns ...string) *Filter {
+
+	if len(value) <= 0 || len(columns) <= 0 {
+		return f
+	}
+
+	placeholder := f.Arg(EscapeLike(value))
+
+	matches := make([]string, 0, len(columns))
+	for _, column := range columns {
+		matches = append(matches, fmt.Sprintf("%s ILIKE '%%' || %s || '%%'", column, placeholder))
+	}
+
+	f.conditions = append(f.conditions, "("+strings.Join(matches, " OR ")+")")
+
+	return f
+}
+
+// Equal matches rows where column equals value. An empty value adds no
+// condition.
+func (f *Filter) Equal(column string, value string) *Filter {
+
+	if len(value) <= 0 {
+		return f
+	}
+
+	return f.Where(column+" = ?", value)
+}
+
+// Range matches rows where column lies between from and to, both inclusive.
+// A nil or zero bound, such as 0 or "", is left open.
+func (f *Filter) Range(column string, from, to interface{}) *Filter {
+
+	if !isZero(from) {
+		f.Where(column+" >= ?", from)
+	}
+
+	if !isZero(to) {
+		f.Where(column+" <= ?", to)
+	}
+
+	return f
+}
+
+// SoftDeleted leaves out rows marked by a deleted_at column, unless include
+// is set.
+func (f *Filter) SoftDeleted(include bool) *Filter {
+
+	if include {
+		return f
+	}
+
+	return f.Where("deleted_at IS NULL")
+}
+
+func isZero(value interface{}) bool {
+	return value == nil || reflect.ValueOf(value).IsZero()
+}
+
+// String returns the WHERE clause, with a leading space.
+func (f *Filter) String() string {
+
+	if len(f.conditions) <= 0 {
+		return " WHERE TRUE "
+	}
+
+	return " WHERE " + strings.Join(f.conditions, " AND ") + " "
+}
+
+// Args returns the values bound to the placeholders of the clause.
+func (f *Filter) Args() []interface{} {
+	return f.args
+}
+
+// EscapeLike escapes the LIKE wildcards in s, so it matches literally.
+func EscapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// OrderBy returns the ORDER BY clause for sort, falling back to defaultSort
+// when sort is empty, and the applied ordering in the format of
+// models.ParseSort. Rows are finally ordered by id, so pages are stable when
+// the sort fields tie. Fields outside allowed are rejected, as they are
+// written into the SQL text.
+func OrderBy(sort []models.SortField, allowed []string, defaultSort string) (string, string, error) {
+
+	if len(sort) <= 0 {
+		parsed, err := models.ParseSort(defaultSort, allowed)
+		if err != nil {
+			return "", "", err
+		}
+		sort = parsed
+	}
+
+	columns := make([]string, 0, len(sort)+1)
+
+	for _, field := range sort {
+
+		if !isAllowed(allowed, field.Field) {
+			return "", "", &Error{Kind: KindValidation, Message: fmt.Sprintf("cannot sort by %q", field.Field)}
+		}
+
+		if field.Desc {
+			columns = append(columns, field.Field+" DESC")
+		} else {
+			columns = append(columns, field.Field+" ASC")
+		}
+	}
+
+	applied := append(sort[:len(sort):len(sort)], models.SortField{Field: "id"})
+
+	return " ORDER BY " + strings.Join(append(columns, "id ASC"), ", ") + " ", models.FormatSort(applied), nil
+}
+
+func isAllowed(allowed []string, field string) bool {
+
+	for _, a := range allowed {
+		if a == field {
+			return true
+		}
+	}
+
+	return false
+}
+
+// KeysetOrder is the ordering of lists paged with a cursor: newest first, with
+// id breaking ties.
+const KeysetOrder = "-created_at,-id"
+
+// Keyset restricts rows to those after cursor in KeysetOrder and returns the
+// matching ORDER BY clause. The zero cursor starts at the newest row.
+func (f *Filter) Keyset(cursor *models.Cursor) string {
+
+	if !cursor.IsZero() {
+		f.Where("(created_at, id) < (?, ?)", cursor.Created_at, cursor.Id)
+	}
+
+	return " ORDER BY created_at DESC, id DESC "
+}
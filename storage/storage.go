@@ -2,11 +2,14 @@ package storage
 
 import (
 	"app/api/models"
+	"app/pkg/events"
+	"app/pkg/geo"
 	"context"
 )
 
 type StorageI interface {
 	CloseDB()
+	Events()	*events.Bus
 	Book() 		BookRepoI
 	User() 		UserRepoI
 	Author() 	AuthorRepoI
@@ -15,6 +18,16 @@ type StorageI interface {
 	Product()	ProductRepoI
 	Category()	CategoryRepoI
 	Order()		OrderRepoI
+	Cart()		CartRepoI
+	DeliveryZone()	DeliveryZoneRepoI
+	CustomerAddress()	CustomerAddressRepoI
+	Role()		RoleRepoI
+	Otp()		OtpRepoI
+	ApiKey()	ApiKeyRepoI
+	Idempotency()	IdempotencyRepoI
+	// WithTx runs fn with a StorageI whose repositories share one
+	// transaction, committed when fn returns nil and rolled back otherwise.
+	WithTx(context.Context, func(StorageI) error) error
 }
 
 type BookRepoI interface {
@@ -23,14 +36,20 @@ type BookRepoI interface {
 	GetList(context.Context, *models.GetListBookRequest) (*models.GetListBookResponse, error)
 	Update(context.Context, *models.UpdateBook) (int64, error)
 	Delete(context.Context, *models.BookPrimaryKey) error
+	Restore(context.Context, *models.BookPrimaryKey) error
 }
 
 type UserRepoI interface{
 	CreateUser(context.Context, *models.CreateUser) (string, error)
 	UpdateUser(context.Context, *models.UpdateUser) (int64, error)
 	DeleteUser(context.Context, *models.UserPrimaryKey) error
+	RestoreUser(context.Context, *models.UserPrimaryKey) error
 	UserGetByID(context.Context, *models.UserPrimaryKey) (*models.User, error)
 	UserGetList(context.Context, *models.GetListUserRequest) (*models.GetListUserResponse, error)
+	GetUserCredentials(context.Context, *models.LoginRequest) (*models.UserCredentials, error)
+	CreateBalanceTransaction(context.Context, *models.CreateBalanceTransaction) (string, error)
+	GetByIdBalanceTransaction(context.Context, *models.BalanceTransactionPrimaryKey) (*models.BalanceTransaction, error)
+	GetListBalanceTransactions(context.Context, *models.GetListBalanceTransactionRequest) (*models.GetListBalanceTransactionResponse, error)
 }
 
 type AuthorRepoI interface {
@@ -39,7 +58,8 @@ type AuthorRepoI interface {
 	GetListAuthor(context.Context, *models.GetListAuthorRequest) (*models.GetListAuthorResponse, error)
 	UpdateAuthor(context.Context, *models.UpdateAuthor) (int64, error)
 	DeleteAuthor(context.Context, *models.AuthorPrimaryKey) error
-	
+	RestoreAuthor(context.Context, *models.AuthorPrimaryKey) error
+
 }
 
 type CustomerRepoI interface {
@@ -48,6 +68,7 @@ type CustomerRepoI interface {
 	GetListCustomer(context.Context, *models.GetListCustomerRequest) (*models.GetListCustomerResponse, error)
 	UpdateCustomer(context.Context, *models.UpdateCustomer) (int64, error)
 	DeleteCustomer(context.Context, *models.CustomerPrimaryKey) (error)
+	RestoreCustomer(context.Context, *models.CustomerPrimaryKey) (error)
 }
 
 type CourierRepoI interface {
@@ -56,6 +77,10 @@ type CourierRepoI interface {
 	GetListCourier(context.Context, *models.GetListCourierRequest) (*models.GetListCourierResponse, error)
 	UpdateCourier(context.Context, *models.UpdateCourier) (int64, error)
 	DeleteCourier(context.Context, *models.CourierPrimaryKey) (error)
+	RestoreCourier(context.Context, *models.CourierPrimaryKey) (error)
+	CreateCourierLocation(context.Context, *models.CreateCourierLocation) (int64, error)
+	GetCourierLocation(context.Context, *models.CourierPrimaryKey) (*models.CourierLocation, error)
+	GetCourierTrack(context.Context, *models.GetCourierTrackRequest) (*models.GetCourierTrackResponse, error)
 }
 
 type ProductRepoI interface {
@@ -64,6 +89,7 @@ type ProductRepoI interface {
 	GetListProduct(context.Context, *models.GetListProductRequest) (*models.GetListProductResponse, error)
 	UpdateProduct(context.Context, *models.UpdateProduct) (int64, error)
 	DeleteProduct(context.Context, *models.ProductPrimaryKey) (error)
+	RestoreProduct(context.Context, *models.ProductPrimaryKey) (error)
 }
 
 type CategoryRepoI interface {
@@ -72,6 +98,7 @@ type CategoryRepoI interface {
 	GetListCategory(context.Context, *models.GetListCatogoryRequest) (*models.GetListCategoryResponse, error)
 	UpdateCategory(context.Context, *models.UpdateCategory) (int64, error)
 	DeleteCategory(context.Context, *models.CategoryPrimaryKey) (error)
+	RestoreCategory(context.Context, *models.CategoryPrimaryKey) (error)
 }
 
 type OrderRepoI interface {
@@ -80,5 +107,59 @@ type OrderRepoI interface {
 	GetListOrders(context.Context, *models.GetListOrderRequest) (*models.GetListOrderResponse, error)
 	UpdateOrder(context.Context,*models.UpdateOrder) (int64, error)
 	PatchOrder(context.Context, *models.PatchRequest) (int64, error)
+	UpdateOrderStatus(context.Context, *models.UpdateOrderStatus) (int64, error)
+	GetOrderStatusHistory(context.Context, *models.OrderPrimaryKey) (*models.GetOrderStatusHistoryResponse, error)
+	AssignCourier(context.Context, *models.OrderPrimaryKey) (int64, error)
 	DeleteOrder(context.Context,*models.OrderPrimaryKey) (error)
-}
\ No newline at end of file
+}
+
+type CartRepoI interface {
+	CreateCart(context.Context, *models.CreateCart) (string, error)
+	GetByIdCart(context.Context, *models.CartPrimaryKey) (*models.Cart, error)
+	AddCartItem(context.Context, *models.AddCartItem) (int64, error)
+	UpdateCartItem(context.Context, *models.UpdateCartItem) (int64, error)
+	DeleteCartItem(context.Context, *models.CartItemPrimaryKey) (error)
+	Checkout(context.Context, *models.CheckoutCart) (string, error)
+}
+
+type DeliveryZoneRepoI interface {
+	CreateDeliveryZone(context.Context, *models.CreateDeliveryZone) (string, error)
+	GetByIdDeliveryZone(context.Context, *models.DeliveryZonePrimaryKey) (*models.DeliveryZone, error)
+	GetListDeliveryZone(context.Context, *models.GetListDeliveryZoneRequest) (*models.GetListDeliveryZoneResponse, error)
+	UpdateDeliveryZone(context.Context, *models.UpdateDeliveryZone) (int64, error)
+	DeleteDeliveryZone(context.Context, *models.DeliveryZonePrimaryKey) (error)
+	QuoteDelivery(context.Context, geo.Point) (*models.DeliveryQuote, error)
+}
+
+type CustomerAddressRepoI interface {
+	CreateCustomerAddress(context.Context, *models.CreateCustomerAddress) (string, error)
+	GetByIdCustomerAddress(context.Context, *models.CustomerAddressPrimaryKey) (*models.CustomerAddress, error)
+	GetListCustomerAddress(context.Context, *models.GetListCustomerAddressRequest) (*models.GetListCustomerAddressResponse, error)
+	UpdateCustomerAddress(context.Context, *models.UpdateCustomerAddress) (int64, error)
+	DeleteCustomerAddress(context.Context, *models.CustomerAddressPrimaryKey) (error)
+}
+
+type RoleRepoI interface {
+	GetListRoles(context.Context) (*models.GetListRoleResponse, error)
+	AssignUserRole(context.Context, *models.AssignUserRole) (int64, error)
+	GetPrincipal(context.Context, *models.UserPrimaryKey) (*models.Principal, error)
+}
+
+type OtpRepoI interface {
+	CreateCustomerOtp(context.Context, *models.CreateCustomerOtp) (string, error)
+	VerifyCustomerOtp(context.Context, *models.VerifyOtp) (*models.OtpLogin, error)
+}
+
+type ApiKeyRepoI interface {
+	CreateApiKey(context.Context, *models.CreateApiKey) (string, error)
+	GetByIdApiKey(context.Context, *models.ApiKeyPrimaryKey) (*models.ApiKey, error)
+	GetListApiKey(context.Context, *models.GetListApiKeyRequest) (*models.GetListApiKeyResponse, error)
+	RevokeApiKey(context.Context, *models.ApiKeyPrimaryKey) (int64, error)
+	AuthenticateApiKey(ctx context.Context, keyHash string) (*models.ApiKeyAuth, error)
+}
+
+type IdempotencyRepoI interface {
+	BeginIdempotentRequest(context.Context, *models.IdempotencyKey) (*models.IdempotentResponse, error)
+	FinishIdempotentRequest(context.Context, *models.IdempotencyKey, *models.IdempotentResponse) error
+	ReleaseIdempotentRequest(context.Context, *models.IdempotencyKey) error
+}